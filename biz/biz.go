@@ -66,7 +66,7 @@ func SetupComs(args *config.CmdArgs) *errs.Error {
 	}
 	var logCores []zapcore.Core
 	if core.LiveMode {
-		logCores = append(logCores, rpc.NewExcNotify())
+		logCores = append(logCores, rpc.NewExcNotify(), rpc.NewLogSub())
 		if args.Logfile == "" {
 			args.Logfile = filepath.Join(config.GetLogsDir(), config.Name+".log")
 		}
@@ -174,26 +174,10 @@ InitOdSubs 为所有策略OnOrderChange注册订单事件监听。
 只需在LoadStratJobs后调用一次，交易的Accounts不变就始终生效
 */
 func InitOdSubs() {
-	// 这里只调用成员函数，不读取变量，所以每个策略只存储一个实例即可
-	var subStgys = map[string]*strat.TradeStrat{}
-	for _, items := range strat.PairStrats {
-		for stgName, stagy := range items {
-			if stagy.OnOrderChange != nil || stagy.HedgeOff {
-				subStgys[stgName] = stagy
-			}
-		}
-	}
-	if len(subStgys) == 0 {
-		return
-	}
+	// The strategy is read from job, so strategies added by hot-reloading are also supported
+	// 从job读取策略，以便支持热加载新增的策略
 	for acc := range strat.AccJobs {
 		strat.AddOdSub(acc, func(acc string, od *ormo.InOutOrder, evt int) {
-			stgy, ok := subStgys[od.Strategy]
-			if !ok {
-				// The current strategy does not monitor order status
-				// 当前策略未监听订单状态
-				return
-			}
 			items, _ := strat.AccJobs[acc]
 			if len(items) == 0 {
 				return
@@ -204,25 +188,32 @@ func InitOdSubs() {
 				return
 			}
 			job, _ := its[od.Strategy]
-			if job != nil {
-				if stgy.HedgeOff && evt == strat.OdChgEnterFill {
-					// 策略为单向持仓，成交时尝试关闭另一侧订单
-					closeSideOrders(job, !od.Short)
-				}
-				if stgy.OnOrderChange != nil {
-					if evt == strat.OdChgExitFill {
-						openOds, lock := ormo.GetOpenODs(acc)
-						lock.Lock()
-						job.UpdateOrders(utils.ValsOfMap(openOds))
-						lock.Unlock()
-					}
-					stgy.OnOrderChange(job, od, evt)
+			if job == nil {
+				return
+			}
+			stgy := job.Strat
+			if stgy.OnOrderChange == nil && !stgy.HedgeOff {
+				// The current strategy does not monitor order status
+				// 当前策略未监听订单状态
+				return
+			}
+			if stgy.HedgeOff && evt == strat.OdChgEnterFill {
+				// 策略为单向持仓，成交时尝试关闭另一侧订单
+				closeSideOrders(job, !od.Short)
+			}
+			if stgy.OnOrderChange != nil {
+				if evt == strat.OdChgExitFill {
+					openOds, lock := ormo.GetOpenODs(acc)
+					lock.Lock()
+					job.UpdateOrders(utils.ValsOfMap(openOds))
+					lock.Unlock()
 				}
-				if len(job.Entrys) > 0 || len(job.Exits) > 0 {
-					_, _, err := GetOdMgr(acc).ProcessOrders(nil, job)
-					if err != nil {
-						log.Error("process orders fail", zap.Error(err))
-					}
+				stgy.OnOrderChange(job, od, evt)
+			}
+			if len(job.Entrys) > 0 || len(job.Exits) > 0 {
+				_, _, err := GetOdMgr(acc).ProcessOrders(nil, job)
+				if err != nil {
+					log.Error("process orders fail", zap.Error(err))
 				}
 			}
 		})
@@ -390,6 +381,11 @@ func ResetVars() {
 	strat.BatchTasks = make(map[string]*strat.BatchMap)
 	strat.ForbidJobs = make(map[string]map[string]bool)
 	strat.LastBatchMS = 0
+	strat.MemStates = make(map[string]*ormo.StratState)
+	strat.PlotSeries = make(map[string]map[string]*strat.PlotLine)
+	lockPaper.Lock()
+	paperQueues = make(map[string]*paperQueue)
+	lockPaper.Unlock()
 }
 
 type VarsBackup struct {
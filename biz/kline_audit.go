@@ -0,0 +1,434 @@
+package biz
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banbot/exg"
+	"github.com/banbox/banbot/orm"
+	"github.com/banbox/banbot/utils"
+	"github.com/banbox/banexg"
+	"github.com/banbox/banexg/errs"
+	"github.com/banbox/banexg/log"
+	utils2 "github.com/banbox/banexg/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	AuditOHLC      = "ohlc"     // OHLC invariant violated OHLC关系不成立
+	AuditDupTime   = "dup_time" // duplicated or unordered timestamp 时间戳重复或乱序
+	AuditOutlier   = "outlier"  // return beyond N sigma 收益率超过N倍标准差
+	AuditZeroVol   = "zero_vol" // run of zero-volume bars 连续零成交量
+	AuditStale     = "stale"    // run of repeated closes 连续相同收盘价
+	AuditAggDiff   = "agg_diff" // mismatch with aggregation from 1m 与1m聚合结果不一致
+	auditMinRun    = 3          // min length of zero-volume runs 零成交量最小连续数
+	auditStaleRun  = 5          // min length of stale close runs 相同收盘价最小连续数
+	auditMinBars   = 30         // min bars to calculate sigma 计算标准差的最少bar数
+	auditChunkNum  = 10000      // bars per query 每次查询bar数
+	auditAggTol    = 1e-6       // relative tolerance for aggregation prices 聚合价格相对误差
+	auditVolTol    = 0.01       // relative tolerance for aggregation volume 聚合成交量相对误差
+	auditRepairGap = 100        // merge repair ranges whose gap is less than this bars 间隔小于此bar数的修复区间合并
+)
+
+/*
+KlineIssue
+A data quality problem found in klines
+K线中发现的数据质量问题
+*/
+type KlineIssue struct {
+	Pair      string
+	TimeFrame string
+	Time      int64
+	EndTime   int64 // time of the last flagged bar, later than Time for runs 最后一个有问题bar的时间，连续区间时晚于Time
+	Kind      string
+	Detail    string
+	Repaired  bool
+}
+
+/*
+AuditKlines
+Scan klines of pairs and timeframes in time range, flag OHLC invariant violations, outlier returns, zero-volume runs,
+stale closes and mismatches with aggregation from 1m. Write a csv report, and re-download flagged bars when repair.
+扫描时间范围内品种各周期的K线，标记OHLC关系错误、异常收益率、连续零成交量、收盘价不变以及与1m聚合不一致的情况。
+输出csv报告，repair时重新下载有问题的bar
+*/
+func AuditKlines(args *config.CmdArgs) *errs.Error {
+	if len(args.TimeFrames) == 0 {
+		return errs.NewMsg(errs.CodeParamRequired, "--timeframes is required")
+	}
+	if args.Sigma <= 0 {
+		args.Sigma = 8
+	}
+	pairs := args.Pairs
+	if len(pairs) == 0 {
+		for _, exs := range orm.GetAllExSymbols() {
+			if exs.Exchange == core.ExgName && exs.Market == core.Market {
+				pairs = append(pairs, exs.Symbol)
+			}
+		}
+		if len(pairs) == 0 {
+			return errs.NewMsg(errs.CodeParamRequired, "--pairs is required")
+		}
+		sort.Strings(pairs)
+	}
+	outPath := args.OutPath
+	if outPath == "" {
+		outPath = filepath.Join(config.GetDataDir(), "kline_audit.csv")
+	}
+	sess, conn, err := orm.Conn(nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	start, stop := config.TimeRange.StartMS, config.TimeRange.EndMS
+	log.Info("audit kline", zap.Strings("tf", args.TimeFrames), zap.Int("num", len(pairs)),
+		zap.String("dt", btime.ToDateStr(start, "")+" - "+btime.ToDateStr(stop, "")),
+		zap.Float64("sigma", args.Sigma), zap.Bool("repair", args.Repair))
+	pBar := utils.NewPrgBar(len(pairs)*len(args.TimeFrames), "Audit")
+	defer pBar.Close()
+	var issues []*KlineIssue
+	for _, pair := range pairs {
+		exs, err := orm.GetExSymbolCur(pair)
+		if err != nil {
+			log.Warn("audit skip pair", zap.String("pair", pair), zap.Error(err))
+			pBar.Add(len(args.TimeFrames))
+			continue
+		}
+		for _, tf := range args.TimeFrames {
+			items, err := auditPairTF(sess, exs, tf, start, stop, args.Sigma)
+			pBar.Add(1)
+			if err != nil {
+				log.Warn("audit fail", zap.String("pair", pair), zap.String("tf", tf), zap.Error(err))
+				continue
+			}
+			if args.Repair && len(items) > 0 {
+				if !orm.IsStoredTF(tf) {
+					log.Warn("repair skipped for aggregated timeframe", zap.String("tf", tf))
+				} else {
+					err = repairKlines(sess, exs, tf, items)
+					if err != nil {
+						log.Warn("repair fail", zap.String("pair", pair), zap.String("tf", tf), zap.Error(err))
+					}
+				}
+			}
+			issues = append(issues, items...)
+		}
+	}
+	err = writeAuditCsv(outPath, issues)
+	if err != nil {
+		return err
+	}
+	kindNums := make(map[string]int)
+	for _, it := range issues {
+		kindNums[it.Kind] += 1
+	}
+	log.Info("audit kline done", zap.Int("issues", len(issues)), zap.Any("kinds", kindNums),
+		zap.String("out", outPath))
+	return nil
+}
+
+func auditPairTF(sess *orm.Queries, exs *orm.ExSymbol, tf string, start, stop int64, sigma float64) ([]*KlineIssue, *errs.Error) {
+	tfMSecs := int64(utils2.TFToSecs(tf) * 1000)
+	chunkMS := tfMSecs * auditChunkNum
+	var res []*KlineIssue
+	for curMS := start; curMS < stop; curMS += chunkMS {
+		endMS := min(stop, curMS+chunkMS)
+		bars, err := sess.QueryOHLCV(exs, tf, curMS, endMS, 0, false)
+		if err != nil {
+			return nil, err
+		}
+		items := auditBars(bars, sigma)
+		if tf != "1m" && len(bars) > 0 {
+			subs, err := sess.QueryOHLCV(exs, "1m", curMS, endMS, 0, false)
+			if err != nil {
+				return nil, err
+			}
+			if len(subs) > 0 {
+				built, _ := orm.BuildSessOHLCV(exs, subs, tfMSecs, 0, nil, 60000, orm.GetAlignOff(exs.ID, tfMSecs))
+				items = append(items, auditAgg(bars, built)...)
+			}
+		}
+		for _, it := range items {
+			it.Pair = exs.Symbol
+			it.TimeFrame = tf
+		}
+		res = append(res, items...)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Time < res[j].Time
+	})
+	return res, nil
+}
+
+/*
+auditBars
+Check bars for OHLC invariants, timestamps, return outliers beyond sigma, zero-volume runs and stale closes.
+检查bar的OHLC关系、时间戳、超过sigma的异常收益率、连续零成交量和收盘价不变
+*/
+func auditBars(bars []*banexg.Kline, sigma float64) []*KlineIssue {
+	var res []*KlineIssue
+	add := func(time int64, kind, detail string) {
+		res = append(res, &KlineIssue{Time: time, EndTime: time, Kind: kind, Detail: detail})
+	}
+	addRun := func(start, stop int, kind, detail string) {
+		res = append(res, &KlineIssue{Time: bars[start].Time, EndTime: bars[stop-1].Time, Kind: kind, Detail: detail})
+	}
+	rets := make([]float64, 0, len(bars))
+	for i, b := range bars {
+		if b.Open <= 0 || b.High <= 0 || b.Low <= 0 || b.Close <= 0 || b.Volume < 0 ||
+			b.High < max(b.Open, b.Close) || b.Low > min(b.Open, b.Close) {
+			add(b.Time, AuditOHLC, fmt.Sprintf("o:%v h:%v l:%v c:%v v:%v", b.Open, b.High, b.Low, b.Close, b.Volume))
+		}
+		if i == 0 {
+			continue
+		}
+		prev := bars[i-1]
+		if b.Time <= prev.Time {
+			add(b.Time, AuditDupTime, fmt.Sprintf("prev: %v", prev.Time))
+		}
+		if prev.Close > 0 && b.Close > 0 {
+			rets = append(rets, math.Log(b.Close/prev.Close))
+		} else {
+			rets = append(rets, 0)
+		}
+	}
+	zeroRuns := findRuns(len(bars), func(i int) bool {
+		return bars[i].Volume == 0
+	}, auditMinRun)
+	for _, r := range zeroRuns {
+		addRun(r[0], r[1], AuditZeroVol, fmt.Sprintf("%d bars", r[1]-r[0]))
+	}
+	// bar i continues a stale run when its close equals the previous one 收盘价等于前一个时bar i延续不变区间
+	staleRuns := findRuns(len(bars), func(i int) bool {
+		return i > 0 && bars[i].Close == bars[i-1].Close
+	}, auditStaleRun-1)
+	for _, r := range staleRuns {
+		start, stop := r[0]-1, r[1]
+		hasVol := false
+		for _, b := range bars[start:stop] {
+			if b.Volume > 0 {
+				hasVol = true
+				break
+			}
+		}
+		if hasVol {
+			// runs without volume are reported as zero_vol 无成交量的区间已作为zero_vol报告
+			addRun(start, stop, AuditStale, fmt.Sprintf("%d bars close %v", stop-start, bars[start].Close))
+		}
+	}
+	if len(rets) >= auditMinBars {
+		mean, std := meanStd(rets)
+		if std > 0 {
+			for i, r := range rets {
+				dev := math.Abs(r-mean) / std
+				if dev > sigma {
+					add(bars[i+1].Time, AuditOutlier, fmt.Sprintf("ret %.4f%%, %.1f sigma", (math.Exp(r)-1)*100, dev))
+				}
+			}
+		}
+	}
+	return res
+}
+
+/*
+auditAgg
+Compare bars with the ones aggregated from 1m
+将bar与从1m聚合得到的bar对比
+*/
+func auditAgg(bars, built []*banexg.Kline) []*KlineIssue {
+	var res []*KlineIssue
+	builtMap := make(map[int64]*banexg.Kline, len(built))
+	for _, b := range built {
+		builtMap[b.Time] = b
+	}
+	priceDiff := func(a, b float64) bool {
+		return math.Abs(a-b) > max(math.Abs(a), math.Abs(b))*auditAggTol
+	}
+	for _, b := range bars {
+		s, ok := builtMap[b.Time]
+		if !ok {
+			res = append(res, &KlineIssue{Time: b.Time, EndTime: b.Time, Kind: AuditAggDiff, Detail: "no 1m bars"})
+			continue
+		}
+		var fields []string
+		if priceDiff(b.Open, s.Open) {
+			fields = append(fields, fmt.Sprintf("o:%v/%v", b.Open, s.Open))
+		}
+		if priceDiff(b.High, s.High) {
+			fields = append(fields, fmt.Sprintf("h:%v/%v", b.High, s.High))
+		}
+		if priceDiff(b.Low, s.Low) {
+			fields = append(fields, fmt.Sprintf("l:%v/%v", b.Low, s.Low))
+		}
+		if priceDiff(b.Close, s.Close) {
+			fields = append(fields, fmt.Sprintf("c:%v/%v", b.Close, s.Close))
+		}
+		if math.Abs(b.Volume-s.Volume) > max(b.Volume, s.Volume)*auditVolTol {
+			fields = append(fields, fmt.Sprintf("v:%v/%v", b.Volume, s.Volume))
+		}
+		if len(fields) > 0 {
+			res = append(res, &KlineIssue{Time: b.Time, EndTime: b.Time, Kind: AuditAggDiff,
+				Detail: strings.Join(fields, " ")})
+		}
+	}
+	return res
+}
+
+/*
+findRuns
+Find [start, end) ranges of consecutive indexes matched, whose length >= minLen
+查找连续匹配的索引区间[start, end)，长度不小于minLen
+*/
+func findRuns(n int, match func(i int) bool, minLen int) [][2]int {
+	var res [][2]int
+	start := -1
+	for i := 0; i <= n; i++ {
+		if i < n && match(i) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start >= minLen {
+			res = append(res, [2]int{start, i})
+		}
+		start = -1
+	}
+	return res
+}
+
+func meanStd(arr []float64) (float64, float64) {
+	var sum float64
+	for _, v := range arr {
+		sum += v
+	}
+	mean := sum / float64(len(arr))
+	var sqSum float64
+	for _, v := range arr {
+		sqSum += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sqSum / float64(len(arr)))
+}
+
+/*
+repairKlines
+Re-download all flagged bars (whole range for runs) from exchange and replace them in database,
+bigger timeframes are re-aggregated.
+从交易所重新下载所有有问题的bar（连续区间下载整个区间）并替换数据库中的数据，更大周期会重新聚合
+*/
+func repairKlines(sess *orm.Queries, exs *orm.ExSymbol, tf string, issues []*KlineIssue) *errs.Error {
+	exchange, err := exg.GetWith(exs.Exchange, exs.Market, "")
+	if err != nil {
+		return err
+	}
+	tfMSecs := int64(utils2.TFToSecs(tf) * 1000)
+	items := make([][2]int64, 0, len(issues))
+	for _, it := range issues {
+		items = append(items, [2]int64{it.Time, max(it.Time, it.EndTime)})
+	}
+	ranges := mergeRepairRanges(items, tfMSecs)
+	repaired := make(map[int64]bool)
+	for _, rg := range ranges {
+		startMS, endMS := rg[0], rg[1]
+		limit := int((endMS - startMS) / tfMSecs)
+		bars, err := exchange.FetchOHLCV(exs.Symbol, tf, startMS, limit, nil)
+		if err != nil {
+			return err
+		}
+		valids := make([]*banexg.Kline, 0, len(bars))
+		for _, b := range bars {
+			if b.Time >= startMS && b.Time < endMS {
+				valids = append(valids, b)
+			}
+		}
+		if len(valids) == 0 {
+			continue
+		}
+		err = sess.DelKLines(exs.ID, tf, startMS, endMS)
+		if err != nil {
+			return err
+		}
+		_, err = sess.InsertKLines(tf, exs.ID, valids, true)
+		if err != nil {
+			return err
+		}
+		err = sess.UpdateKRange(exs, tf, startMS, endMS, valids, true)
+		if err != nil {
+			return err
+		}
+		for _, b := range valids {
+			repaired[b.Time] = true
+		}
+		log.Info("repaired klines", zap.String("pair", exs.Symbol), zap.String("tf", tf),
+			zap.Int64("start", startMS), zap.Int("num", len(valids)))
+	}
+	for _, it := range issues {
+		it.Repaired = true
+		for t := it.Time; t <= it.EndTime; t += tfMSecs {
+			if !repaired[t] {
+				it.Repaired = false
+				break
+			}
+		}
+	}
+	return nil
+}
+
+/*
+mergeRepairRanges
+Merge [first bar time, last bar time] items into [start, end) ranges, near ones are merged to reduce requests.
+Ranges are split to at most KBatchSize bars for one request.
+将[首个bar时间, 最后bar时间]合并为[start, end)区间，相邻的合并以减少请求。区间拆分为每次请求最多KBatchSize个bar
+*/
+func mergeRepairRanges(items [][2]int64, tfMSecs int64) [][2]int64 {
+	if len(items) == 0 {
+		return nil
+	}
+	sorted := append([][2]int64{}, items...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i][0] < sorted[j][0]
+	})
+	var merged [][2]int64
+	cur := [2]int64{sorted[0][0], sorted[0][1] + tfMSecs}
+	for _, it := range sorted[1:] {
+		if it[0]-cur[1] <= auditRepairGap*tfMSecs {
+			cur[1] = max(cur[1], it[1]+tfMSecs)
+			continue
+		}
+		merged = append(merged, cur)
+		cur = [2]int64{it[0], it[1] + tfMSecs}
+	}
+	merged = append(merged, cur)
+	maxLen := int64(core.KBatchSize) * tfMSecs
+	res := make([][2]int64, 0, len(merged))
+	for _, rg := range merged {
+		for start := rg[0]; start < rg[1]; start += maxLen {
+			res = append(res, [2]int64{start, min(start+maxLen, rg[1])})
+		}
+	}
+	return res
+}
+
+func writeAuditCsv(path string, issues []*KlineIssue) *errs.Error {
+	rows := make([][]string, 0, len(issues)+1)
+	rows = append(rows, []string{"pair", "timeframe", "time", "date", "kind", "detail", "repaired"})
+	for _, it := range issues {
+		rows = append(rows, []string{
+			it.Pair,
+			it.TimeFrame,
+			strconv.FormatInt(it.Time, 10),
+			btime.ToDateStr(it.Time, core.DefaultDateFmt),
+			it.Kind,
+			it.Detail,
+			strconv.FormatBool(it.Repaired),
+		})
+	}
+	return utils.WriteCsvFile(path, rows, false)
+}
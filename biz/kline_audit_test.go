@@ -0,0 +1,67 @@
+package biz
+
+import (
+	"testing"
+
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banexg"
+)
+
+func TestAuditBars(t *testing.T) {
+	var bars []*banexg.Kline
+	price := 100.0
+	for i := 0; i < 60; i++ {
+		price += float64(i%3) - 1
+		bars = append(bars, &banexg.Kline{Time: int64(i) * 60000, Open: price, High: price + 1, Low: price - 1,
+			Close: price + 0.5, Volume: 10})
+	}
+	bars[10].High = bars[10].Low - 1
+	bars[20].Close, bars[20].High = 200, 201
+	for i := 30; i < 34; i++ {
+		bars[i].Volume = 0
+	}
+	for i := 40; i < 46; i++ {
+		bars[i].Close = bars[39].Close
+		bars[i].High = max(bars[i].High, bars[i].Close)
+		bars[i].Low = min(bars[i].Low, bars[i].Close)
+	}
+	bars[50].Time = bars[49].Time
+	issues := auditBars(bars, 5)
+	kinds := make(map[string][]int64)
+	for _, it := range issues {
+		kinds[it.Kind] = append(kinds[it.Kind], it.Time/60000)
+	}
+	expects := map[string]int64{
+		AuditOHLC:    10,
+		AuditOutlier: 20,
+		AuditZeroVol: 30,
+		AuditStale:   39,
+		AuditDupTime: 49,
+	}
+	for kind, idx := range expects {
+		found := false
+		for _, v := range kinds[kind] {
+			found = found || v == idx
+		}
+		if !found {
+			t.Errorf("%s expect at %v, got %v", kind, idx, kinds[kind])
+		}
+	}
+	if len(kinds[AuditZeroVol]) != 1 || len(kinds[AuditStale]) != 1 {
+		t.Errorf("runs should be reported once: %v", kinds)
+	}
+}
+
+func TestMergeRepairRanges(t *testing.T) {
+	res := mergeRepairRanges([][2]int64{{300000, 300000}, {60000, 60000}, {120000, 180000}, {600000000, 600000000}}, 60000)
+	if len(res) != 2 || res[0] != [2]int64{60000, 360000} || res[1][0] != 600000000 {
+		t.Errorf("bad ranges: %v", res)
+	}
+	// the whole run is repaired and split by KBatchSize 整个连续区间都被修复，并按KBatchSize拆分
+	tfMSecs := int64(60000)
+	res = mergeRepairRanges([][2]int64{{0, tfMSecs * int64(core.KBatchSize+9)}}, tfMSecs)
+	if len(res) != 2 || res[0] != [2]int64{0, tfMSecs * int64(core.KBatchSize)} ||
+		res[1] != [2]int64{tfMSecs * int64(core.KBatchSize), tfMSecs * int64(core.KBatchSize+10)} {
+		t.Errorf("bad run ranges: %v", res)
+	}
+}
@@ -164,6 +164,15 @@ func (o *OrderMgr) allowOrderEnter(exs *orm.ExSymbol, tf string, enters []*strat
 	if numCut > 0 {
 		tagMap["OpenTooMuch"] = numCut
 	}
+	// Check the max open orders of symbol tags 检查品种标签的最大开单数量
+	tagNum := len(enters)
+	enters = o.checkTagOpenNum(exs, enters)
+	if tagCut := tagNum - len(enters); tagCut > 0 {
+		tagMap[strat.FailOpenNumLimitTag] = tagCut
+		if len(enters) == 0 {
+			return nil, tagMap
+		}
+	}
 	// Check whether the maximum number of orders opened by the strategy is exceeded
 	// 检查是否超出策略最大开单数量
 	openOds, lock := ormo.GetOpenODs(o.Account)
@@ -198,13 +207,53 @@ func (o *OrderMgr) allowOrderEnter(exs *orm.ExSymbol, tf string, enters []*strat
 	if skipNum > 0 {
 		strat.AddAccFailOpens(o.Account, strat.FailOpenNumLimitPol, skipNum)
 	}
-	numCut = rawNum - len(enters)
-	if numCut > 0 {
-		tagMap["OpenTooMuch"] = numCut
-	}
 	return res, tagMap
 }
 
+/*
+checkTagOpenNum
+Limit open orders of symbols sharing a tag by `max_open_tags`
+按`max_open_tags`限制带有相同标签的品种的开单数量
+*/
+func (o *OrderMgr) checkTagOpenNum(exs *orm.ExSymbol, enters []*strat.EnterReq) []*strat.EnterReq {
+	if len(config.MaxOpenTags) == 0 || len(enters) == 0 {
+		return enters
+	}
+	limits := make(map[string]int)
+	for _, tag := range orm.GetSymbolTags(exs.ID) {
+		if num, ok := config.MaxOpenTags[tag]; ok {
+			limits[tag] = num
+		}
+	}
+	if len(limits) == 0 {
+		return enters
+	}
+	tagNums := make(map[string]int)
+	openOds, lock := ormo.GetOpenODs(o.Account)
+	lock.Lock()
+	for _, od := range openOds {
+		for _, tag := range orm.GetSymbolTags(int32(od.Sid)) {
+			if _, ok := limits[tag]; ok {
+				tagNums[tag] += 1
+			}
+		}
+	}
+	lock.Unlock()
+	allowNum := len(enters)
+	for tag, maxNum := range limits {
+		allowNum = min(allowNum, max(0, maxNum-tagNums[tag]))
+	}
+	if allowNum < len(enters) {
+		if core.LiveMode {
+			log.Warn("cut enters by max_open_tags", zap.String("pair", exs.Symbol),
+				zap.Int("left", allowNum), zap.Int("cut", len(enters)-allowNum))
+		}
+		strat.AddAccFailOpens(o.Account, strat.FailOpenNumLimitTag, len(enters)-allowNum)
+		enters = enters[:allowNum]
+	}
+	return enters
+}
+
 func checkOrderNum(enters []*strat.EnterReq, oldNum, maxNum int, tag string) []*strat.EnterReq {
 	cutNum := oldNum + len(enters) - maxNum
 	if maxNum > 0 && cutNum > 0 {
@@ -742,6 +791,7 @@ func (o *OrderMgr) UpdateByBar(allOpens []*ormo.InOutOrder, bar *orm.InfoKline)
 		if od.Symbol != bar.Symbol || od.Timeframe != bar.TimeFrame || od.Status >= ormo.InOutStatusFullExit {
 			continue
 		}
+		od.UpdateExcursion(bar.High, bar.Low, bar.Time)
 		od.UpdateProfits(bar.Close)
 	}
 	return nil
@@ -783,6 +833,47 @@ func (o *OrderMgr) CleanUp() *errs.Error {
 	return nil
 }
 
+/*
+validateSubOd
+Check the sub order by market limits, before submitting to exchange in live, or before filling in backtest.
+The rejection reason is logged and counted, rejected entries are also counted as fail opens.
+在实盘提交到交易所前，或回测成交前，按市场限制检查子订单。记录拒绝原因，被拒绝的入场单同时计入开单失败
+*/
+func validateSubOd(account string, od *ormo.InOutOrder, subOd *ormo.ExOrder, price float64) *errs.Error {
+	reason, err := checkSubOd(account, od, subOd, price)
+	if reason == "" {
+		return err
+	}
+	action := "exit"
+	if subOd.Enter {
+		action = "enter"
+		strat.AddAccFailOpen(account, strat.FailOpenExgRejPrefix+reason)
+	}
+	core.AddMetric(core.MetricOrderRejects, 1, "account", account, "action", action, "reason", reason)
+	log.Warn("order rejected by market limits", zap.String("acc", account), zap.String("key", od.Key()),
+		zap.String("action", action), zap.String("err", err.Short()))
+	return err
+}
+
+// checkSubOd check the sub order by market limits without recording 按市场限制检查子订单，不记录
+func checkSubOd(account string, od *ormo.InOutOrder, subOd *ormo.ExOrder, price float64) (string, *errs.Error) {
+	odType := subOd.OrderType
+	if odType == "" {
+		odType = config.OrderType
+	}
+	return exg.ValidateOrder(exg.Default, &exg.OrderCheck{
+		Symbol:    od.Symbol,
+		Side:      subOd.Side,
+		OrderType: odType,
+		Amount:    subOd.Amount,
+		Price:     price,
+		RefPrice:  core.GetPrice(od.Symbol, subOd.Side),
+		Leverage:  od.Leverage,
+		Account:   account,
+		Reduce:    !subOd.Enter,
+	})
+}
+
 func CloseAccOrders(acc string, odList []*ormo.InOutOrder, req *strat.ExitReq) (int, int, *errs.Error) {
 	var odMgr IOrderMgr
 	if core.EnvReal {
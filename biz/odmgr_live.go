@@ -1636,6 +1636,11 @@ func (o *LiveOrderMgr) submitExgOrder(od *ormo.InOutOrder, isEnter bool) *errs.E
 			return nil
 		}
 	}
+	// 提交前按市场限制校验，避免交易所拒绝
+	err = validateSubOd(o.Account, od, subOd, subOd.Price)
+	if err != nil {
+		return err
+	}
 	side, amount, price := subOd.Side, subOd.Amount, subOd.Price
 	params := map[string]interface{}{
 		banexg.ParamAccount:       o.Account,
@@ -1656,8 +1661,16 @@ func (o *LiveOrderMgr) submitExgOrder(od *ormo.InOutOrder, isEnter bool) *errs.E
 			params[banexg.ParamTakeProfitPrice] = od.Stop
 		}
 	}
+	startMS := btime.UTCStamp()
 	res, err := exchange.CreateOrder(od.Symbol, subOd.OrderType, side, amount, price, params)
+	action := "exit"
+	if isEnter {
+		action = "enter"
+	}
+	core.ObserveMetric(core.MetricSubmitSecs, float64(btime.UTCStamp()-startMS)/1000, "account", o.Account,
+		"action", action)
 	if err != nil {
+		core.AddMetric(core.MetricSubmitFails, 1, "account", o.Account, "action", action)
 		if !isEnter && err.BizCode == -2022 {
 			msg := "ReduceOnly Order is rejected."
 			log.Error("close exg pos fail", zap.String("acc", o.Account), zap.String("key", od.Key()), zap.Error(err))
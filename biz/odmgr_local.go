@@ -21,6 +21,8 @@ type LocalOrderMgr struct {
 	OrderMgr
 	showLog  bool
 	zeroAmts map[string]int
+	rejExits map[int64]*ormo.ExOrder // reported exits rejected by market limits 已记录的被市场限制拒绝的出场单
+	rejNum   int                     // number of rejected exits 被拒绝的出场单数量
 }
 
 type FnOdCb = func(od *ormo.InOutOrder, isEnter bool)
@@ -36,6 +38,7 @@ func InitLocalOrderMgr(callBack FnOdCb, showLog bool) {
 				},
 				showLog:  showLog,
 				zeroAmts: make(map[string]int),
+				rejExits: make(map[int64]*ormo.ExOrder),
 			}
 			odMgr.afterEnter = makeLocalAfterEnter(odMgr)
 			accOdMgrs[account] = odMgr
@@ -173,6 +176,10 @@ func (o *LocalOrderMgr) fillPendingOrders(orders []*ormo.InOutOrder, bar *orm.In
 			}
 			continue
 		}
+		if isPaperQueued(o.Account, od.ID, exOrder.Enter) {
+			// filled by queue simulation of paper trading 由模拟实盘的排队模拟撮合
+			continue
+		}
 		odType := config.OrderType
 		if exOrder.OrderType != "" {
 			odType = exOrder.OrderType
@@ -243,6 +250,25 @@ func (o *LocalOrderMgr) fillPendingOrders(orders []*ormo.InOutOrder, bar *orm.In
 				err = o.tryFillTriggers(od, endBar)
 			}
 		} else {
+			if bar != nil {
+				// Rejected exit stays pending and is validated again on next bar like resubmitting in live,
+				// each exit is reported once. forced exits at bot stop are not checked
+				// 被拒绝的出场单保持挂起，下个bar再次校验，类似实盘重新提交，每个出场单只记录一次。机器人停止时的强制平仓不检查
+				exitPrice := price
+				if strings.Contains(odType, "limit") && exOrder.Price > 0 {
+					exitPrice = exOrder.Price
+				}
+				if o.rejExits[od.ID] == exOrder {
+					if _, err := checkSubOd(o.Account, od, exOrder, exitPrice); err != nil {
+						continue
+					}
+				} else if validateSubOd(o.Account, od, exOrder, exitPrice) != nil {
+					o.rejExits[od.ID] = exOrder
+					o.rejNum += 1
+					continue
+				}
+				delete(o.rejExits, od.ID)
+			}
 			err = o.fillPendingExit(od, price, fillMS)
 		}
 		if err != nil {
@@ -322,6 +348,17 @@ func (o *LocalOrderMgr) fillPendingEnter(od *ormo.InOutOrder, price float64, fil
 	if exOrder.Price == 0 {
 		exOrder.Price = entPrice
 	}
+	// Validate by market limits same as live, rejected orders are exited locally
+	// 和实盘相同按市场限制校验，被拒绝的订单本地退出
+	err = validateSubOd(o.Account, od, exOrder, exOrder.Price)
+	if err != nil {
+		msg := err.Short()
+		err = od.LocalExit(fillMS, core.ExitTagFatalErr, od.InitPrice, msg, "")
+		_, quote, _, _ := core.SplitSymbol(od.Symbol)
+		wallets.Cancel(od.Key(), quote, 0, true)
+		strat.FireOdChange(o.Account, od, strat.OdChgExitFill)
+		return err
+	}
 	updateTime := fillMS
 	exOrder.UpdateAt = updateTime
 	if exOrder.CreateAt == 0 {
@@ -620,6 +657,9 @@ func (o *LocalOrderMgr) CleanUp() *errs.Error {
 	if len(o.zeroAmts) > 0 {
 		log.Warn("prec amount to zero", zap.Any("times", o.zeroAmts))
 	}
+	if o.rejNum > 0 {
+		log.Warn("exit orders rejected by market limits", zap.Int("num", o.rejNum))
+	}
 	// Reset Unrealized P&L
 	// 重置未实现盈亏
 	wallets := GetWallets(o.Account)
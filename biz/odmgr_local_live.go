@@ -34,6 +34,7 @@ func InitLocalLiveOrderMgr(callBack FnOdCb, showLog bool) {
 					},
 					showLog:  showLog,
 					zeroAmts: make(map[string]int),
+					rejExits: make(map[int64]*ormo.ExOrder),
 				},
 			}
 			odMgr.afterEnter = makeAfterEnterLocalLive(odMgr)
@@ -93,25 +94,44 @@ func tryFillLocalLiveOrder(o *LocalLiveOrderMgr, od *ormo.InOutOrder, isEnter bo
 	if exOd.OrderType != "" {
 		odType = exOd.OrderType
 	}
-	if !strings.Contains(odType, banexg.OdTypeMarket) || od.Stop > 0 {
-		// 限价单或触发价格，按推送价格处理
-		return nil
-	}
-	// 市价单立刻撮合成交
-	ask, bid, err := getAskBidPrice(od.Symbol)
-	if err != nil {
-		return err
-	}
-	fillPrice := ask
-	if od.Short == isEnter {
-		// 做空入场，做多离场，都是吃买单
-		fillPrice = bid
-	}
 	tag := "exit"
 	if isEnter {
 		tag = "enter"
 	}
-	log.Info("try fill market "+tag, zap.String("od", od.Key()), zap.Float64("price", fillPrice))
+	var fillPrice float64
+	var err *errs.Error
+	if !strings.Contains(odType, banexg.OdTypeMarket) || od.Stop > 0 {
+		if od.Stop > 0 || exOd.Price <= 0 || !usePaperQueue() {
+			// 限价单或触发价格，按推送价格处理
+			return nil
+		}
+		// limit order waits in queue, filled by trades stream unless it crosses the book
+		// 限价单排队等待，由逐笔成交撮合，除非价格穿越订单簿
+		fillPrice, err = addPaperQueue(o.Account, od, exOd)
+		if err != nil || fillPrice == 0 {
+			return err
+		}
+		log.Info("try fill crossed limit "+tag, zap.String("od", od.Key()), zap.Float64("price", fillPrice))
+	} else if usePaperDepth() {
+		// 市价单按订单簿深度逐档成交
+		fillPrice, err = getPaperMarketPrice(od, exOd)
+		if err != nil {
+			return err
+		}
+		log.Info("try fill market by depth "+tag, zap.String("od", od.Key()), zap.Float64("price", fillPrice))
+	} else {
+		// 市价单立刻撮合成交
+		ask, bid, err := getAskBidPrice(od.Symbol)
+		if err != nil {
+			return err
+		}
+		fillPrice = ask
+		if od.Short == isEnter {
+			// 做空入场，做多离场，都是吃买单
+			fillPrice = bid
+		}
+		log.Info("try fill market "+tag, zap.String("od", od.Key()), zap.Float64("price", fillPrice))
+	}
 	if isEnter {
 		err = o.fillPendingEnter(od, fillPrice, btime.UTCStamp())
 	} else {
@@ -0,0 +1,384 @@
+package biz
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banbot/exg"
+	"github.com/banbox/banbot/orm/ormo"
+	"github.com/banbox/banexg"
+	"github.com/banbox/banexg/errs"
+	"github.com/banbox/banexg/log"
+	"github.com/sasha-s/go-deadlock"
+	"go.uber.org/zap"
+)
+
+/*
+paperQueue
+A resting limit order of paper trading, waiting in the queue of its price level.
+模拟实盘中挂在某个价位排队等待成交的限价单
+*/
+type paperQueue struct {
+	Account  string
+	OdID     int64
+	Symbol   string
+	IsEnter  bool
+	IsBuy    bool
+	Price    float64
+	Amount   float64 // amount of the order 订单数量
+	Ahead    float64 // volume ahead in the queue 排在前面的数量
+	Traded   float64 // volume traded at this price since placed 挂单后在此价位的成交量
+	CreateAt int64
+	BookAt   int64 // timestamp of the order book last used 最后使用的订单簿时间戳
+}
+
+var (
+	paperQueues = make(map[string]*paperQueue) // key: account_odID_enter/exit
+	lockPaper   deadlock.Mutex
+)
+
+func paperQueueKey(account string, odID int64, isEnter bool) string {
+	tag := "exit"
+	if isEnter {
+		tag = "enter"
+	}
+	return fmt.Sprintf("%s_%d_%s", account, odID, tag)
+}
+
+/*
+isPaperQueued
+Whether the pending sub-order is filled by the queue simulation instead of klines
+挂单是否由排队模拟而不是K线撮合成交
+*/
+func isPaperQueued(account string, odID int64, isEnter bool) bool {
+	lockPaper.Lock()
+	defer lockPaper.Unlock()
+	if len(paperQueues) == 0 {
+		return false
+	}
+	_, ok := paperQueues[paperQueueKey(account, odID, isEnter)]
+	return ok
+}
+
+/*
+walkBookSide
+Walk the order book side (asks for buy, bids for sell) to fill the amount, return average price and filled amount.
+When limit > 0, levels worse than limit are not taken.
+逐档吃入订单簿一侧(买入吃asks，卖出吃bids)，返回成交均价和成交数量。limit>0时不吃入比limit更差的档位
+*/
+func walkBookSide(side *banexg.OdBookSide, amount, limit float64) (float64, float64) {
+	if side == nil || amount <= 0 {
+		return 0, 0
+	}
+	side.Lock.Lock()
+	defer side.Lock.Unlock()
+	var filled, cost float64
+	for i, price := range side.Price {
+		if limit > 0 && (price-limit)*bookDirt(side) < 0 {
+			break
+		}
+		size := min(side.Size[i], amount-filled)
+		filled += size
+		cost += size * price
+		if filled >= amount {
+			break
+		}
+	}
+	if filled == 0 {
+		return 0, 0
+	}
+	return cost / filled, filled
+}
+
+/*
+bookLevelSize
+The resting volume at the price on the side. Return -1 if price is better than the best level, which means
+the queue is empty.
+订单簿一侧该价位的挂单量。价格优于最优档位时返回-1，表示队列为空
+*/
+func bookLevelSize(side *banexg.OdBookSide, price float64) float64 {
+	side.Lock.Lock()
+	defer side.Lock.Unlock()
+	if len(side.Price) == 0 || (price-side.Price[0])*bookDirt(side) > 0 {
+		return -1
+	}
+	for i, p := range side.Price {
+		if math.Abs(p-price) <= price*1e-9 {
+			return side.Size[i]
+		}
+		if (p-price)*bookDirt(side) < 0 {
+			break
+		}
+	}
+	return 0
+}
+
+// bookDirt 1 for bids(desc), -1 for asks(asc); price*dirt is larger when better for makers
+func bookDirt(side *banexg.OdBookSide) float64 {
+	if side.IsBuy {
+		return 1
+	}
+	return -1
+}
+
+/*
+getPaperMarketPrice
+Average fill price of market order by walking order book depth.
+通过逐档吃入订单簿深度计算市价单成交均价
+*/
+func getPaperMarketPrice(od *ormo.InOutOrder, exOd *ormo.ExOrder) (float64, *errs.Error) {
+	book, err := exg.GetOdBook(od.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	isBuy := exOd.Side == banexg.OdSideBuy
+	side := book.Asks
+	if !isBuy {
+		side = book.Bids
+	}
+	bestPrice, _ := side.Level(0)
+	if bestPrice == 0 {
+		return 0, errs.NewMsg(core.ErrRunTime, "order book of %s is empty", od.Symbol)
+	}
+	amount := exOd.Amount
+	if amount == 0 {
+		amount = od.QuoteCost / bestPrice
+	}
+	avgPrice, filled := walkBookSide(side, amount, 0)
+	if filled < amount {
+		// book is not deep enough, the rest is filled at the last level
+		// 订单簿深度不足，剩余部分按最后一档成交
+		lastPrice, _ := side.Level(len(side.Price) - 1)
+		avgPrice = (avgPrice*filled + lastPrice*(amount-filled)) / amount
+	}
+	return avgPrice, nil
+}
+
+/*
+addPaperQueue
+Put a resting limit order into the queue simulation. Return the fill price if it crosses the book and should be
+filled as taker immediately.
+将挂单限价单加入排队模拟。若价格穿越订单簿应立即作为taker成交，返回成交价格
+*/
+func addPaperQueue(account string, od *ormo.InOutOrder, exOd *ormo.ExOrder) (float64, *errs.Error) {
+	book, err := exg.GetOdBook(od.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	isBuy := exOd.Side == banexg.OdSideBuy
+	taker, maker := book.Asks, book.Bids
+	if !isBuy {
+		taker, maker = book.Bids, book.Asks
+	}
+	amount := exOd.Amount
+	if amount == 0 {
+		amount = od.QuoteCost / exOd.Price
+	}
+	bestPrice, _ := taker.Level(0)
+	if bestPrice > 0 && (bestPrice-exOd.Price)*bookDirt(taker) >= 0 {
+		// crosses the book: take liquidity up to limit price
+		// 穿越订单簿：吃入到限价为止的流动性
+		avgPrice, _ := walkBookSide(taker, amount, exOd.Price)
+		if avgPrice > 0 {
+			return avgPrice, nil
+		}
+	}
+	ahead := max(0, bookLevelSize(maker, exOd.Price))
+	item := &paperQueue{
+		Account:  account,
+		OdID:     od.ID,
+		Symbol:   od.Symbol,
+		IsEnter:  exOd.Enter,
+		IsBuy:    isBuy,
+		Price:    exOd.Price,
+		Amount:   amount,
+		Ahead:    ahead,
+		CreateAt: btime.UTCStamp(),
+		BookAt:   book.TimeStamp,
+	}
+	lockPaper.Lock()
+	paperQueues[paperQueueKey(account, od.ID, exOd.Enter)] = item
+	lockPaper.Unlock()
+	log.Info("paper limit queued", zap.String("od", od.Key()), zap.Float64("price", exOd.Price),
+		zap.Float64("ahead", ahead))
+	return 0, nil
+}
+
+/*
+onTrades
+Consume the queue by public trades, return whether the order is filled and the fill time.
+Trades through the price fill the order directly; trades at the price only consume the volume ahead first.
+用公开逐笔成交消耗排队量，返回订单是否成交及成交时间。
+穿过挂单价的成交直接使订单成交；在挂单价的成交先消耗前方排队量
+*/
+func (q *paperQueue) onTrades(trades []*banexg.Trade) (bool, int64) {
+	for _, t := range trades {
+		if t.Timestamp < q.CreateAt {
+			continue
+		}
+		diff := t.Price - q.Price
+		if !q.IsBuy {
+			diff = -diff
+		}
+		if diff < -q.Price*1e-9 {
+			return true, t.Timestamp
+		} else if diff > q.Price*1e-9 {
+			continue
+		}
+		if t.Side != "" && (t.Side == banexg.OdSideBuy) == q.IsBuy {
+			// only aggressors from the other side match our level 只有对手方主动成交才会匹配到我方价位
+			continue
+		}
+		q.Traded += t.Amount
+		if q.Traded-q.Ahead >= q.Amount {
+			return true, t.Timestamp
+		}
+	}
+	return false, 0
+}
+
+/*
+refreshAhead
+Volume ahead can only decrease by cancellation, shrink it to the latest resting volume of the level.
+前方排队量只会因撤单减少，将其缩小到该价位最新的挂单量
+*/
+func (q *paperQueue) refreshAhead(book *banexg.OrderBook) {
+	if book == nil || book.TimeStamp <= q.BookAt {
+		return
+	}
+	side := book.Bids
+	if !q.IsBuy {
+		side = book.Asks
+	}
+	if side == nil {
+		return
+	}
+	q.BookAt = book.TimeStamp
+	size := max(0, bookLevelSize(side, q.Price))
+	// volume traded since placed is part of the old queue 挂单后的成交量属于旧队列
+	if size+q.Traded < q.Ahead {
+		q.Ahead = size + q.Traded
+	}
+}
+
+/*
+CallLocalLiveOdMgrsTrades
+Fill paper limit orders of the pair by public trades stream
+通过公开逐笔成交流撮合品种的模拟限价单
+*/
+func CallLocalLiveOdMgrsTrades(pair string, trades []*banexg.Trade) {
+	if len(trades) == 0 {
+		return
+	}
+	book, _ := core.GetOdBook(pair)
+	type fillItem struct {
+		item   *paperQueue
+		fillMS int64
+	}
+	var fills []*fillItem
+	lockPaper.Lock()
+	for _, q := range paperQueues {
+		if q.Symbol != pair {
+			continue
+		}
+		q.refreshAhead(book)
+		if done, fillMS := q.onTrades(trades); done {
+			fills = append(fills, &fillItem{item: q, fillMS: fillMS})
+		}
+	}
+	lockPaper.Unlock()
+	for _, f := range fills {
+		q := f.item
+		mgr, ok := accOdMgrs[q.Account].(*LocalLiveOrderMgr)
+		od := getOpenOrder(q.Account, q.OdID)
+		if !ok || od == nil {
+			delPaperQueue(q)
+			continue
+		}
+		err := mgr.fillPaperQueue(od, q, f.fillMS)
+		if err != nil {
+			log.Error("fill paper limit fail", zap.String("od", od.Key()), zap.Error(err))
+		}
+	}
+	cleanPaperQueues(pair)
+}
+
+func (o *LocalLiveOrderMgr) fillPaperQueue(od *ormo.InOutOrder, q *paperQueue, fillMS int64) *errs.Error {
+	exOd := od.Enter
+	if !q.IsEnter {
+		exOd = od.Exit
+	}
+	delPaperQueue(q)
+	if exOd == nil || exOd.Status >= ormo.OdStatusClosed {
+		return nil
+	}
+	log.Info("paper limit filled", zap.String("od", od.Key()), zap.Float64("price", q.Price),
+		zap.Float64("traded", q.Traded), zap.Float64("ahead", q.Ahead))
+	var err *errs.Error
+	if q.IsEnter {
+		err = o.fillPendingEnter(od, q.Price, fillMS)
+	} else {
+		err = o.fillPendingExit(od, q.Price, fillMS)
+	}
+	if err != nil {
+		return err
+	}
+	if od.IsDirty() {
+		err = od.Save(nil)
+	}
+	return err
+}
+
+func getOpenOrder(account string, odID int64) *ormo.InOutOrder {
+	openOds, lock := ormo.GetOpenODs(account)
+	lock.Lock()
+	od, _ := openOds[odID]
+	lock.Unlock()
+	return od
+}
+
+func delPaperQueue(q *paperQueue) {
+	lockPaper.Lock()
+	delete(paperQueues, paperQueueKey(q.Account, q.OdID, q.IsEnter))
+	lockPaper.Unlock()
+}
+
+/*
+cleanPaperQueues
+Remove queued items whose orders are closed, cancelled or replaced.
+移除订单已关闭、取消或被替换的排队项
+*/
+func cleanPaperQueues(pair string) {
+	lockPaper.Lock()
+	items := make([]*paperQueue, 0, len(paperQueues))
+	for _, q := range paperQueues {
+		if q.Symbol == pair {
+			items = append(items, q)
+		}
+	}
+	lockPaper.Unlock()
+	for _, q := range items {
+		od := getOpenOrder(q.Account, q.OdID)
+		var exOd *ormo.ExOrder
+		if od != nil {
+			exOd = od.Enter
+			if !q.IsEnter {
+				exOd = od.Exit
+			}
+		}
+		if exOd == nil || exOd.Status >= ormo.OdStatusClosed || exOd.Price != q.Price {
+			delPaperQueue(q)
+		}
+	}
+}
+
+func usePaperQueue() bool {
+	return config.PaperTrade != nil && config.PaperTrade.QueueFill
+}
+
+func usePaperDepth() bool {
+	return config.PaperTrade != nil && config.PaperTrade.DepthWalk
+}
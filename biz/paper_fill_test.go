@@ -0,0 +1,51 @@
+package biz
+
+import (
+	"math"
+	"testing"
+
+	"github.com/banbox/banexg"
+)
+
+func TestWalkBookSide(t *testing.T) {
+	asks := &banexg.OdBookSide{Price: []float64{100, 101, 102}, Size: []float64{1, 2, 3}}
+	price, filled := walkBookSide(asks, 2, 0)
+	if filled != 2 || math.Abs(price-100.5) > 1e-9 {
+		t.Errorf("walk 2 got %v %v", price, filled)
+	}
+	price, filled = walkBookSide(asks, 10, 101)
+	if filled != 3 || math.Abs(price-302.0/3) > 1e-9 {
+		t.Errorf("walk to limit got %v %v", price, filled)
+	}
+	bids := &banexg.OdBookSide{IsBuy: true, Price: []float64{99, 98}, Size: []float64{1, 1}}
+	if size := bookLevelSize(bids, 98); size != 1 {
+		t.Errorf("level size of 98 got %v", size)
+	}
+	if size := bookLevelSize(bids, 99.5); size != -1 {
+		t.Errorf("level size of 99.5 got %v", size)
+	}
+	if size := bookLevelSize(bids, 98.5); size != 0 {
+		t.Errorf("level size of 98.5 got %v", size)
+	}
+}
+
+func TestPaperQueueTrades(t *testing.T) {
+	q := &paperQueue{IsBuy: true, Price: 100, Amount: 1, Ahead: 3}
+	trades := []*banexg.Trade{
+		{Price: 100, Amount: 2, Side: banexg.OdSideSell, Timestamp: 1},
+		{Price: 100, Amount: 5, Side: banexg.OdSideBuy, Timestamp: 2},
+		{Price: 100.5, Amount: 5, Side: banexg.OdSideSell, Timestamp: 3},
+	}
+	if done, _ := q.onTrades(trades); done {
+		t.Fatalf("should not fill before queue cleared, traded %v", q.Traded)
+	}
+	done, fillMS := q.onTrades([]*banexg.Trade{{Price: 100, Amount: 2, Side: banexg.OdSideSell, Timestamp: 4}})
+	if !done || fillMS != 4 {
+		t.Errorf("should fill after queue cleared, traded %v", q.Traded)
+	}
+	q = &paperQueue{IsBuy: false, Price: 100, Amount: 1, Ahead: 10}
+	done, fillMS = q.onTrades([]*banexg.Trade{{Price: 100.1, Amount: 0.1, Side: banexg.OdSideBuy, Timestamp: 5}})
+	if !done || fillMS != 5 {
+		t.Errorf("trade through price should fill sell limit")
+	}
+}
@@ -0,0 +1,319 @@
+package biz
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/orm"
+	"github.com/banbox/banbot/orm/ormo"
+	"github.com/banbox/banbot/rpc"
+	"github.com/banbox/banbot/utils"
+	"github.com/banbox/banexg/errs"
+	"github.com/banbox/banexg/log"
+	utils2 "github.com/banbox/banexg/utils"
+	"go.uber.org/zap"
+	"html"
+	"slices"
+	"strings"
+)
+
+type GroupItem struct {
+	Key       string             `json:"key"`
+	HoldHours float64            `json:"holdHours"`
+	TotalCost float64            `json:"totalCost"`
+	ProfitSum float64            `json:"profitSum"`
+	ProfitPct float64            `json:"profitPct"`
+	CloseNum  int                `json:"closeNum"`
+	WinNum    int                `json:"winNum"`
+	Orders    []*ormo.InOutOrder `json:"-"`
+}
+
+func GroupOrders(orders []*ormo.InOutOrder, odKey func(od *ormo.InOutOrder) string) []*GroupItem {
+	var itemMap = map[string]*GroupItem{}
+	hourMSecs := float64(utils2.TFToSecs("1h") * 1000)
+	for _, od := range orders {
+		key := odKey(od)
+		gp, ok := itemMap[key]
+		if !ok {
+			gp = &GroupItem{Key: key}
+			itemMap[key] = gp
+		}
+		holdHours := float64(od.RealExitMS()-od.RealEnterMS()) / hourMSecs
+		gp.CloseNum += 1
+		gp.ProfitSum += od.Profit
+		gp.TotalCost += od.EnterCost()
+		gp.HoldHours += holdHours
+		gp.Orders = append(gp.Orders, od)
+		if od.Profit > 0 {
+			gp.WinNum += 1
+		}
+	}
+	for _, gp := range itemMap {
+		if gp.TotalCost > 0 {
+			gp.ProfitPct = gp.ProfitSum / gp.TotalCost
+		}
+		gp.HoldHours /= float64(gp.CloseNum)
+	}
+	var res = make([]*GroupItem, 0, len(itemMap))
+	for _, v := range itemMap {
+		res = append(res, v)
+	}
+	slices.SortFunc(res, func(a, b *GroupItem) int {
+		if a.Key <= b.Key {
+			return -1
+		}
+		return 1
+	})
+	return res
+}
+
+/*
+PerfReport
+Performance of an account over a period, sent periodically by config.Reports
+账户在一段时间内的绩效，按config.Reports定期发送
+*/
+type PerfReport struct {
+	Name           string
+	Account        string
+	StartMS        int64
+	StopMS         int64
+	Total          *GroupItem   // all closed orders in period 周期内所有已平仓订单
+	Strats         []*GroupItem // grouped by strategy, sorted by profit desc 按策略分组，按利润降序
+	Fees           float64
+	MaxDrawDown    float64 // max drawdown of realized equity 已实现权益的最大回撤
+	MaxDrawDownPct float64
+	Best           *ormo.InOutOrder
+	Worst          *ormo.InOutOrder
+	OpenNum        int
+	OpenProfit     float64
+	Times          []int64   // 13-digit timestamps of equity curve 权益曲线的时间戳
+	Equity         []float64 // realized equity curve 已实现权益曲线
+}
+
+/*
+calcPerfReport
+Calculate report from closed orders, startEquity is the equity at StartMS, used for drawdown and equity curve.
+根据已平仓订单计算报告，startEquity为开始时的权益，用于回撤和权益曲线
+*/
+func calcPerfReport(rep *PerfReport, orders []*ormo.InOutOrder, startEquity float64) {
+	orders = slices.Clone(orders)
+	slices.SortFunc(orders, func(a, b *ormo.InOutOrder) int {
+		return cmp.Compare(a.RealExitMS(), b.RealExitMS())
+	})
+	totals := GroupOrders(orders, func(od *ormo.InOutOrder) string {
+		return "total"
+	})
+	if len(totals) > 0 {
+		rep.Total = totals[0]
+	} else {
+		rep.Total = &GroupItem{Key: "total"}
+	}
+	rep.Strats = GroupOrders(orders, func(od *ormo.InOutOrder) string {
+		return od.Strategy
+	})
+	slices.SortStableFunc(rep.Strats, func(a, b *GroupItem) int {
+		return cmp.Compare(b.ProfitSum, a.ProfitSum)
+	})
+	equity, peak := startEquity, startEquity
+	rep.Times = append(rep.Times, rep.StartMS)
+	rep.Equity = append(rep.Equity, equity)
+	for _, od := range orders {
+		if od.Enter != nil {
+			rep.Fees += od.Enter.FeeQuote
+		}
+		if od.Exit != nil {
+			rep.Fees += od.Exit.FeeQuote
+		}
+		if rep.Best == nil || od.ProfitRate > rep.Best.ProfitRate {
+			rep.Best = od
+		}
+		if rep.Worst == nil || od.ProfitRate < rep.Worst.ProfitRate {
+			rep.Worst = od
+		}
+		equity += od.Profit
+		rep.Times = append(rep.Times, od.RealExitMS())
+		rep.Equity = append(rep.Equity, equity)
+		peak = max(peak, equity)
+		if peak-equity > rep.MaxDrawDown {
+			rep.MaxDrawDown = peak - equity
+			if peak > 0 {
+				rep.MaxDrawDownPct = rep.MaxDrawDown / peak
+			}
+		}
+	}
+	if rep.StopMS > rep.Times[len(rep.Times)-1] {
+		rep.Times = append(rep.Times, rep.StopMS)
+		rep.Equity = append(rep.Equity, equity)
+	}
+}
+
+// BuildPerfReport 统计账户最近days天的绩效报告
+func BuildPerfReport(name, account string, days int) (*PerfReport, *errs.Error) {
+	stopMS := btime.UTCStamp()
+	rep := &PerfReport{
+		Name:    name,
+		Account: account,
+		StartMS: stopMS - int64(days)*int64(utils2.TFToSecs("1d")*1000),
+		StopMS:  stopMS,
+	}
+	orders, err := getOrdersClosedAfter(account, rep.StartMS)
+	if err != nil {
+		return nil, err
+	}
+	openOds, lock := ormo.GetOpenODs(account)
+	lock.Lock()
+	for _, od := range openOds {
+		rep.OpenNum += 1
+		rep.OpenProfit += od.Profit
+	}
+	lock.Unlock()
+	var realized float64
+	for _, od := range orders {
+		realized += od.Profit
+	}
+	curEquity := GetWallets(account).FiatValue(true)
+	calcPerfReport(rep, orders, curEquity-rep.OpenProfit-realized)
+	return rep, nil
+}
+
+func fmtReportOrder(od *ormo.InOutOrder) string {
+	return fmt.Sprintf("%s %s %+.2f%% (%.4f)", od.Symbol, od.Strategy, od.ProfitRate*100, od.Profit)
+}
+
+func (r *PerfReport) winRate(gp *GroupItem) float64 {
+	if gp.CloseNum == 0 {
+		return 0
+	}
+	return float64(gp.WinNum) * 100 / float64(gp.CloseNum)
+}
+
+// Title 报告标题
+func (r *PerfReport) Title() string {
+	return fmt.Sprintf("[%s] %s/%s performance report", r.Name, config.Name, r.Account)
+}
+
+// Text 渲染为文本，用于telegram/wework
+func (r *PerfReport) Text(topN int) string {
+	var b strings.Builder
+	b.WriteString("📊 " + r.Title() + "\n")
+	b.WriteString(fmt.Sprintf("period: %s ~ %s\n", btime.ToDateStrLoc(r.StartMS, "2006-01-02 15:04"),
+		btime.ToDateStrLoc(r.StopMS, "2006-01-02 15:04")))
+	t := r.Total
+	b.WriteString(fmt.Sprintf("closed: %d, win rate: %.1f%%\n", t.CloseNum, r.winRate(t)))
+	b.WriteString(fmt.Sprintf("profit: %.4f (%.2f%%), fees: %.4f\n", t.ProfitSum, t.ProfitPct*100, r.Fees))
+	b.WriteString(fmt.Sprintf("max drawdown: %.4f (%.2f%%)\n", r.MaxDrawDown, r.MaxDrawDownPct*100))
+	b.WriteString(fmt.Sprintf("open: %d, unrealized: %.4f\n", r.OpenNum, r.OpenProfit))
+	if r.Best != nil {
+		b.WriteString("best: " + fmtReportOrder(r.Best) + "\n")
+		b.WriteString("worst: " + fmtReportOrder(r.Worst) + "\n")
+	}
+	if len(r.Strats) > 0 {
+		b.WriteString("strategies:\n")
+		for i, gp := range r.Strats {
+			if i >= topN {
+				b.WriteString(fmt.Sprintf("  ... %d more\n", len(r.Strats)-i))
+				break
+			}
+			b.WriteString(fmt.Sprintf("  %s: %d orders, win %.0f%%, profit %.4f (%.2f%%)\n", gp.Key, gp.CloseNum,
+				r.winRate(gp), gp.ProfitSum, gp.ProfitPct*100))
+		}
+	}
+	return b.String()
+}
+
+// HTML 渲染为html，用于邮件，imgCid为权益曲线图片的Content-ID，为空时不显示
+func (r *PerfReport) HTML(topN int, imgCid string) string {
+	var b strings.Builder
+	esc := html.EscapeString
+	b.WriteString("<html><body style=\"font-family:Arial,sans-serif;font-size:14px\">")
+	b.WriteString("<h3>" + esc(r.Title()) + "</h3>")
+	b.WriteString(fmt.Sprintf("<p>%s ~ %s</p>", btime.ToDateStrLoc(r.StartMS, "2006-01-02 15:04"),
+		btime.ToDateStrLoc(r.StopMS, "2006-01-02 15:04")))
+	t := r.Total
+	rows := [][2]string{
+		{"Closed Orders", fmt.Sprintf("%d", t.CloseNum)},
+		{"Win Rate", fmt.Sprintf("%.1f%%", r.winRate(t))},
+		{"Profit", fmt.Sprintf("%.4f (%.2f%%)", t.ProfitSum, t.ProfitPct*100)},
+		{"Fees", fmt.Sprintf("%.4f", r.Fees)},
+		{"Max Drawdown", fmt.Sprintf("%.4f (%.2f%%)", r.MaxDrawDown, r.MaxDrawDownPct*100)},
+		{"Open Orders", fmt.Sprintf("%d, unrealized %.4f", r.OpenNum, r.OpenProfit)},
+	}
+	if r.Best != nil {
+		rows = append(rows, [2]string{"Best Trade", fmtReportOrder(r.Best)},
+			[2]string{"Worst Trade", fmtReportOrder(r.Worst)})
+	}
+	const tblStyle = "<table border=\"1\" cellspacing=\"0\" cellpadding=\"4\" style=\"border-collapse:collapse\">"
+	b.WriteString(tblStyle)
+	for _, row := range rows {
+		b.WriteString("<tr><th align=\"left\">" + row[0] + "</th><td>" + esc(row[1]) + "</td></tr>")
+	}
+	b.WriteString("</table>")
+	if imgCid != "" {
+		b.WriteString("<h4>Equity</h4><img src=\"cid:" + imgCid + "\" alt=\"equity\"/>")
+	}
+	if len(r.Strats) > 0 {
+		b.WriteString("<h4>Strategies</h4>" + tblStyle)
+		b.WriteString("<tr><th>Strategy</th><th>Orders</th><th>Win Rate</th><th>Profit</th><th>Profit %</th>" +
+			"<th>Hold Hours</th></tr>")
+		for i, gp := range r.Strats {
+			if i >= topN {
+				break
+			}
+			b.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%d</td><td>%.1f%%</td><td>%.4f</td><td>%.2f%%</td>"+
+				"<td>%.1f</td></tr>", esc(gp.Key), gp.CloseNum, r.winRate(gp), gp.ProfitSum, gp.ProfitPct*100,
+				gp.HoldHours))
+		}
+		b.WriteString("</table>")
+		if len(r.Strats) > topN {
+			b.WriteString(fmt.Sprintf("<p>... %d more</p>", len(r.Strats)-topN))
+		}
+	}
+	b.WriteString("</body></html>")
+	return b.String()
+}
+
+/*
+SendPerfReports
+Build performance reports of all accounts and send them to rpc channels
+统计所有账户的绩效报告并发送到rpc渠道
+*/
+func SendPerfReports(cfg *config.ReportConfig) {
+	accounts := utils2.KeysOfMap(config.Accounts)
+	slices.Sort(accounts)
+	for _, acc := range accounts {
+		rep, err := BuildPerfReport(cfg.Name, acc, cfg.Days)
+		if err != nil {
+			log.Error("build perf report fail", zap.String("acc", acc), zap.Error(err))
+			continue
+		}
+		cid := "equity_" + acc
+		var images map[string][]byte
+		img, err_ := utils.GenLineImg(acc+" equity", rep.Times, rep.Equity, "")
+		if err_ != nil {
+			log.Warn("gen equity image fail", zap.String("acc", acc), zap.Error(err_))
+			cid = ""
+		} else {
+			images = map[string][]byte{cid: img}
+		}
+		rpc.SendReport(acc, rep.Title(), rep.Text(cfg.TopN), rep.HTML(cfg.TopN, cid), images)
+	}
+}
+
+// getOrdersClosedAfter 获取当前任务在closeAfter之后平仓的订单
+func getOrdersClosedAfter(account string, closeAfter int64) ([]*ormo.InOutOrder, *errs.Error) {
+	taskId := ormo.GetTaskID(account)
+	if taskId == 0 {
+		return nil, nil
+	}
+	sess, conn, err := ormo.Conn(orm.DbTrades, false)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return sess.GetOrders(ormo.GetOrdersArgs{
+		TaskID:     taskId,
+		Status:     2,
+		CloseAfter: closeAfter,
+	})
+}
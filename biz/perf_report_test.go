@@ -0,0 +1,55 @@
+package biz
+
+import (
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/orm/ormo"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalcPerfReport(t *testing.T) {
+	newOd := func(strat string, exitMS int64, profit, rate float64) *ormo.InOutOrder {
+		return &ormo.InOutOrder{
+			IOrder: &ormo.IOrder{Symbol: "BTC/USDT", Strategy: strat, EnterAt: exitMS - 1000, ExitAt: exitMS,
+				Profit: profit, ProfitRate: rate},
+			Enter: &ormo.ExOrder{Filled: 1, Average: 100, FeeQuote: 0.1},
+			Exit:  &ormo.ExOrder{Filled: 1, Average: 100 + profit, FeeQuote: 0.1},
+		}
+	}
+	orders := []*ormo.InOutOrder{
+		newOd("ma", 3000, -20, -0.2),
+		newOd("ma", 2000, 10, 0.1),
+		newOd("rsi", 4000, 5, 0.05),
+	}
+	btime.LocShow = time.UTC
+	rep := &PerfReport{Name: "daily", Account: "user1", StartMS: 1000, StopMS: 5000}
+	calcPerfReport(rep, orders, 1000)
+	if rep.Total.CloseNum != 3 || rep.Total.WinNum != 2 || rep.Total.ProfitSum != -5 {
+		t.Fatalf("bad total: %+v", rep.Total)
+	}
+	if math.Abs(rep.Fees-0.6) > 1e-9 {
+		t.Fatalf("bad fees: %v", rep.Fees)
+	}
+	// equity: 1000 -> 1010 -> 990 -> 995
+	if rep.MaxDrawDown != 20 || math.Abs(rep.MaxDrawDownPct-20.0/1010) > 1e-9 {
+		t.Fatalf("bad drawdown: %v %v", rep.MaxDrawDown, rep.MaxDrawDownPct)
+	}
+	if len(rep.Equity) != 5 || rep.Equity[3] != 995 || rep.Times[4] != 5000 {
+		t.Fatalf("bad equity: %v %v", rep.Times, rep.Equity)
+	}
+	if rep.Best.ProfitRate != 0.1 || rep.Worst.ProfitRate != -0.2 {
+		t.Fatalf("bad best/worst: %v %v", rep.Best.ProfitRate, rep.Worst.ProfitRate)
+	}
+	if len(rep.Strats) != 2 || rep.Strats[0].Key != "rsi" {
+		t.Fatalf("strats should sort by profit desc: %+v", rep.Strats)
+	}
+	text := rep.Text(1)
+	if !strings.Contains(text, "rsi") || strings.Contains(text, "ma:") || !strings.Contains(text, "1 more") {
+		t.Fatalf("bad text:\n%s", text)
+	}
+	if !strings.Contains(rep.HTML(10, "eq"), "cid:eq") {
+		t.Fatal("html should contain equity image")
+	}
+}
@@ -0,0 +1,410 @@
+package biz
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banbot/orm"
+	"github.com/banbox/banbot/orm/ormo"
+	"github.com/banbox/banbot/strat"
+	"github.com/banbox/banexg/errs"
+	"github.com/banbox/banexg/log"
+	utils2 "github.com/banbox/banexg/utils"
+	"github.com/sasha-s/go-deadlock"
+	"go.uber.org/zap"
+)
+
+var (
+	jobSnaps     = make(map[string]map[string]string) // account: pair_tf|stratID: json, updated by cacheJobSnap 由cacheJobSnap更新
+	lockJobSnap  deadlock.Mutex
+	onceJobSnaps sync.Once
+)
+
+type jobSnap struct {
+	Version int               `json:"version"`
+	TPMaxs  map[int64]float64 `json:"tp_maxs,omitempty"`
+	More    interface{}       `json:"more,omitempty"`
+}
+
+type walletSnap struct {
+	Available float64            `json:"available"`
+	Pendings  map[string]float64 `json:"pendings,omitempty"`
+	Frozens   map[string]float64 `json:"frozens,omitempty"`
+	Withdraw  float64            `json:"withdraw"`
+}
+
+type batchSnap struct {
+	TimeFrame string      `json:"tf"`
+	Strat     string      `json:"strat"`
+	ExecMS    int64       `json:"exec_ms"`
+	TFMSecs   int64       `json:"tf_msecs"`
+	Items     [][2]string `json:"items"` // [job pair, info pair or ""]
+}
+
+/*
+snapKind return the snapshot kind of the account. In dry-run all accounts share the same task,
+so the account is part of the kind to avoid overwriting each other.
+返回账户的快照类型。模拟运行时所有账户共用同一任务，类型中包含账户避免互相覆盖
+*/
+func snapKind(kind, account string) string {
+	return kind + "@" + account
+}
+
+/*
+SaveSnapshots
+Save the in-memory state of live jobs (custom More, TPMaxs), dry-run wallets and pending batch jobs
+to the trade database, so that the bot can restore them quickly after restart.
+Job states are copied by cacheJobSnap on the bar goroutine, here only the copies are saved.
+保存实盘任务的内存状态（自定义More、TPMaxs）、模拟钱包和待执行批量任务到交易数据库，以便重启后快速恢复
+任务状态由cacheJobSnap在K线处理协程中复制，这里只保存副本
+*/
+func SaveSnapshots() *errs.Error {
+	if !core.LiveMode {
+		return nil
+	}
+	sess, conn, err := ormo.Conn(orm.DbTrades, true)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	for account := range config.Accounts {
+		taskID := ormo.GetTaskID(account)
+		if taskID == 0 {
+			continue
+		}
+		kinds := map[string]map[string]string{
+			ormo.SnapKindJob:    dumpJobSnaps(account),
+			ormo.SnapKindBatch:  dumpBatchSnaps(account),
+			ormo.SnapKindWallet: nil,
+		}
+		if !core.EnvReal {
+			kinds[ormo.SnapKindWallet] = dumpWalletSnaps(account)
+		}
+		tx, err_ := conn.Begin()
+		if err_ != nil {
+			return errs.New(core.ErrDbConnFail, err_)
+		}
+		txSess := sess.WithTx(tx)
+		for kind, items := range kinds {
+			err = txSess.SetSnapshots(taskID, snapKind(kind, account), items)
+			if err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+		if err_ = tx.Commit(); err_ != nil {
+			return errs.New(core.ErrDbExecFail, err_)
+		}
+	}
+	return nil
+}
+
+/*
+cacheJobSnap
+Copy the state of the job for SaveSnapshots. Should be called on the goroutine which processes bars of the job,
+as TPMaxs and More are not safe to read from other goroutines.
+复制任务状态供SaveSnapshots使用。应在处理此任务K线的协程中调用，TPMaxs和More无法在其他协程中安全读取
+*/
+func cacheJobSnap(pairTF string, job *strat.StratJob) {
+	onceJobSnaps.Do(func() {
+		strat.AddJobSub(dropJobSnaps)
+	})
+	key := pairTF + "|" + job.Strat.Name
+	data := ""
+	if job.More != nil || len(job.TPMaxs) > 0 {
+		var err error
+		data, err = utils2.MarshalString(&jobSnap{
+			Version: job.Strat.Version,
+			TPMaxs:  job.TPMaxs,
+			More:    job.More,
+		})
+		if err != nil {
+			log.Warn("marshal job snapshot fail", zap.String("job", key), zap.Error(err))
+			return
+		}
+	}
+	lockJobSnap.Lock()
+	defer lockJobSnap.Unlock()
+	items, ok := jobSnaps[job.Account]
+	if !ok {
+		items = make(map[string]string)
+		jobSnaps[job.Account] = items
+	}
+	if data == "" {
+		delete(items, key)
+	} else {
+		items[key] = data
+	}
+}
+
+// dropJobSnaps remove cached states of jobs removed by LoadStratJobs 删除LoadStratJobs中已移除任务的缓存状态
+func dropJobSnaps(account string, adds, removes []string) {
+	if len(removes) == 0 {
+		return
+	}
+	lockJobSnap.Lock()
+	defer lockJobSnap.Unlock()
+	items := jobSnaps[account]
+	for _, k := range removes {
+		// pair may contain "/", stratID is after the last one 品种可能包含"/"，最后一个之后为策略ID
+		if idx := strings.LastIndex(k, "/"); idx > 0 {
+			delete(items, k[:idx]+"|"+k[idx+1:])
+		}
+	}
+}
+
+func dumpJobSnaps(account string) map[string]string {
+	lockJobSnap.Lock()
+	defer lockJobSnap.Unlock()
+	res := make(map[string]string, len(jobSnaps[account]))
+	for key, data := range jobSnaps[account] {
+		res[key] = data
+	}
+	return res
+}
+
+func dumpWalletSnaps(account string) map[string]string {
+	res := make(map[string]string)
+	wallets := GetWallets(account)
+	for coin, item := range wallets.Items {
+		item.lock.Lock()
+		data, err := utils2.MarshalString(&walletSnap{
+			Available: item.Available,
+			Pendings:  item.Pendings,
+			Frozens:   item.Frozens,
+			Withdraw:  item.Withdraw,
+		})
+		item.lock.Unlock()
+		if err != nil {
+			log.Warn("marshal wallet snapshot fail", zap.String("coin", coin), zap.Error(err))
+			continue
+		}
+		res[coin] = data
+	}
+	return res
+}
+
+func dumpBatchSnaps(account string) map[string]string {
+	lockBatch.Lock()
+	defer lockBatch.Unlock()
+	res := make(map[string]string)
+	for key, tasks := range strat.BatchTasks {
+		item := &batchSnap{ExecMS: tasks.ExecMS, TFMSecs: tasks.TFMSecs}
+		for _, task := range tasks.Map {
+			if item.Strat == "" {
+				// account, timeframe and strategy are the same for all tasks of the batch 批量任务中所有任务的账户、周期和策略相同
+				if task.Job.Account != account {
+					break
+				}
+				item.TimeFrame, item.Strat = task.Job.TimeFrame, task.Job.Strat.Name
+			}
+			info := ""
+			if task.Env != nil {
+				info = task.Symbol
+			}
+			item.Items = append(item.Items, [2]string{task.Job.Symbol.Symbol, info})
+		}
+		if item.Strat == "" {
+			continue
+		}
+		data, err := utils2.MarshalString(item)
+		if err != nil {
+			log.Warn("marshal batch snapshot fail", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		res[key] = data
+	}
+	return res
+}
+
+/*
+RestoreSnapshots
+Restore states saved by SaveSnapshots. Should be called after jobs are created and warmed up.
+Jobs are still fully warmed up to rebuild indicators, only downloading of cached 1m klines is skipped.
+Job states whose strategy version changed are ignored; TPMaxs are only restored for orders still open.
+恢复SaveSnapshots保存的状态。应在任务创建并预热后调用。
+任务仍会完整预热以重建指标，仅跳过已缓存1m K线的下载。
+策略版本变化的任务状态会被忽略；TPMaxs仅对仍未平仓的订单恢复
+*/
+func RestoreSnapshots() *errs.Error {
+	if !core.LiveMode {
+		return nil
+	}
+	sess, conn, err := ormo.Conn(orm.DbTrades, false)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	for account := range config.Accounts {
+		taskID := ormo.GetTaskID(account)
+		if taskID == 0 {
+			continue
+		}
+		jobNum, err := restoreJobSnaps(sess, taskID, account)
+		if err != nil {
+			return err
+		}
+		walletNum := 0
+		if !core.EnvReal {
+			walletNum, err = restoreWalletSnaps(sess, taskID, account)
+			if err != nil {
+				return err
+			}
+		}
+		batchNum, err := restoreBatchSnaps(sess, taskID, account)
+		if err != nil {
+			return err
+		}
+		if jobNum+walletNum+batchNum > 0 {
+			log.Info("restored snapshots", zap.String("acc", account), zap.Int("jobs", jobNum),
+				zap.Int("wallets", walletNum), zap.Int("batches", batchNum))
+		}
+	}
+	return nil
+}
+
+func restoreJobSnaps(sess *ormo.Queries, taskID int64, account string) (int, *errs.Error) {
+	snaps, err := sess.GetSnapshots(taskID, snapKind(ormo.SnapKindJob, account))
+	if err != nil || len(snaps) == 0 {
+		return 0, err
+	}
+	openOds, lock := ormo.GetOpenODs(account)
+	lock.Lock()
+	openIds := make(map[int64]bool, len(openOds))
+	for id := range openOds {
+		openIds[id] = true
+	}
+	lock.Unlock()
+	num := 0
+	for pairTF, jobs := range strat.AccJobs[account] {
+		for stratID, job := range jobs {
+			key := pairTF + "|" + stratID
+			snap, ok := snaps[key]
+			if !ok {
+				continue
+			}
+			var item jobSnap
+			if job.More != nil {
+				// decode into the object created by strategy, keeping its concrete type
+				// 解码到策略创建的对象中，保留其具体类型
+				item.More = job.More
+			}
+			err_ := utils2.UnmarshalString(snap.Data, &item, utils2.JsonNumDefault)
+			if err_ != nil {
+				log.Warn("unmarshal job snapshot fail", zap.String("job", key), zap.Error(err_))
+				continue
+			}
+			if item.Version != job.Strat.Version {
+				log.Info("skip job snapshot for version changed", zap.String("job", key),
+					zap.Int("old", item.Version), zap.Int("new", job.Strat.Version))
+				continue
+			}
+			job.More = item.More
+			for odId, price := range item.TPMaxs {
+				if openIds[odId] {
+					job.TPMaxs[odId] = price
+				}
+			}
+			cacheJobSnap(pairTF, job)
+			num += 1
+		}
+	}
+	return num, nil
+}
+
+func restoreWalletSnaps(sess *ormo.Queries, taskID int64, account string) (int, *errs.Error) {
+	snaps, err := sess.GetSnapshots(taskID, snapKind(ormo.SnapKindWallet, account))
+	if err != nil || len(snaps) == 0 {
+		return 0, err
+	}
+	wallets := GetWallets(account)
+	for coin, snap := range snaps {
+		var item walletSnap
+		err_ := utils2.UnmarshalString(snap.Data, &item, utils2.JsonNumDefault)
+		if err_ != nil {
+			log.Warn("unmarshal wallet snapshot fail", zap.String("coin", coin), zap.Error(err_))
+			continue
+		}
+		wallet := wallets.Get(coin)
+		wallet.lock.Lock()
+		wallet.Available = item.Available
+		wallet.Withdraw = item.Withdraw
+		wallet.Pendings = make(map[string]float64)
+		wallet.Frozens = make(map[string]float64)
+		for k, v := range item.Pendings {
+			wallet.Pendings[k] = v
+		}
+		for k, v := range item.Frozens {
+			wallet.Frozens[k] = v
+		}
+		wallet.lock.Unlock()
+	}
+	return len(snaps), nil
+}
+
+func restoreBatchSnaps(sess *ormo.Queries, taskID int64, account string) (int, *errs.Error) {
+	snaps, err := sess.GetSnapshots(taskID, snapKind(ormo.SnapKindBatch, account))
+	if err != nil || len(snaps) == 0 {
+		return 0, err
+	}
+	lockBatch.Lock()
+	defer lockBatch.Unlock()
+	curMS := btime.TimeMS()
+	num := 0
+	for name, snap := range snaps {
+		var item batchSnap
+		err_ := utils2.UnmarshalString(snap.Data, &item, utils2.JsonNumDefault)
+		if err_ != nil {
+			log.Warn("unmarshal batch snapshot fail", zap.String("key", name), zap.Error(err_))
+			continue
+		}
+		// Expired batches belong to a previous bar, skip them
+		// 已过期的批量任务属于之前的bar，跳过
+		if item.ExecMS+item.TFMSecs <= curMS || item.Strat == "" {
+			continue
+		}
+		tf, stratID := item.TimeFrame, item.Strat
+		key := fmt.Sprintf("%s_%s_%s", tf, account, stratID)
+		tasks := &strat.BatchMap{
+			Map:     make(map[string]*strat.JobEnv),
+			TFMSecs: item.TFMSecs,
+			ExecMS:  item.ExecMS,
+		}
+		for _, it := range item.Items {
+			pair, info := it[0], it[1]
+			if info == "" {
+				job, ok := strat.AccJobs[account][fmt.Sprintf("%s_%s", pair, tf)][stratID]
+				if ok {
+					tasks.Map[pair+"_main"] = &strat.JobEnv{Job: job, Symbol: pair}
+				}
+				continue
+			}
+			infoKey := fmt.Sprintf("%s_%s", info, tf)
+			job, ok := strat.AccInfoJobs[account][infoKey][stratID+"_"+pair]
+			env, ok2 := strat.Envs[infoKey]
+			if ok && ok2 {
+				tasks.Map[info+"_info"] = &strat.JobEnv{Job: job, Env: env, Symbol: info}
+			}
+		}
+		if len(tasks.Map) == 0 {
+			continue
+		}
+		if old, ok := strat.BatchTasks[key]; ok {
+			// keep tasks added after startup
+			// 保留启动后添加的任务
+			for k, v := range tasks.Map {
+				if _, ok = old.Map[k]; !ok {
+					old.Map[k] = v
+				}
+			}
+		} else {
+			strat.BatchTasks[key] = tasks
+		}
+		num += 1
+	}
+	return num, nil
+}
@@ -1,11 +1,21 @@
 package biz
 
 import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/core"
 	"github.com/banbox/banbot/orm"
 	"github.com/banbox/banbot/orm/ormo"
 	"github.com/banbox/banbot/rpc"
 	"github.com/banbox/banbot/strat"
+	"github.com/banbox/banexg/errs"
 	"github.com/banbox/banexg/log"
+	utils2 "github.com/banbox/banexg/utils"
 	"go.uber.org/zap"
 )
 
@@ -180,6 +190,178 @@ func (m *TelegramOrderManager) GetOrderStats(account string) (longCount, shortCo
 	return longCount, shortCount, nil
 }
 
+// GetBalance 获取账户钱包余额及法币总值
+func (m *TelegramOrderManager) GetBalance(account string) ([]*rpc.BalanceItem, float64, error) {
+	wallets := GetWallets(account)
+	var result []*rpc.BalanceItem
+	for coin, item := range wallets.Items {
+		total := item.Total(true)
+		if total == 0 {
+			continue
+		}
+		result = append(result, &rpc.BalanceItem{
+			Coin:      coin,
+			Total:     total,
+			Free:      item.Available,
+			Used:      item.Used(),
+			UPol:      item.UnrealizedPOL,
+			TotalFiat: total * core.GetPriceSafe(coin, ""),
+		})
+	}
+	slices.SortFunc(result, func(a, b *rpc.BalanceItem) int {
+		return cmp.Compare(b.TotalFiat, a.TotalFiat)
+	})
+	return result, wallets.FiatValue(true), nil
+}
+
+// GetProfitSta 获取最近days天(0表示全部)已平仓订单和当前持仓的收益统计
+func (m *TelegramOrderManager) GetProfitSta(account string, days int) (*rpc.ProfitSta, error) {
+	res := &rpc.ProfitSta{}
+	openOds, lock := ormo.GetOpenODs(account)
+	lock.Lock()
+	for _, od := range openOds {
+		res.OpenNum += 1
+		res.OpenProfit += od.Profit
+	}
+	lock.Unlock()
+	orders, err := getDoneOrders(account, days)
+	if err != nil {
+		return nil, err
+	}
+	var totalCost float64
+	for _, od := range orders {
+		res.DoneNum += 1
+		res.DoneProfit += od.Profit
+		totalCost += od.EnterCost()
+		if od.Profit > 0 {
+			res.WinNum += 1
+		}
+		if res.BestPair == "" || od.ProfitRate > res.BestRate {
+			res.BestRate = od.ProfitRate
+			res.BestPair = od.Symbol
+		}
+	}
+	if totalCost > 0 {
+		res.DoneProfitPct = res.DoneProfit / totalCost * 100
+	}
+	return res, nil
+}
+
+/*
+GetPerformance 按groupBy对最近days天(0表示全部)已平仓订单分组统计。
+groupBy: strategy/symbol/enterTag/exitTag/day，与web接口group_sta一致
+*/
+func (m *TelegramOrderManager) GetPerformance(account, groupBy string, days int) ([]*rpc.PerfItem, error) {
+	var odKey func(od *ormo.InOutOrder) string
+	switch groupBy {
+	case "strategy":
+		odKey = func(od *ormo.InOutOrder) string {
+			return od.Strategy
+		}
+	case "symbol":
+		odKey = func(od *ormo.InOutOrder) string {
+			return od.Symbol
+		}
+	case "enterTag":
+		odKey = func(od *ormo.InOutOrder) string {
+			return fmt.Sprintf("%v:%v", od.Strategy, od.EnterTag)
+		}
+	case "exitTag":
+		odKey = func(od *ormo.InOutOrder) string {
+			return fmt.Sprintf("%v:%v", od.Strategy, od.ExitTag)
+		}
+	case "day":
+		tfMSecs := int64(utils2.TFToSecs("1d") * 1000)
+		odKey = func(od *ormo.InOutOrder) string {
+			dateMS := utils2.AlignTfMSecs(od.RealExitMS(), tfMSecs)
+			return btime.ToDateStrLoc(dateMS, "2006-01-02")
+		}
+	default:
+		return nil, errs.NewMsg(errs.CodeParamInvalid, "unsupport group type: %s", groupBy)
+	}
+	orders, err := getDoneOrders(account, days)
+	if err != nil {
+		return nil, err
+	}
+	groups := GroupOrders(orders, odKey)
+	res := make([]*rpc.PerfItem, 0, len(groups))
+	for _, gp := range groups {
+		res = append(res, &rpc.PerfItem{
+			Key:       gp.Key,
+			CloseNum:  gp.CloseNum,
+			WinNum:    gp.WinNum,
+			ProfitSum: gp.ProfitSum,
+			ProfitPct: gp.ProfitPct * 100,
+			HoldHours: gp.HoldHours,
+		})
+	}
+	slices.SortFunc(res, func(a, b *rpc.PerfItem) int {
+		if groupBy == "day" {
+			return strings.Compare(a.Key, b.Key)
+		}
+		if a.ProfitSum != b.ProfitSum {
+			return cmp.Compare(b.ProfitSum, a.ProfitSum)
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	return res, nil
+}
+
+// PauseStrategy 暂停或恢复策略的新开单，策略名需已加载
+func (m *TelegramOrderManager) PauseStrategy(account, stratName string, pause bool) error {
+	if _, ok := strat.Versions[stratName]; !ok {
+		return errs.NewMsg(errs.CodeParamInvalid, "strategy not found: %s", stratName)
+	}
+	strat.PauseStrat(account, stratName, pause)
+	log.Info("telegram pause strategy", zap.String("acc", account),
+		zap.String("strat", stratName), zap.Bool("pause", pause))
+	return nil
+}
+
+// GetPausedStrats 获取已暂停开单的策略
+func (m *TelegramOrderManager) GetPausedStrats(account string) []string {
+	return strat.GetPausedStrats(account)
+}
+
+// ForceExitPair 强制平仓某品种的所有订单，pair支持短格式如BTC
+func (m *TelegramOrderManager) ForceExitPair(account, pair string) (int, int, error) {
+	pairs, err := config.ParsePairs(pair)
+	if err != nil {
+		return 0, 0, err
+	}
+	openOds, lock := ormo.GetOpenODs(account)
+	var targets []*ormo.InOutOrder
+	lock.Lock()
+	for _, od := range openOds {
+		if od.Symbol == pairs[0] {
+			targets = append(targets, od)
+		}
+	}
+	lock.Unlock()
+	if len(targets) == 0 {
+		return 0, 0, nil
+	}
+	closeNum, failNum, err := CloseAccOrders(account, targets, &strat.ExitReq{
+		Tag:   "telegram_force_exit",
+		Force: true,
+	})
+	if err != nil {
+		// 返回nil的*errs.Error会导致error接口非nil
+		return closeNum, failNum, err
+	}
+	return closeNum, failNum, nil
+}
+
+// getDoneOrders 获取当前任务最近days天(0表示全部)已平仓的订单
+func getDoneOrders(account string, days int) ([]*ormo.InOutOrder, *errs.Error) {
+	var closeAfter int64
+	if days > 0 {
+		dayMSecs := int64(utils2.TFToSecs("1d") * 1000)
+		closeAfter = utils2.AlignTfMSecs(btime.UTCStamp(), dayMSecs) - int64(days-1)*dayMSecs
+	}
+	return getOrdersClosedAfter(account, closeAfter)
+}
+
 // OrderNotFoundError 订单未找到错误
 type OrderNotFoundError struct {
 	OrderID int64
@@ -164,6 +164,9 @@ func (t *Trader) onAccountKline(account string, env *ta.BarEnv, bar *orm.InfoKli
 				return err
 			}
 		}
+		if core.LiveMode {
+			cacheJobSnap(envKey, job)
+		}
 	}
 	// invoke OnInfoBar
 	// 更新辅助订阅数据
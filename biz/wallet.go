@@ -20,9 +20,13 @@ import (
 )
 
 var (
-	accWallets = make(map[string]*BanWallets)
+	accWallets     = make(map[string]*BanWallets)
+	balanceSubs    []FnBalanceChange // listeners of balance updates from exchange 交易所余额更新的监听
+	lockBalanceSub deadlock.Mutex
 )
 
+type FnBalanceChange func(acc string, assets []*banexg.Asset)
+
 type ItemWallet struct {
 	Coin          string             // Coin code, not pair 币代码，非交易对
 	Available     float64            // Available balance 可用余额
@@ -963,6 +967,23 @@ func UpdateWalletByBalances(wallets *BanWallets, item *banexg.Balances) {
 	if len(msgList) > 0 {
 		log.Debug(fmt.Sprintf("update balances %s: %s", wallets.Account, strings.Join(msgList, "  ")))
 	}
+	lockBalanceSub.Lock()
+	subs := balanceSubs
+	lockBalanceSub.Unlock()
+	for _, cb := range subs {
+		cb(wallets.Account, items)
+	}
+}
+
+/*
+AddBalanceSub
+Add a listener for balances updated from exchange, Total of assets is the legal value.
+添加交易所余额更新的监听，资产的Total为法币价值
+*/
+func AddBalanceSub(cb FnBalanceChange) {
+	lockBalanceSub.Lock()
+	balanceSubs = append(balanceSubs, cb)
+	lockBalanceSub.Unlock()
 }
 
 /*
@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"hash/fnv"
 	"math"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -144,11 +146,9 @@ func ParseConfigs(paths []string, showLog bool) (*Config, *errs.Error) {
 		if err != nil {
 			return nil, errs.NewFull(core.ErrIOReadFail, err, "Read %s Fail", path)
 		}
-		fileData = []byte(os.ExpandEnv(string(fileData)))
-		var unpak map[string]interface{}
-		err = yaml.Unmarshal(fileData, &unpak)
-		if err != nil {
-			return nil, errs.NewFull(errs.CodeUnmarshalFail, err, "Unmarshal %s Fail", path)
+		unpak, err2 := resolveYml(fileData, path)
+		if err2 != nil {
+			return nil, err2
 		}
 		for key := range noExtends {
 			if _, ok := unpak[key]; ok {
@@ -174,13 +174,11 @@ func ParseConfig(path string) (*Config, *errs.Error) {
 
 func ParseYmlConfig(fileData []byte, path string) (*Config, *errs.Error) {
 	var res Config
-	fileData = []byte(os.ExpandEnv(string(fileData)))
-	var unpak map[string]interface{}
-	err := yaml.Unmarshal(fileData, &unpak)
-	if err != nil {
-		return nil, errs.NewFull(errs.CodeUnmarshalFail, err, "Unmarshal %s Fail", path)
+	unpak, err2 := resolveYml(fileData, path)
+	if err2 != nil {
+		return nil, err2
 	}
-	err = mapstructure.Decode(unpak, &res)
+	err := mapstructure.Decode(unpak, &res)
 	if err != nil {
 		return nil, errs.NewFull(errs.CodeUnmarshalFail, err, "decode Config Fail")
 	}
@@ -310,6 +308,10 @@ func ApplyConfig(args *CmdArgs, c *Config) *errs.Error {
 	if CloseOnStuck == 0 {
 		CloseOnStuck = 20
 	}
+	SnapshotCron = c.SnapshotCron
+	if SnapshotCron == "" {
+		SnapshotCron = "20 * * * * *"
+	}
 	StakeAmount = c.StakeAmount
 	StakePct = c.StakePct
 	MaxStakeAmt = c.MaxStakeAmt
@@ -332,6 +334,8 @@ func ApplyConfig(args *CmdArgs, c *Config) *errs.Error {
 		BTNetCost = 15
 	}
 	RelaySimUnFinish = c.RelaySimUnFinish
+	BtBenchmark = c.BtBenchmark
+	BtKlineHtml = c.BtKlineHtml
 	NTPLangCode = c.NTPLangCode
 	if NTPLangCode == "" {
 		NTPLangCode = "none"
@@ -344,12 +348,18 @@ func ApplyConfig(args *CmdArgs, c *Config) *errs.Error {
 	if BTInLive == nil {
 		BTInLive = &BtInLiveConfig{}
 	}
+	PaperTrade = c.PaperTrade
+	if PaperTrade == nil {
+		PaperTrade = &PaperTradeConfig{}
+	}
 	OrderBarMax = c.OrderBarMax
 	if OrderBarMax == 0 {
 		OrderBarMax = 500
 	}
+	Seed = c.Seed
 	MaxOpenOrders = c.MaxOpenOrders
 	MaxSimulOpen = c.MaxSimulOpen
+	MaxOpenTags = c.MaxOpenTags
 	WalletAmounts = c.WalletAmounts
 	DrawBalanceOver = c.DrawBalanceOver
 	StakeCurrency = c.StakeCurrency
@@ -431,6 +441,38 @@ func ApplyConfig(args *CmdArgs, c *Config) *errs.Error {
 		Mail = &MailConfig{}
 	}
 	Webhook = c.Webhook
+	Confirm = c.Confirm
+	if Confirm == nil {
+		Confirm = &ConfirmConfig{}
+	}
+	if Confirm.TTLSecs <= 0 {
+		Confirm.TTLSecs = 120
+	}
+	Reports = c.Reports
+	for _, r := range Reports {
+		if r.Days <= 0 {
+			r.Days = 1
+		}
+		if r.TopN <= 0 {
+			r.TopN = 10
+		}
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("%dd", r.Days)
+		}
+	}
+	RateLimit = c.RateLimit
+	if RateLimit == nil {
+		RateLimit = &RateLimitConfig{}
+	}
+	if RateLimit.Weights == nil {
+		RateLimit.Weights = map[string]int{"binance": 2400}
+	}
+	if RateLimit.DataPct <= 0 || RateLimit.DataPct > 100 {
+		RateLimit.DataPct = 70
+	}
+	if RateLimit.BackoffSecs <= 0 {
+		RateLimit.BackoffSecs = 30
+	}
 	return nil
 }
 
@@ -485,6 +527,62 @@ func SetRunPolicy(index bool, items ...*RunPolicyConfig) *errs.Error {
 	return nil
 }
 
+/*
+ReloadRunPolicy
+Apply run_policy, pairs, pairlists and pairmgr from the new config, used for hot-reloading in live mode.
+Return IDs of added, removed and changed policies compared with the current RunPolicy.
+应用新配置中的run_policy、pairs、pairlists、pairmgr，用于实盘热加载。返回相比当前RunPolicy新增、删除、修改的策略ID
+*/
+func ReloadRunPolicy(c *Config) ([]string, []string, []string, *errs.Error) {
+	olds := make(map[string]string)
+	for _, pol := range RunPolicy {
+		olds[pol.ID()] = pol.dumpCompare()
+	}
+	pairs, err := ParsePairs(c.Pairs...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	err = SetRunPolicy(true, c.RunPolicy...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if c.PairMgr == nil {
+		c.PairMgr = &PairMgrConfig{}
+	}
+	Pairs, _ = utils2.UniqueItems(pairs)
+	PairMgr = c.PairMgr
+	PairFilters = c.PairFilters
+	Data.Pairs = c.Pairs
+	Data.PairMgr = c.PairMgr
+	Data.PairFilters = c.PairFilters
+	Data.RunPolicy = c.RunPolicy
+	var adds, removes, changes []string
+	for _, pol := range RunPolicy {
+		polID := pol.ID()
+		text, ok := olds[polID]
+		if !ok {
+			adds = append(adds, polID)
+		} else if text != pol.dumpCompare() {
+			changes = append(changes, polID)
+		}
+		delete(olds, polID)
+	}
+	for polID := range olds {
+		removes = append(removes, polID)
+	}
+	return adds, removes, changes, nil
+}
+
+func (c *RunPolicyConfig) dumpCompare() string {
+	item := *c
+	item.Score = 0
+	data, err := yaml.Marshal(&item)
+	if err != nil {
+		return c.ToYaml()
+	}
+	return string(data)
+}
+
 // GetStaticPairs 合并pairs和run_policy.pairs返回，bool表示是否需要动态计算
 func GetStaticPairs() ([]string, bool) {
 	var res = make([]string, 0, len(Pairs))
@@ -725,9 +823,14 @@ func (c *Config) Clone() *Config {
 		LowCostAction:    c.LowCostAction,
 		BTNetCost:        c.BTNetCost,
 		RelaySimUnFinish: c.RelaySimUnFinish,
+		BtBenchmark:      c.BtBenchmark,
+		BtKlineHtml:      c.BtKlineHtml,
+		PaperTrade:       c.PaperTrade,
 		OrderBarMax:      c.OrderBarMax,
+		Seed:             c.Seed,
 		MaxOpenOrders:    c.MaxOpenOrders,
 		MaxSimulOpen:     c.MaxSimulOpen,
+		MaxOpenTags:      c.MaxOpenTags,
 		WalletAmounts:    c.WalletAmounts,
 		DrawBalanceOver:  c.DrawBalanceOver,
 		StakeCurrency:    c.StakeCurrency,
@@ -747,6 +850,9 @@ func (c *Config) Clone() *Config {
 		PairFilters:      c.PairFilters,
 		SpiderAddr:       c.SpiderAddr,
 		Webhook:          c.Webhook,
+		Confirm:          c.Confirm,
+		Reports:          c.Reports,
+		RateLimit:        c.RateLimit,
 		Accounts:         c.Accounts,
 		Exchange:         c.Exchange,
 	}
@@ -822,7 +928,9 @@ func (c *Config) Desensitize() *Config {
 		}
 	}
 
-	return res
+	// Mask values resolved from !file, !secret and ${ENV} of sensitive keys
+	// 屏蔽从!file、!secret以及敏感键的${ENV}解析的值
+	return res.maskSecrets()
 }
 
 func DumpYaml(desensitize bool) ([]byte, *errs.Error) {
@@ -1268,3 +1376,15 @@ func ParsePairs(pairs ...string) ([]string, *errs.Error) {
 	}
 	return result, nil
 }
+
+/*
+NewRand
+Create a random generator for the named source, derived from the run-level `seed`, so results are reproducible
+with the same seed and sources don't affect each other.
+为指定名称的随机源创建随机数生成器，由运行级`seed`派生，相同种子结果可复现，且各随机源互不影响
+*/
+func NewRand(name string) *rand.Rand {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return rand.New(rand.NewSource(Seed ^ int64(h.Sum64())))
+}
@@ -52,7 +52,11 @@ type CmdArgs struct {
 	InType        string  // Input file data type 输入文件的数据类型
 	RunEveryTF    string  // run once every n timeframe
 	BatchSize     int
-	Separate      bool // Used for backtesting. When true, the strategy combination is tested separately. 用于回测，true时策略组合单独测试
+	Separate      bool    // Used for backtesting. When true, the strategy combination is tested separately. 用于回测，true时策略组合单独测试
+	Sigma         float64 // Outlier threshold in sigma for kline audit K线审计的异常值标准差倍数
+	Repair        bool    // Re-download flagged bars in kline audit K线审计时重新下载有问题的bar
+	SrcA          string  // Kline source A for compare, e.g. db, exg:binance.linear, zip:<dir>, proto:<dir> 用于对比的K线来源A
+	SrcB          string  // Kline source B for compare 用于对比的K线来源B
 	Inited        bool
 	DeadLock      bool
 }
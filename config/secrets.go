@@ -0,0 +1,299 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/banbox/banbot/core"
+	utils2 "github.com/banbox/banbot/utils"
+	"github.com/banbox/banexg/errs"
+	"github.com/sasha-s/go-deadlock"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	secretMask      = "******"
+	secretsFileName = "secrets.enc"
+	tagFile         = "!file"
+	tagSecret       = "!secret"
+)
+
+var (
+	resolvedVals = make(map[string]bool) // secret values resolved from env/file/secrets, masked in dumps 从环境变量/文件/密钥解析的敏感值，导出时屏蔽
+	secretItems  map[string]string       // decrypted items of secrets file 解密后的密钥文件内容
+	lockSecret   deadlock.Mutex
+)
+
+/*
+resolveYml
+Parse yaml and resolve references in scalar values:
+`${ENV_VAR}` or `$ENV_VAR`: environment variable;
+`!file /path/to/file`: content of the file (trimmed);
+`!secret name`: item in the encrypted secrets file, which is `$BanSecretsFile` or `secrets.enc` in data dir,
+decrypted by hex key in `$BanMasterKey`.
+解析yaml并替换标量值中的引用：
+`${ENV_VAR}`或`$ENV_VAR`：环境变量；
+`!file /path/to/file`：文件内容（去除首尾空白）；
+`!secret name`：加密密钥文件中的项，文件为`$BanSecretsFile`或数据目录下的`secrets.enc`，使用`$BanMasterKey`中的十六进制密钥解密
+*/
+func resolveYml(fileData []byte, path string) (map[string]interface{}, *errs.Error) {
+	var node yaml.Node
+	err := yaml.Unmarshal(fileData, &node)
+	if err != nil {
+		return nil, errs.NewFull(errs.CodeUnmarshalFail, err, "Unmarshal %s Fail", path)
+	}
+	err2 := resolveNode(&node, "")
+	if err2 != nil {
+		return nil, err2
+	}
+	var unpak map[string]interface{}
+	err = node.Decode(&unpak)
+	if err != nil {
+		return nil, errs.NewFull(errs.CodeUnmarshalFail, err, "Unmarshal %s Fail", path)
+	}
+	return unpak, nil
+}
+
+/*
+resolveNode resolve references in scalar node, key is the mapping key of the node.
+`!file`/`!secret` values are always recorded as secrets, env values only for sensitive keys.
+解析标量节点中的引用，key为节点的映射键。`!file`/`!secret`的值总是记为敏感值，环境变量仅在敏感键下记录
+*/
+func resolveNode(n *yaml.Node, key string) *errs.Error {
+	if n.Kind != yaml.ScalarNode {
+		for i, child := range n.Content {
+			childKey := key
+			if n.Kind == yaml.MappingNode {
+				if i%2 == 0 {
+					continue
+				}
+				childKey = n.Content[i-1].Value
+			}
+			if err := resolveNode(child, childKey); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	switch n.Tag {
+	case tagFile:
+		data, err := os.ReadFile(ParsePath(expandEnv(n.Value, false)))
+		if err != nil {
+			return errs.NewFull(core.ErrIOReadFail, err, "read %s fail", n.Value)
+		}
+		setResolved(n, strings.TrimSpace(string(data)))
+	case tagSecret:
+		val, err := getSecret(strings.TrimSpace(n.Value))
+		if err != nil {
+			return err
+		}
+		setResolved(n, val)
+	default:
+		if !strings.Contains(n.Value, "$") {
+			return nil
+		}
+		val := expandEnv(n.Value, isSensitiveKey(key))
+		if val != n.Value {
+			n.Value = val
+			if n.Style == 0 {
+				// re-resolve type for plain scalars, e.g. `port: ${PORT}` 重新解析普通标量的类型
+				n.Tag = ""
+			}
+		}
+	}
+	return nil
+}
+
+func setResolved(n *yaml.Node, val string) {
+	n.Tag = "!!str"
+	n.Value = val
+	addResolved(val)
+}
+
+func expandEnv(text string, record bool) string {
+	return os.Expand(text, func(key string) string {
+		val := os.Getenv(key)
+		if record {
+			addResolved(val)
+		}
+		return val
+	})
+}
+
+var sensitiveKeys = []string{"pass", "pwd", "secret", "token", "api_key", "apikey", "private_key", "url", "dsn"}
+
+// isSensitiveKey whether the config key holds passwords, api keys, tokens or db urls 配置键是否为密码、API密钥、令牌或数据库url
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if key == "key" {
+		return true
+	}
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func addResolved(val string) {
+	if strings.TrimSpace(val) == "" {
+		return
+	}
+	lockSecret.Lock()
+	resolvedVals[val] = true
+	lockSecret.Unlock()
+}
+
+func getSecret(name string) (string, *errs.Error) {
+	lockSecret.Lock()
+	defer lockSecret.Unlock()
+	if secretItems == nil {
+		items, err := loadSecrets()
+		if err != nil {
+			return "", err
+		}
+		secretItems = items
+	}
+	val, ok := secretItems[name]
+	if !ok {
+		return "", errs.NewMsg(core.ErrBadConfig, "secret `%s` not found", name)
+	}
+	return val, nil
+}
+
+func getSecretsPath() string {
+	path := os.Getenv("BanSecretsFile")
+	if path != "" {
+		return ParsePath(path)
+	}
+	dataDir := GetDataDirSafe()
+	if dataDir == "" {
+		return ""
+	}
+	return filepath.Join(dataDir, secretsFileName)
+}
+
+func loadSecrets() (map[string]string, *errs.Error) {
+	path := getSecretsPath()
+	if path == "" {
+		return nil, errs.NewMsg(core.ErrBadConfig, "env `BanSecretsFile` or `BanDataDir` is required for !secret")
+	}
+	key := os.Getenv("BanMasterKey")
+	if key == "" {
+		return nil, errs.NewMsg(core.ErrBadConfig, "env `BanMasterKey` is required for !secret")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errs.NewFull(core.ErrIOReadFail, err, "read secrets %s fail", path)
+	}
+	plain, err := utils2.DecryptData(data, key)
+	if err != nil {
+		return nil, errs.New(core.ErrDecryptFail, err)
+	}
+	var res map[string]string
+	err = yaml.Unmarshal(plain, &res)
+	if err != nil {
+		return nil, errs.NewFull(errs.CodeUnmarshalFail, err, "Unmarshal secrets Fail")
+	}
+	return res, nil
+}
+
+/*
+EncryptSecrets
+Encrypt a plain yaml file of `name: value` items into a secrets file. A new hex master key is generated when
+key is empty. Return the key used.
+将`name: value`格式的明文yaml文件加密为密钥文件。key为空时生成新的十六进制主密钥。返回使用的密钥
+*/
+func EncryptSecrets(inPath, outPath, key string) (string, *errs.Error) {
+	data, err := os.ReadFile(inPath)
+	if err != nil {
+		return "", errs.NewFull(core.ErrIOReadFail, err, "read %s fail", inPath)
+	}
+	var items map[string]string
+	err = yaml.Unmarshal(data, &items)
+	if err != nil {
+		return "", errs.NewFull(errs.CodeUnmarshalFail, err, "secrets should be `name: value` items")
+	}
+	if key == "" {
+		buf := make([]byte, 32)
+		if _, err = rand.Read(buf); err != nil {
+			return "", errs.New(core.ErrEncryptFail, err)
+		}
+		key = hex.EncodeToString(buf)
+	}
+	encData, err := utils2.EncryptData(data, key)
+	if err != nil {
+		return "", errs.New(core.ErrEncryptFail, err)
+	}
+	if outPath == "" {
+		outPath = getSecretsPath()
+	}
+	err2 := utils2.WriteFile(outPath, encData)
+	if err2 != nil {
+		return "", err2
+	}
+	return key, nil
+}
+
+/*
+maskSecrets
+Replace secret values resolved from env/file/secrets with mask in the config. Values longer than 7 chars are also
+masked when they are part of other values (e.g. password in database url).
+将配置中从环境变量/文件/密钥解析得到的敏感值替换为掩码。长度超过7的值作为其他值的一部分时（如数据库url中的密码）也会被屏蔽
+*/
+func (c *Config) maskSecrets() *Config {
+	lockSecret.Lock()
+	vals := make([]string, 0, len(resolvedVals))
+	for v := range resolvedVals {
+		vals = append(vals, v)
+	}
+	lockSecret.Unlock()
+	if len(vals) == 0 {
+		return c
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return c
+	}
+	var node yaml.Node
+	if err = yaml.Unmarshal(data, &node); err != nil {
+		return c
+	}
+	maskNode(&node, vals)
+	var res Config
+	if err = node.Decode(&res); err != nil {
+		return c
+	}
+	res.TimeRange = c.TimeRange
+	return &res
+}
+
+func maskNode(n *yaml.Node, vals []string) {
+	if n.Kind != yaml.ScalarNode {
+		for i, child := range n.Content {
+			if n.Kind == yaml.MappingNode && i%2 == 0 {
+				continue
+			}
+			maskNode(child, vals)
+		}
+		return
+	}
+	if n.ShortTag() != "!!str" {
+		return
+	}
+	for _, v := range vals {
+		if n.Value == v {
+			n.Value = secretMask
+			n.Tag = "!!str"
+			return
+		}
+		if len(v) > 7 && strings.Contains(n.Value, v) {
+			n.Value = strings.ReplaceAll(n.Value, v, secretMask)
+			n.Tag = "!!str"
+		}
+	}
+}
@@ -25,6 +25,7 @@ var (
 	ChargeOnBomb     bool
 	TakeOverStrat    string
 	CloseOnStuck     int
+	SnapshotCron     string  // cron for saving live state snapshots, "-" to disable 保存实盘状态快照的cron，"-"表示禁用
 	StakeAmount      float64 // The amount of a single order, the priority is lower than StakePct 单笔开单金额，优先级低于StakePct
 	StakePct         float64 // Percentage of single bill amount 单笔开单金额百分比
 	MaxStakeAmt      float64 // Maximum bill amount for a single transaction 单笔最大开单金额
@@ -33,12 +34,17 @@ var (
 	LowCostAction    string  // Actions taken when stake amount less than the minimum amount 花费不足最小金额时的动作：ignore, keep
 	BTNetCost        float64 // Order placement delay during backtesting, simulated slippage, unit seconds 回测时下单延迟，模拟滑点，单位秒
 	RelaySimUnFinish bool    // 交易新品种时(回测/实盘)，是否从开始时间未平仓订单接力开始交易
+	BtBenchmark      string  // Benchmark in backtest reports: cash/basket/symbol 回测报告中的基准：cash现金/basket等权组合/标的买入持有
+	BtKlineHtml      int     // Kline html with orders in backtest: 0 disable, -1 all pairs, N top N pairs 回测输出带订单的K线html：0禁用，-1全部品种，N订单最多的N个品种
 	NTPLangCode      string  // NTP真实时间同步所用langCode，默认none不启用
 	ShowLangCode     string
 	BTInLive         *BtInLiveConfig
-	OrderBarMax      int // 查找开始时间未平仓订单向前模拟最大bar数量
+	PaperTrade       *PaperTradeConfig // Fill simulation of dry-run live trading 模拟实盘的成交模拟
+	OrderBarMax      int               // 查找开始时间未平仓订单向前模拟最大bar数量
+	Seed             int64             // run-level seed for all random sources 所有随机源使用的运行级种子
 	MaxOpenOrders    int
 	MaxSimulOpen     int
+	MaxOpenTags      map[string]int // max open orders of symbols with the tag 带有该标签的品种最大开单数量
 	WalletAmounts    map[string]float64
 	DrawBalanceOver  float64
 	StakeCurrency    []string
@@ -63,6 +69,9 @@ var (
 	RPCChannels      map[string]map[string]interface{}
 	Mail             *MailConfig
 	Webhook          map[string]map[string]string
+	Confirm          *ConfirmConfig   // confirmation of destructive actions 危险操作的确认
+	Reports          []*ReportConfig  // scheduled performance reports 定时绩效报告
+	RateLimit        *RateLimitConfig // request scheduler of exchange rest api 交易所rest接口的请求调度
 
 	outSaved = false // Docker外部传入配置是否已保存到config.local.yml
 )
@@ -77,6 +86,7 @@ var (
 		"wallet_amounts": true,
 		"fatal_stop":     true,
 		"watch_jobs":     true,
+		"max_open_tags":  true,
 	}
 )
 
@@ -99,6 +109,7 @@ type Config struct {
 	ChargeOnBomb     bool                              `yaml:"charge_on_bomb,omitempty" mapstructure:"charge_on_bomb"`
 	TakeOverStrat    string                            `yaml:"take_over_strat,omitempty" mapstructure:"take_over_strat"`
 	CloseOnStuck     int                               `yaml:"close_on_stuck,omitempty" mapstructure:"close_on_stuck"`
+	SnapshotCron     string                            `yaml:"snapshot_cron,omitempty" mapstructure:"snapshot_cron"`
 	StakeAmount      float64                           `yaml:"stake_amount,omitempty" mapstructure:"stake_amount"`
 	StakePct         float64                           `yaml:"stake_pct,omitempty" mapstructure:"stake_pct"`
 	MaxStakeAmt      float64                           `yaml:"max_stake_amt,omitempty" mapstructure:"max_stake_amt"`
@@ -107,12 +118,17 @@ type Config struct {
 	LowCostAction    string                            `yaml:"low_cost_action,omitempty" mapstructure:"low_cost_action"`
 	BTNetCost        float64                           `yaml:"bt_net_cost,omitempty" mapstructure:"bt_net_cost"`
 	RelaySimUnFinish bool                              `yaml:"relay_sim_unfinish,omitempty" mapstructure:"relay_sim_unfinish"`
+	BtBenchmark      string                            `yaml:"bt_benchmark,omitempty" mapstructure:"bt_benchmark"`
+	BtKlineHtml      int                               `yaml:"bt_kline_html,omitempty" mapstructure:"bt_kline_html"`
 	NTPLangCode      string                            `yaml:"ntp_lang_code,omitempty" mapstructure:"ntp_lang_code"`
 	ShowLangCode     string                            `yaml:"show_lang_code,omitempty" mapstructure:"show_lang_code"`
 	BTInLive         *BtInLiveConfig                   `yaml:"bt_in_live,omitempty" mapstructure:"bt_in_live"`
+	PaperTrade       *PaperTradeConfig                 `yaml:"paper_trade,omitempty" mapstructure:"paper_trade"`
 	OrderBarMax      int                               `yaml:"order_bar_max,omitempty" mapstructure:"order_bar_max"`
+	Seed             int64                             `yaml:"seed,omitempty" mapstructure:"seed"`
 	MaxOpenOrders    int                               `yaml:"max_open_orders,omitempty" mapstructure:"max_open_orders"`
 	MaxSimulOpen     int                               `yaml:"max_simul_open,omitempty" mapstructure:"max_simul_open"`
+	MaxOpenTags      map[string]int                    `yaml:"max_open_tags,omitempty" mapstructure:"max_open_tags"`
 	WalletAmounts    map[string]float64                `yaml:"wallet_amounts,omitempty" mapstructure:"wallet_amounts"`
 	DrawBalanceOver  float64                           `yaml:"draw_balance_over,omitempty" mapstructure:"draw_balance_over"`
 	StakeCurrency    []string                          `yaml:"stake_currency,omitempty,flow" mapstructure:"stake_currency"`
@@ -138,6 +154,9 @@ type Config struct {
 	RPCChannels      map[string]map[string]interface{} `yaml:"rpc_channels,omitempty" mapstructure:"rpc_channels"`
 	Mail             *MailConfig                       `yaml:"mail,omitempty" mapstructure:"mail"`
 	Webhook          map[string]map[string]string      `yaml:"webhook,omitempty" mapstructure:"webhook"`
+	Confirm          *ConfirmConfig                    `yaml:"confirm,omitempty" mapstructure:"confirm"`
+	Reports          []*ReportConfig                   `yaml:"reports,omitempty" mapstructure:"reports"`
+	RateLimit        *RateLimitConfig                  `yaml:"rate_limit,omitempty" mapstructure:"rate_limit"`
 }
 
 // The strategy to run, multiple strategies can be run at the same time 运行的策略，可以多个策略同时运行
@@ -168,6 +187,16 @@ type BtInLiveConfig struct {
 	MailTo []string `yaml:"mail_to" mapstructure:"mail_to"`
 }
 
+/*
+PaperTradeConfig
+Fill simulation for dry-run live trading against real order books and trades stream
+模拟实盘时基于真实订单簿和逐笔成交的成交模拟
+*/
+type PaperTradeConfig struct {
+	DepthWalk bool `yaml:"depth_walk" mapstructure:"depth_walk"` // walk order book depth for market orders 市价单按订单簿深度逐档成交
+	QueueFill bool `yaml:"queue_fill" mapstructure:"queue_fill"` // simulate queue position for limit orders 限价单模拟排队位置
+}
+
 type StratPerfConfig struct {
 	Enable    bool    `yaml:"enable" mapstructure:"enable"`
 	MinOdNum  int     `yaml:"min_od_num,omitempty" mapstructure:"min_od_num"`
@@ -190,6 +219,7 @@ type APIServerConfig struct {
 	Port         int           `yaml:"port" mapstructure:"port"`                               // LOCAL LISTENING PORT 本地监听端口
 	Verbosity    string        `yaml:"verbosity" mapstructure:"verbosity"`                     // Detail level 详细程度
 	JWTSecretKey string        `yaml:"jwt_secret_key,omitempty" mapstructure:"jwt_secret_key"` // Key used for password encryption 用于密码加密的密钥
+	MetricsToken string        `yaml:"metrics_token,omitempty" mapstructure:"metrics_token"`   // Bearer token required by /metrics, empty to disable /metrics要求的Bearer token，为空时禁用
 	CORSOrigins  []string      `yaml:"CORS_origins,flow" mapstructure:"CORS_origins"`          // When accessing banweb, you need to add the address of banweb here to allow access. banweb访问时，要这里添加banweb的地址放行
 	Users        []*UserConfig `yaml:"users" mapstructure:"users"`                             // Login user 登录用户
 }
@@ -212,6 +242,45 @@ type MailConfig struct {
 	Password string `yaml:"password" mapstructure:"password"`
 }
 
+/*
+ConfirmConfig
+Destructive actions (close all, force exit...) from telegram, live api and cli require a time-limited one-time code.
+When Approvers is set, the code must be confirmed by another user in the list. Users are `tg:<telegram user id>`,
+`api:<api user>` or `cli:<os user>`.
+来自telegram、实盘api和命令行的危险操作(平仓所有、强制平仓等)需要限时一次性确认码。
+设置Approvers时，必须由列表中的另一个用户确认。用户格式为上面三种。
+*/
+type ConfirmConfig struct {
+	Enable    bool     `yaml:"enable" mapstructure:"enable"`
+	TTLSecs   int      `yaml:"ttl_secs,omitempty" mapstructure:"ttl_secs"`        // validity of code, default 120 确认码有效秒数，默认120
+	Approvers []string `yaml:"approvers,omitempty,flow" mapstructure:"approvers"` // second approvers 第二审批人列表
+}
+
+/*
+ReportConfig
+A performance report sent to rpc channels periodically in live trading. Text for telegram/wework, html with equity chart for email.
+实盘时定期发送到rpc渠道的绩效报告。telegram/wework为文本，邮件为带权益曲线的html
+*/
+type ReportConfig struct {
+	Name string `yaml:"name" mapstructure:"name"`             // title of report, e.g. daily 报告标题，如daily
+	Cron string `yaml:"cron" mapstructure:"cron"`             // cron with seconds, e.g. "0 0 8 * * *" 带秒的cron表达式
+	Days int    `yaml:"days,omitempty" mapstructure:"days"`   // period of report, default 1 统计最近多少天，默认1
+	TopN int    `yaml:"top_n,omitempty" mapstructure:"top_n"` // max strategies shown, default 10 最多显示的策略数，默认10
+}
+
+/*
+RateLimitConfig
+Weight budgets of exchange rest api per minute. Orders are placed before account queries, and account queries
+before data fetching; data fetching can only use DataPct of the budget. Pause all requests on 429/418.
+交易所rest接口每分钟的权重预算。下单优先于账户查询，账户查询优先于数据获取；数据获取最多使用预算的DataPct。
+出现429/418时暂停所有请求
+*/
+type RateLimitConfig struct {
+	Weights     map[string]int `yaml:"weights,omitempty" mapstructure:"weights"`           // exchange: weight per minute, 0 for unlimited 交易所: 每分钟权重，0不限制
+	DataPct     int            `yaml:"data_pct,omitempty" mapstructure:"data_pct"`         // max percent of budget for data, default 70 数据获取最多占用预算的百分比，默认70
+	BackoffSecs int            `yaml:"backoff_secs,omitempty" mapstructure:"backoff_secs"` // pause secs when no Retry-After, default 30 无Retry-After时暂停秒数，默认30
+}
+
 /** ********************************** Symbol FILTER标的筛选器  ******************************** */
 
 type PairMgrConfig struct {
@@ -2,6 +2,8 @@ package core
 
 import (
 	"time"
+
+	"github.com/banbox/banexg"
 )
 
 func SetRunMode(mode string) {
@@ -31,3 +33,50 @@ func Sleep(d time.Duration) bool {
 		return false
 	}
 }
+
+/*
+SetPairCopiedMs update the latest kline time and waiting interval of pair from spider
+更新从爬虫收到的标的最新K线时间和等待间隔
+*/
+func SetPairCopiedMs(pair string, barMS, waitMS int64) {
+	lockPairCopied.Lock()
+	PairCopiedMs[pair] = [2]int64{barMS, waitMS}
+	lockPairCopied.Unlock()
+}
+
+func DelPairCopiedMs(pair string) {
+	lockPairCopied.Lock()
+	delete(PairCopiedMs, pair)
+	lockPairCopied.Unlock()
+}
+
+/*
+GetOdBook return the cached order book of pair
+返回标的缓存的订单簿
+*/
+func GetOdBook(pair string) (*banexg.OrderBook, bool) {
+	lockOdBooks.RLock()
+	book, ok := OdBooks[pair]
+	lockOdBooks.RUnlock()
+	return book, ok
+}
+
+func SetOdBook(pair string, book *banexg.OrderBook) {
+	lockOdBooks.Lock()
+	OdBooks[pair] = book
+	lockOdBooks.Unlock()
+}
+
+/*
+GetPairCopiedMs return a copy of PairCopiedMs, safe to iterate while spider is updating
+返回PairCopiedMs的副本，爬虫更新时可安全遍历
+*/
+func GetPairCopiedMs() map[string][2]int64 {
+	lockPairCopied.RLock()
+	res := make(map[string][2]int64, len(PairCopiedMs))
+	for k, v := range PairCopiedMs {
+		res[k] = v
+	}
+	lockPairCopied.RUnlock()
+	return res
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"github.com/banbox/banexg/errs"
 	"github.com/banbox/banexg/log"
 	"github.com/sasha-s/go-deadlock"
 
@@ -133,11 +134,16 @@ var (
 	lockPrices     deadlock.RWMutex
 	lockBarPrices  deadlock.RWMutex
 	TfPairHitsLock deadlock.RWMutex
+	lockPairCopied deadlock.RWMutex
+	lockOdBooks    deadlock.RWMutex
 	Ctx            context.Context // Used to stop all goroutines at the same time 用于全部goroutine同时停止
 	StopAll        func()          // Stop all robot threads 停止全部机器人线程
 	BotRunning     bool            // Is the robot running? 机器人是否正在运行
 )
 
+// ReloadConfig Hot-reload run_policy and pairlists in live mode, return changed policy IDs 实盘热加载run_policy和pairlists，返回变化的策略ID
+var ReloadConfig func() (map[string][]string, *errs.Error)
+
 var (
 	OrderTypeEnums = []string{"", banexg.OdTypeMarket, banexg.OdTypeLimit, banexg.OdTypeLimitMaker, banexg.OdTypeStopLoss,
 		banexg.OdTypeStopLossLimit, banexg.OdTypeTakeProfit, banexg.OdTypeTakeProfitLimit,
@@ -35,6 +35,7 @@ const (
 	ErrLowFunds     = -131
 	ErrLowSrcAmount = -132
 	ErrExgNotInit   = -133
+	ErrOrderReject  = -134
 
 	// network
 
@@ -72,6 +73,7 @@ func init() {
 		ErrLowSrcAmount:      "LowSrcAmount",
 		ErrInvalidCost:       "InvalidCost",
 		ErrExgNotInit:        "ExgNotInit",
+		ErrOrderReject:       "OrderReject",
 		ErrCacheErr:          "CacheErr",
 		ErrInvalidTF:         "InvalidTF",
 		ErrInvalidSymbol:     "InvalidSymbol",
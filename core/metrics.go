@@ -0,0 +1,195 @@
+package core
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sasha-s/go-deadlock"
+)
+
+const (
+	MetricCounter = "counter"
+	MetricGauge   = "gauge"
+	MetricSummary = "summary" // only sum and count are exported 仅导出sum和count
+)
+
+type metricItem struct {
+	labels string
+	value  float64
+	count  int64
+}
+
+type metricFamily struct {
+	name  string
+	help  string
+	kind  string
+	items map[string]*metricItem
+}
+
+const (
+	MetricEquity         = "banbot_equity"
+	MetricUnrealizedPOL  = "banbot_unrealized_pnl"
+	MetricOpenOrders     = "banbot_open_orders"
+	MetricSubmitSecs     = "banbot_order_submit_seconds"
+	MetricSubmitFails    = "banbot_order_submit_fails_total"
+	MetricOrderRejects   = "banbot_order_rejects_total"
+	MetricReqWeight      = "banbot_exg_req_weight"
+	MetricReqWaitSecs    = "banbot_exg_req_wait_seconds"
+	MetricReqLimited     = "banbot_exg_req_limited_total"
+	MetricKlineDelay     = "banbot_kline_delay_seconds"
+	MetricSpiderReconns  = "banbot_spider_reconnects_total"
+	MetricFailOpens      = "banbot_fail_opens"
+	MetricLastKlineDelay = "banbot_last_kline_delay_seconds"
+)
+
+var (
+	metrics    = make(map[string]*metricFamily)
+	lockMetric deadlock.Mutex
+)
+
+func init() {
+	RegMetric(MetricEquity, MetricGauge, "Total legal value of account wallets")
+	RegMetric(MetricUnrealizedPOL, MetricGauge, "Unrealized profit and loss of account")
+	RegMetric(MetricOpenOrders, MetricGauge, "Number of open orders of account")
+	RegMetric(MetricSubmitSecs, MetricSummary, "Latency of submitting orders to exchange")
+	RegMetric(MetricSubmitFails, MetricCounter, "Failures of submitting orders to exchange")
+	RegMetric(MetricOrderRejects, MetricCounter, "Orders rejected by pre-trade validation of market limits")
+	RegMetric(MetricReqWeight, MetricGauge, "Request weight of exchange rest api used in last minute")
+	RegMetric(MetricReqWaitSecs, MetricSummary, "Waiting time of exchange rest requests in scheduler")
+	RegMetric(MetricReqLimited, MetricCounter, "Responses of exchange rest api with status 429/418")
+	RegMetric(MetricKlineDelay, MetricGauge, "Seconds since the latest kline of pair received from spider")
+	RegMetric(MetricLastKlineDelay, MetricGauge, "Seconds since any kline received from spider")
+	RegMetric(MetricSpiderReconns, MetricCounter, "Websocket resubscriptions of spider")
+	RegMetric(MetricFailOpens, MetricGauge, "Number of failed entries by reason")
+}
+
+/*
+RegMetric
+Register a metric with kind of MetricCounter/MetricGauge/MetricSummary, which is exported in prometheus text format.
+注册一个指标，类型为MetricCounter/MetricGauge/MetricSummary，以prometheus文本格式导出
+*/
+func RegMetric(name, kind, help string) {
+	lockMetric.Lock()
+	if _, ok := metrics[name]; !ok {
+		metrics[name] = &metricFamily{name: name, help: help, kind: kind, items: make(map[string]*metricItem)}
+	}
+	lockMetric.Unlock()
+}
+
+func getMetricItem(name string, labels []string) *metricItem {
+	fam, ok := metrics[name]
+	if !ok {
+		fam = &metricFamily{name: name, kind: MetricGauge, items: make(map[string]*metricItem)}
+		metrics[name] = fam
+	}
+	key := fmtMetricLabels(labels)
+	item, ok := fam.items[key]
+	if !ok {
+		item = &metricItem{labels: key}
+		fam.items[key] = item
+	}
+	return item
+}
+
+/*
+AddMetric
+Add val to a counter. labels are key-value pairs: `AddMetric(name, 1, "account", acc)`
+为计数器增加val。labels为键值对
+*/
+func AddMetric(name string, val float64, labels ...string) {
+	lockMetric.Lock()
+	getMetricItem(name, labels).value += val
+	lockMetric.Unlock()
+}
+
+// SetMetric set value of a gauge 设置仪表值
+func SetMetric(name string, val float64, labels ...string) {
+	lockMetric.Lock()
+	getMetricItem(name, labels).value = val
+	lockMetric.Unlock()
+}
+
+// ObserveMetric record an observation of a summary 为摘要记录一次观测值
+func ObserveMetric(name string, val float64, labels ...string) {
+	lockMetric.Lock()
+	item := getMetricItem(name, labels)
+	item.value += val
+	item.count += 1
+	lockMetric.Unlock()
+}
+
+// ResetMetric remove all label values of a metric, used for gauges refreshed at once 删除指标的所有标签值，用于一次性刷新的仪表
+func ResetMetric(name string) {
+	lockMetric.Lock()
+	if fam, ok := metrics[name]; ok {
+		fam.items = make(map[string]*metricItem)
+	}
+	lockMetric.Unlock()
+}
+
+func fmtMetricLabels(labels []string) string {
+	if len(labels) < 2 {
+		return ""
+	}
+	var b strings.Builder
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(labels[i])
+		b.WriteString(`="`)
+		b.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[i+1]))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+/*
+DumpMetrics
+Export all metrics in prometheus text exposition format
+以prometheus文本格式导出所有指标
+*/
+func DumpMetrics() string {
+	lockMetric.Lock()
+	defer lockMetric.Unlock()
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	writeLine := func(name, labels string, val float64) {
+		b.WriteString(name)
+		if labels != "" {
+			b.WriteByte('{')
+			b.WriteString(labels)
+			b.WriteByte('}')
+		}
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatFloat(val, 'g', -1, 64))
+		b.WriteByte('\n')
+	}
+	for _, name := range names {
+		fam := metrics[name]
+		if fam.help != "" {
+			b.WriteString("# HELP " + name + " " + fam.help + "\n")
+		}
+		b.WriteString("# TYPE " + name + " " + fam.kind + "\n")
+		keys := make([]string, 0, len(fam.items))
+		for k := range fam.items {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			item := fam.items[k]
+			if fam.kind == MetricSummary {
+				writeLine(name+"_sum", item.labels, item.value)
+				writeLine(name+"_count", item.labels, float64(item.count))
+			} else {
+				writeLine(name, item.labels, item.value)
+			}
+		}
+	}
+	return b.String()
+}
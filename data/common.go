@@ -5,7 +5,6 @@ import (
 	"github.com/banbox/banbot/core"
 	"github.com/banbox/banbot/exg"
 	"github.com/banbox/banbot/orm"
-	"github.com/banbox/banbot/utils"
 	"github.com/banbox/banexg"
 	"github.com/banbox/banexg/errs"
 	"github.com/banbox/banexg/log"
@@ -199,13 +198,19 @@ func (j *PairTFCache) fillLacks(pair string, subTfSecs int, startMS, endMS int64
 		return nil, nil
 	}
 	// 这里NextMS < startMS，出现了bar缺失，查询更新。
-	fetchTF := utils2.SecsToTF(subTfSecs)
-	tfMSecs := int64(j.TFSecs * 1000)
-	bigStartMS := utils2.AlignTfMSecs(j.SubNextMS, tfMSecs)
 	exs, err := orm.GetExSymbolCur(pair)
 	if err != nil {
 		return nil, err
 	}
+	if orm.SessionMSBetween(exs, j.SubNextMS, startMS) == 0 {
+		// The gap is a break between trading sessions, no bars are missing
+		// 缺口是交易时段之间的休市，没有缺失bar
+		j.SubNextMS = endMS
+		return nil, nil
+	}
+	fetchTF := utils2.SecsToTF(subTfSecs)
+	tfMSecs := int64(j.TFSecs * 1000)
+	bigStartMS := utils2.AlignTfMSecs(j.SubNextMS, tfMSecs)
 	_, preBars, err := autoFetchOhlcv(exs, fetchTF, bigStartMS, startMS)
 	if err != nil {
 		return nil, err
@@ -214,7 +219,7 @@ func (j *PairTFCache) fillLacks(pair string, subTfSecs int, startMS, endMS int64
 	j.WaitBar = nil
 	if len(preBars) > 0 {
 		fromTFMS := int64(subTfSecs * 1000)
-		oldBars, _ := utils.BuildOHLCV(preBars, tfMSecs, 0, nil, fromTFMS, j.AlignOffMS, exs.InfoBy())
+		oldBars, _ := orm.BuildSessOHLCV(exs, preBars, tfMSecs, 0, nil, fromTFMS, j.AlignOffMS)
 		if len(oldBars) > 0 {
 			j.WaitBar = oldBars[len(oldBars)-1]
 			doneBars = oldBars[:len(oldBars)-1]
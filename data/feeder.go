@@ -272,7 +272,7 @@ func (f *Feeder) fireCallBacks(timeFrame string, tfMSecs int64, bars []*banexg.K
 	if isLive && !f.isWarmUp {
 		// 检查是否延迟
 		lastTime := bars[len(bars)-1].Time
-		delay := btime.TimeMS() - (lastTime + tfMSecs)
+		delay := orm.SessionMSBetween(f.ExSymbol, lastTime+tfMSecs, btime.TimeMS())
 		if delay > tfMSecs && tfMSecs >= 60000 {
 			barNum := delay / tfMSecs
 			log.Warn(fmt.Sprintf("%s/%s bar too late, delay %v bars, %v", pair, timeFrame, barNum, lastTime))
@@ -477,13 +477,12 @@ func (f *KlineFeeder) onNewBars(barTfMSecs int64, bars []*banexg.Kline) (bool, *
 	staMSecs := int64(state.TFSecs * 1000)
 	var ohlcvs []*banexg.Kline
 	var lastOk bool
-	infoBy := f.InfoBy()
 	if barTfMSecs < staMSecs {
 		var olds []*banexg.Kline
 		if state.WaitBar != nil {
 			olds = append(olds, state.WaitBar)
 		}
-		ohlcvs, lastOk = utils.BuildOHLCV(bars, staMSecs, f.PreFire, olds, barTfMSecs, state.AlignOffMS, infoBy)
+		ohlcvs, lastOk = orm.BuildSessOHLCV(f.ExSymbol, bars, staMSecs, f.PreFire, olds, barTfMSecs, state.AlignOffMS)
 	} else if barTfMSecs == staMSecs {
 		ohlcvs, lastOk = bars, true
 	} else {
@@ -514,7 +513,7 @@ func (f *KlineFeeder) onNewBars(barTfMSecs int64, bars []*banexg.Kline) (bool, *
 		if barTfMSecs < staMSecs {
 			// The last unfinished data should be kept here
 			// 这里应该保留最后未完成的数据
-			ohlcvs, _ = utils.BuildOHLCV(bars, staMSecs, f.PreFire, nil, barTfMSecs, state.AlignOffMS, infoBy)
+			ohlcvs, _ = orm.BuildSessOHLCV(f.ExSymbol, bars, staMSecs, f.PreFire, nil, barTfMSecs, state.AlignOffMS)
 		} else {
 			// 前面过滤了>，这里一定相等
 			ohlcvs = bars
@@ -540,7 +539,7 @@ func (f *KlineFeeder) onNewBars(barTfMSecs int64, bars []*banexg.Kline) (bool, *
 				olds = append(olds, state.WaitBar)
 			}
 			bigTfMSecs := int64(state.TFSecs * 1000)
-			curOhlcvs, lastDone := utils.BuildOHLCV(bars, bigTfMSecs, f.PreFire, olds, srcMSecs, srcAlignOff, infoBy)
+			curOhlcvs, lastDone := orm.BuildSessOHLCV(f.ExSymbol, bars, bigTfMSecs, f.PreFire, olds, srcMSecs, srcAlignOff)
 			f.onStateOhlcvs(state, curOhlcvs, lastDone)
 		}
 	}
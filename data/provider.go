@@ -415,7 +415,8 @@ func SortFeeders(holds []IHistKlineFeeder, hold IHistKlineFeeder, insert bool) [
 type LiveProvider struct {
 	Provider[IKlineFeeder]
 	*KLineWatcher
-	OnMinKlines func(msg *KLineMsg, bars []*banexg.Kline) *errs.Error
+	OnMinKlines  func(msg *KLineMsg, bars []*banexg.Kline) *errs.Error
+	OnPairTrades func(pair string, trades []*banexg.Trade) // trades stream for paper trading 用于模拟实盘的逐笔成交
 }
 
 func NewLiveProvider(callBack FnPairKline, envEnd FuncEnvEnd) (*LiveProvider, *errs.Error) {
@@ -490,7 +491,8 @@ func (p *LiveProvider) SubWarmPairs(items map[string]map[string]int, delOther bo
 			if err != nil {
 				return err
 			}
-			err = orm.BulkDownOHLCV(exchange, down1mPairs, "1m", minSince, btime.UTCStamp(), 0, nil)
+			downStart := cached1mStart(down1mPairs, minSince)
+			err = orm.BulkDownOHLCV(exchange, down1mPairs, "1m", downStart, btime.UTCStamp(), 0, nil)
 			if err != nil {
 				return err
 			}
@@ -509,16 +511,76 @@ func (p *LiveProvider) SubWarmPairs(items map[string]map[string]int, delOther bo
 				return err
 			}
 		}
+		for _, msgType := range p.paperSubTypes() {
+			jobs = make([]WatchJob, 0, len(newHolds))
+			for _, h := range newHolds {
+				jobs = append(jobs, WatchJob{Symbol: h.getSymbol(), TimeFrame: "1m"})
+			}
+			err = p.WatchJobs(core.ExgName, core.Market, msgType, jobs...)
+			if err != nil {
+				return err
+			}
+		}
 	}
 	if len(delPairs) > 0 {
 		err = p.UnWatchJobs(core.ExgName, core.Market, "ohlcv", delPairs)
 		if err != nil {
 			return err
 		}
+		for _, msgType := range p.paperSubTypes() {
+			err = p.UnWatchJobs(core.ExgName, core.Market, msgType, delPairs)
+			if err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
+/*
+paperSubTypes
+Websocket streams required by the fill simulation of paper trading: depth for market orders walking the book,
+trades for limit orders queue simulation.
+模拟实盘成交模拟需要的websocket流：市价单逐档成交需要深度，限价单排队模拟需要逐笔成交
+*/
+func (p *LiveProvider) paperSubTypes() []string {
+	if core.EnvReal || p.OnPairTrades == nil || config.PaperTrade == nil {
+		return nil
+	}
+	var res []string
+	if config.PaperTrade.DepthWalk || config.PaperTrade.QueueFill {
+		res = append(res, core.WsSubDepth)
+	}
+	if config.PaperTrade.QueueFill {
+		res = append(res, core.WsSubTrade)
+	}
+	return res
+}
+
+/*
+cached1mStart
+When all pairs already have 1m klines cached in db after since (e.g. restart), only the tail needs to be
+downloaded, return the earliest cached end time; otherwise return since. Warmup still runs on all bars.
+当所有品种在since之后已缓存1m K线时（如重启），只需下载尾部，返回最早的缓存结束时间；否则返回since。预热仍使用全部K线
+*/
+func cached1mStart(exsMap map[int32]*orm.ExSymbol, since int64) int64 {
+	sess, conn, err := orm.Conn(nil)
+	if err != nil {
+		return since
+	}
+	defer conn.Release()
+	kRanges := sess.GetKlineRanges(utils.KeysOfMap(exsMap), "1m")
+	start := int64(math.MaxInt64)
+	for sid := range exsMap {
+		krange, ok := kRanges[sid]
+		if !ok || krange[0] > since || krange[1] <= since {
+			return since
+		}
+		start = min(start, krange[1])
+	}
+	return start
+}
+
 func (p *LiveProvider) UnSubPairs(pairs ...string) *errs.Error {
 	removed := p.Provider.UnSubPairs(pairs...)
 	if len(removed) == 0 {
@@ -598,6 +660,9 @@ func makeOnKlineMsg(p *LiveProvider) func(msg *KLineMsg) {
 
 func makeOnTrade(p *LiveProvider) func(exgName, market, pair string, trades []*banexg.Trade) {
 	return func(exgName, market, pair string, trades []*banexg.Trade) {
+		if p.OnPairTrades != nil {
+			p.OnPairTrades(pair, trades)
+		}
 		pairMap, _ := strat.WsSubJobs[core.WsSubTrade]
 		if len(pairMap) == 0 || len(trades) == 0 {
 			return
@@ -631,7 +696,7 @@ func fireWsKlines(msg *KLineMsg) {
 		return
 	}
 	last := msg.Arr[len(msg.Arr)-1]
-	if _, ok := core.OdBooks[msg.Pair]; !ok {
+	if _, ok := core.GetOdBook(msg.Pair); !ok {
 		core.SetPrice(msg.Pair, last.Close, last.Close)
 	}
 	pairMap, _ := strat.WsSubJobs[core.WsSubKLine]
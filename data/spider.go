@@ -219,6 +219,7 @@ func (s *LiveSpider) monitorSubscriptions() {
 			// Check KLine subscriptions
 			klineNum := miner.KLines.Len()
 			if klineNum > 0 && miner.KLines.Status == 0 && curMS > retryWaits.NextRetry("watchKLines") {
+				core.AddMetric(core.MetricSpiderReconns, 1, "miner", key, "kind", "kline")
 				log.Info("Recovering KLine subscription",
 					zap.String("miner", key),
 					zap.Int("pairs", klineNum))
@@ -228,6 +229,7 @@ func (s *LiveSpider) monitorSubscriptions() {
 			// Check Trade subscriptions
 			tradeNum := miner.Trades.Len()
 			if tradeNum > 0 && miner.Trades.Status == 0 && curMS > retryWaits.NextRetry("watchTrades") {
+				core.AddMetric(core.MetricSpiderReconns, 1, "miner", key, "kind", "trade")
 				log.Info("Recovering Trade subscription",
 					zap.String("miner", key),
 					zap.Int("pairs", tradeNum))
@@ -237,6 +239,7 @@ func (s *LiveSpider) monitorSubscriptions() {
 			// Check OrderBook subscriptions
 			bookNum := miner.Depths.Len()
 			if bookNum > 0 && miner.Depths.Status == 0 && curMS > retryWaits.NextRetry("watchOdBooks") {
+				core.AddMetric(core.MetricSpiderReconns, 1, "miner", key, "kind", "book")
 				log.Info("Recovering OrderBook subscription",
 					zap.String("miner", key),
 					zap.Int("pairs", bookNum))
@@ -152,7 +152,7 @@ func (w *KLineWatcher) UnWatchJobs(exgName, marketType, jobType string, pairs []
 		}
 		jobKey := fmt.Sprintf("%s_%s", pair, jobType)
 		delete(w.jobs, jobKey)
-		delete(core.PairCopiedMs, pair)
+		core.DelPairCopiedMs(pair)
 	}
 	if len(tags) == 0 {
 		return nil
@@ -227,8 +227,8 @@ func (w *KLineWatcher) onSpiderBar(key string, data []byte) {
 			olds = append(olds, job.WaitBar)
 		}
 		jobMSecs := int64(job.TFSecs * 1000)
-		infoBy := orm.GetExSymbol2(exgName, market, pair).InfoBy()
-		finishes = job.getFinishes(utils.BuildOHLCV(bars.Arr, jobMSecs, 0, olds, tfMSecs, job.AlignOffMS, infoBy))
+		exs := orm.GetExSymbol2(exgName, market, pair)
+		finishes = job.getFinishes(orm.BuildSessOHLCV(exs, bars.Arr, jobMSecs, 0, olds, tfMSecs, job.AlignOffMS))
 	} else {
 		finishes = bars.Arr
 	}
@@ -272,7 +272,7 @@ func (w *KLineWatcher) onTrades(key string, data []byte) {
 		return
 	}
 	last := trades[len(trades)-1]
-	if _, ok := core.OdBooks[pair]; !ok {
+	if _, ok := core.GetOdBook(pair); !ok {
 		core.SetPrice(pair, last.Price, last.Price)
 	}
 	w.OnTrades(exgName, market, pair, trades)
@@ -300,7 +300,7 @@ func (w *KLineWatcher) onBook(key string, data []byte) {
 		return
 	}
 	core.SetPrice(pair, book.Asks.Price[0], book.Bids.Price[0])
-	core.OdBooks[pair] = &book
+	core.SetOdBook(pair, &book)
 	if w.OnDepth != nil {
 		w.OnDepth(&book)
 	}
@@ -91,6 +91,12 @@ func init() {
 		Options: []string{"out", "opt_rounds", "sampler", "picker", "each_pairs", "concur"},
 		Help:    "run hyper parameters optimization",
 	})
+	AddCmdJob(&CmdJob{
+		Name:    "bt_verify",
+		Run:     RunBtVerify,
+		Options: []string{"in"},
+		Help:    "re-run backtest from manifest in output dir and diff results",
+	})
 	AddCmdJob(&CmdJob{
 		Name:    "init",
 		Run:     runInit,
@@ -125,6 +131,13 @@ func init() {
 		Options: []string{"in", "concur"},
 		Help:    "import data from protobuf files to db",
 	})
+	AddCmdJob(&CmdJob{
+		Name:    "import_tags",
+		Parent:  "data",
+		Run:     runImportTags,
+		Options: []string{"in"},
+		Help:    "import symbol tags from csv/yaml file to db",
+	})
 
 	// kline command group
 	AddCmdJob(&CmdJob{
@@ -169,6 +182,20 @@ func init() {
 		Options: []string{"pairs"},
 		Help:    "sync klines between timeframes",
 	})
+	AddCmdJob(&CmdJob{
+		Name:    "audit",
+		Parent:  "kline",
+		Run:     runKlineAudit,
+		Options: []string{"timerange", "timestart", "timeend", "pairs", "timeframes", "out", "sigma", "repair"},
+		Help:    "audit kline data quality, output csv report",
+	})
+	AddCmdJob(&CmdJob{
+		Name:    "compare",
+		Parent:  "kline",
+		Run:     runKlineCompare,
+		Options: []string{"timerange", "timestart", "timeend", "pairs", "timeframes", "out", "src_a", "src_b"},
+		Help:    "compare klines from two sources, report deviations, correlation and lag",
+	})
 	AddCmdJob(&CmdJob{
 		Name:    "adj_calc",
 		Parent:  "kline",
@@ -257,6 +284,12 @@ func init() {
 		RunRaw: runMergeAssets,
 		Help:   "merge multiple assets.html files into one",
 	})
+	AddCmdJob(&CmdJob{
+		Name:   "encrypt_secrets",
+		Parent: "tool",
+		RunRaw: runEncryptSecrets,
+		Help:   "encrypt a plain yaml of secrets for `!secret` in config",
+	})
 	AddCmdJob(&CmdJob{
 		Name:   "cmp_orders",
 		Parent: "tool",
@@ -56,6 +56,38 @@ func RunBackTest(args *config.CmdArgs) *errs.Error {
 	return nil
 }
 
+/*
+RunBtVerify
+Re-run a backtest with the config and seed saved in its output dir, and diff the new manifest with the saved one.
+使用回测输出目录中保存的配置和种子重新回测，并对比新旧运行清单
+*/
+func RunBtVerify(args *config.CmdArgs) *errs.Error {
+	if args.InPath == "" {
+		return errs.NewMsg(errs.CodeParamRequired, "-in is required")
+	}
+	inDir := config.ParsePath(args.InPath)
+	old, err := opt.LoadManifest(inDir)
+	if err != nil {
+		return err
+	}
+	args.Configs = append(args.Configs, filepath.Join(inDir, "config.yml"))
+	core.SetRunMode(core.RunModeBackTest)
+	err = biz.SetupComsExg(args)
+	if err != nil {
+		return err
+	}
+	config.Seed = old.Seed
+	outDir := runBackTest(filepath.Join(inDir, "verify"), "")
+	cur, err := opt.LoadManifest(outDir)
+	if err != nil {
+		return err
+	}
+	if num := opt.PrintManifestDiff(old, cur); num > 0 {
+		return errs.NewMsg(core.ErrRunTime, "%d items differ from manifest, see: %s", num, outDir)
+	}
+	return nil
+}
+
 func runBackTest(outDir string, prgOut string) string {
 	core.BotRunning = true
 	biz.ResetVars()
@@ -151,6 +183,22 @@ func RunKlineCorrect(args *config.CmdArgs) *errs.Error {
 	return orm.SyncKlineTFs(args, nil)
 }
 
+func runKlineAudit(args *config.CmdArgs) *errs.Error {
+	err := biz.SetupComsExg(args)
+	if err != nil {
+		return err
+	}
+	return biz.AuditKlines(args)
+}
+
+func runKlineCompare(args *config.CmdArgs) *errs.Error {
+	err := biz.SetupComsExg(args)
+	if err != nil {
+		return err
+	}
+	return opt.CompareKlines(args)
+}
+
 func RunKlineAdjFactors(args *config.CmdArgs) *errs.Error {
 	err := biz.SetupComs(args)
 	if err != nil {
@@ -251,6 +299,18 @@ func runDataImport(args *config.CmdArgs) *errs.Error {
 	return orm.ImportData(args.InPath, args.Concur, nil)
 }
 
+func runImportTags(args *config.CmdArgs) *errs.Error {
+	err := biz.SetupComsExg(args)
+	if err != nil {
+		return err
+	}
+	if args.InPath == "" {
+		return errs.NewMsg(errs.CodeParamRequired, "-in is required")
+	}
+	_, err = orm.ImportSymbolTags(args.InPath)
+	return err
+}
+
 func runMergeAssets(args []string) error {
 	fs := flag.NewFlagSet("", flag.ExitOnError)
 	var outPath string
@@ -284,3 +344,35 @@ func runMergeAssets(args []string) error {
 	log.Info("assets merged", zap.String("to", outPath))
 	return nil
 }
+
+func runEncryptSecrets(args []string) error {
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	var inPath, outPath, key string
+	fs.StringVar(&inPath, "in", "", "plain yaml file of `name: value` secrets")
+	fs.StringVar(&outPath, "out", "", "output path, default: $BanSecretsFile or secrets.enc in data dir")
+	fs.StringVar(&key, "key", "", "hex master key, default: $BanMasterKey or generate a new one")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if inPath == "" {
+		return errs.NewMsg(errs.CodeParamRequired, "-in is required")
+	}
+	if key == "" {
+		key = os.Getenv("BanMasterKey")
+	}
+	if outPath != "" {
+		outPath = config.ParsePath(outPath)
+	}
+	newKey := key == ""
+	key, err2 := config.EncryptSecrets(config.ParsePath(inPath), outPath, key)
+	if err2 != nil {
+		return err2
+	}
+	log.Info("secrets encrypted, set env `BanMasterKey` to decrypt")
+	if newKey {
+		// print to stdout only, never write the master key into logs 仅输出到标准输出，不要将主密钥写入日志
+		fmt.Printf("generated master key: %s\n", key)
+	}
+	return nil
+}
@@ -273,6 +273,14 @@ func bindSubFlags(args *config.CmdArgs, cmd *flag.FlagSet, noOpts []string, opts
 			cmd.StringVar(&args.OutType, "out-type", "", "output data type")
 		case "separate":
 			cmd.BoolVar(&args.Separate, "separate", false, "run policy separately for backtest")
+		case "sigma":
+			cmd.Float64Var(&args.Sigma, "sigma", 8, "flag returns beyond sigma as outliers")
+		case "repair":
+			cmd.BoolVar(&args.Repair, "repair", false, "re-download flagged bars")
+		case "src_a":
+			cmd.StringVar(&args.SrcA, "src-a", "db", "kline source A: db[:exg.market], exg[:exg.market], zip:dir, proto:dir")
+		case "src_b":
+			cmd.StringVar(&args.SrcB, "src-b", "", "kline source B: db[:exg.market], exg[:exg.market], zip:dir, proto:dir")
 		default:
 			return errors.New(fmt.Sprintf("unknown argument: %s", key))
 		}
@@ -5,8 +5,14 @@ import (
 	"github.com/banbox/banexg/errs"
 )
 
+/*
+BotExchange
+Wrap the exchange, all rest requests of banbot go through the rate limit scheduler.
+包装交易所，banbot的所有rest请求都经过限流调度器
+*/
 type BotExchange struct {
 	banexg.BanExchange
+	sched *ReqScheduler
 }
 
 var (
@@ -25,7 +31,9 @@ type PutOrderRes struct {
 }
 
 func (e *BotExchange) CreateOrder(symbol, odType, side string, amount, price float64, params map[string]interface{}) (*banexg.Order, *errs.Error) {
-	order, err := e.BanExchange.CreateOrder(symbol, odType, side, amount, price, params)
+	order, err := schedCall(e.sched, PriorOrder, 1, func() (*banexg.Order, *errs.Error) {
+		return e.BanExchange.CreateOrder(symbol, odType, side, amount, price, params)
+	})
 	if AfterCreateOrder != nil {
 		err2 := AfterCreateOrder(&PutOrderRes{
 			Symbol:    symbol,
@@ -43,3 +51,172 @@ func (e *BotExchange) CreateOrder(symbol, odType, side string, amount, price flo
 	}
 	return order, err
 }
+
+func (e *BotExchange) EditOrder(symbol, orderId, side string, amount, price float64, params map[string]interface{}) (*banexg.Order, *errs.Error) {
+	return schedCall(e.sched, PriorOrder, 1, func() (*banexg.Order, *errs.Error) {
+		return e.BanExchange.EditOrder(symbol, orderId, side, amount, price, params)
+	})
+}
+
+func (e *BotExchange) CancelOrder(id string, symbol string, params map[string]interface{}) (*banexg.Order, *errs.Error) {
+	return schedCall(e.sched, PriorOrder, 1, func() (*banexg.Order, *errs.Error) {
+		return e.BanExchange.CancelOrder(id, symbol, params)
+	})
+}
+
+func (e *BotExchange) FetchOrder(symbol, orderId string, params map[string]interface{}) (*banexg.Order, *errs.Error) {
+	return schedCall(e.sched, PriorAccount, 1, func() (*banexg.Order, *errs.Error) {
+		return e.BanExchange.FetchOrder(symbol, orderId, params)
+	})
+}
+
+func (e *BotExchange) FetchOrders(symbol string, since int64, limit int, params map[string]interface{}) ([]*banexg.Order, *errs.Error) {
+	return schedCall(e.sched, PriorAccount, 5, func() ([]*banexg.Order, *errs.Error) {
+		return e.BanExchange.FetchOrders(symbol, since, limit, params)
+	})
+}
+
+func (e *BotExchange) FetchOpenOrders(symbol string, since int64, limit int, params map[string]interface{}) ([]*banexg.Order, *errs.Error) {
+	weight := 1
+	if symbol == "" {
+		weight = 40
+	}
+	return schedCall(e.sched, PriorAccount, weight, func() ([]*banexg.Order, *errs.Error) {
+		return e.BanExchange.FetchOpenOrders(symbol, since, limit, params)
+	})
+}
+
+func (e *BotExchange) FetchBalance(params map[string]interface{}) (*banexg.Balances, *errs.Error) {
+	return schedCall(e.sched, PriorAccount, 5, func() (*banexg.Balances, *errs.Error) {
+		return e.BanExchange.FetchBalance(params)
+	})
+}
+
+func (e *BotExchange) FetchAccountPositions(symbols []string, params map[string]interface{}) ([]*banexg.Position, *errs.Error) {
+	return schedCall(e.sched, PriorAccount, 5, func() ([]*banexg.Position, *errs.Error) {
+		return e.BanExchange.FetchAccountPositions(symbols, params)
+	})
+}
+
+func (e *BotExchange) FetchPositions(symbols []string, params map[string]interface{}) ([]*banexg.Position, *errs.Error) {
+	return schedCall(e.sched, PriorAccount, 5, func() ([]*banexg.Position, *errs.Error) {
+		return e.BanExchange.FetchPositions(symbols, params)
+	})
+}
+
+func (e *BotExchange) FetchIncomeHistory(inType string, symbol string, since int64, limit int, params map[string]interface{}) ([]*banexg.Income, *errs.Error) {
+	return schedCall(e.sched, PriorAccount, 30, func() ([]*banexg.Income, *errs.Error) {
+		return e.BanExchange.FetchIncomeHistory(inType, symbol, since, limit, params)
+	})
+}
+
+func (e *BotExchange) SetLeverage(leverage float64, symbol string, params map[string]interface{}) (map[string]interface{}, *errs.Error) {
+	return schedCall(e.sched, PriorAccount, 1, func() (map[string]interface{}, *errs.Error) {
+		return e.BanExchange.SetLeverage(leverage, symbol, params)
+	})
+}
+
+func (e *BotExchange) LoadMarkets(reload bool, params map[string]interface{}) (banexg.MarketMap, *errs.Error) {
+	if !reload && len(e.BanExchange.GetCurMarkets()) > 0 {
+		// loaded markets are returned from memory 已加载时从内存返回
+		return e.BanExchange.LoadMarkets(reload, params)
+	}
+	return schedCall(e.sched, PriorData, 40, func() (banexg.MarketMap, *errs.Error) {
+		return e.BanExchange.LoadMarkets(reload, params)
+	})
+}
+
+func (e *BotExchange) LoadLeverageBrackets(reload bool, params map[string]interface{}) *errs.Error {
+	_, err := schedCall(e.sched, PriorData, 1, func() (struct{}, *errs.Error) {
+		return struct{}{}, e.BanExchange.LoadLeverageBrackets(reload, params)
+	})
+	return err
+}
+
+func (e *BotExchange) FetchTicker(symbol string, params map[string]interface{}) (*banexg.Ticker, *errs.Error) {
+	return schedCall(e.sched, PriorData, 2, func() (*banexg.Ticker, *errs.Error) {
+		return e.BanExchange.FetchTicker(symbol, params)
+	})
+}
+
+func (e *BotExchange) FetchTickers(symbols []string, params map[string]interface{}) ([]*banexg.Ticker, *errs.Error) {
+	weight := 40
+	if len(symbols) > 0 {
+		weight = min(40, len(symbols)*2)
+	}
+	return schedCall(e.sched, PriorData, weight, func() ([]*banexg.Ticker, *errs.Error) {
+		return e.BanExchange.FetchTickers(symbols, params)
+	})
+}
+
+func (e *BotExchange) FetchTickerPrice(symbol string, params map[string]interface{}) (map[string]float64, *errs.Error) {
+	weight := 2
+	if symbol == "" {
+		weight = 4
+	}
+	return schedCall(e.sched, PriorData, weight, func() (map[string]float64, *errs.Error) {
+		return e.BanExchange.FetchTickerPrice(symbol, params)
+	})
+}
+
+func (e *BotExchange) FetchOHLCV(symbol, timeframe string, since int64, limit int, params map[string]interface{}) ([]*banexg.Kline, *errs.Error) {
+	weight := 10
+	if limit < 100 {
+		weight = 1
+	} else if limit < 500 {
+		weight = 2
+	} else if limit <= 1000 {
+		weight = 5
+	}
+	return schedCall(e.sched, PriorData, weight, func() ([]*banexg.Kline, *errs.Error) {
+		return e.BanExchange.FetchOHLCV(symbol, timeframe, since, limit, params)
+	})
+}
+
+func (e *BotExchange) FetchOrderBook(symbol string, limit int, params map[string]interface{}) (*banexg.OrderBook, *errs.Error) {
+	weight := 20
+	if limit <= 50 {
+		weight = 2
+	} else if limit <= 100 {
+		weight = 5
+	} else if limit <= 500 {
+		weight = 10
+	}
+	return schedCall(e.sched, PriorData, weight, func() (*banexg.OrderBook, *errs.Error) {
+		return e.BanExchange.FetchOrderBook(symbol, limit, params)
+	})
+}
+
+func (e *BotExchange) FetchLastPrices(symbols []string, params map[string]interface{}) ([]*banexg.LastPrice, *errs.Error) {
+	weight := 2
+	if len(symbols) == 0 {
+		weight = 4
+	}
+	return schedCall(e.sched, PriorData, weight, func() ([]*banexg.LastPrice, *errs.Error) {
+		return e.BanExchange.FetchLastPrices(symbols, params)
+	})
+}
+
+func (e *BotExchange) FetchFundingRate(symbol string, params map[string]interface{}) (*banexg.FundingRateCur, *errs.Error) {
+	return schedCall(e.sched, PriorData, 1, func() (*banexg.FundingRateCur, *errs.Error) {
+		return e.BanExchange.FetchFundingRate(symbol, params)
+	})
+}
+
+func (e *BotExchange) FetchFundingRates(symbols []string, params map[string]interface{}) ([]*banexg.FundingRateCur, *errs.Error) {
+	return schedCall(e.sched, PriorData, 10, func() ([]*banexg.FundingRateCur, *errs.Error) {
+		return e.BanExchange.FetchFundingRates(symbols, params)
+	})
+}
+
+func (e *BotExchange) FetchFundingRateHistory(symbol string, since int64, limit int, params map[string]interface{}) ([]*banexg.FundingRate, *errs.Error) {
+	return schedCall(e.sched, PriorData, 1, func() ([]*banexg.FundingRate, *errs.Error) {
+		return e.BanExchange.FetchFundingRateHistory(symbol, since, limit, params)
+	})
+}
+
+func (e *BotExchange) Call(method string, params map[string]interface{}) (*banexg.HttpRes, *errs.Error) {
+	return schedCall(e.sched, PriorData, 1, func() (*banexg.HttpRes, *errs.Error) {
+		return e.BanExchange.Call(method, params)
+	})
+}
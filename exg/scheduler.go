@@ -0,0 +1,215 @@
+package exg
+
+import (
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banexg/errs"
+	"github.com/banbox/banexg/log"
+	"github.com/sasha-s/go-deadlock"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+// Priorities of exchange rest requests, smaller is higher 交易所rest请求的优先级，越小越高
+const (
+	PriorOrder   = iota // create/edit/cancel orders 下单、改单、撤单
+	PriorAccount        // balances, positions and orders of account 账户余额、仓位和订单
+	PriorData           // klines, tickers, order books, markets... K线、行情、订单簿、市场等
+	priorNum
+)
+
+var priorNames = [priorNum]string{"order", "account", "data"}
+
+var (
+	schedulers    = map[string]*ReqScheduler{}
+	lockSchedMap  deadlock.Mutex
+	schedWaitIntv = time.Millisecond * 50
+)
+
+/*
+ReqScheduler
+Share the weight budget per minute of an exchange among all rest requests of banbot by priorities,
+and pause all requests when 429/418 occurs.
+按优先级在banbot的所有rest请求间分配交易所每分钟的权重预算，出现429/418时暂停所有请求
+*/
+type ReqScheduler struct {
+	Name       string
+	Exchange   string // key of rate_limit.weights 对应rate_limit.weights中的键
+	buckets    [60]int
+	bucketSecs [60]int64
+	waits      [priorNum]int // number of waiting requests for each priority 各优先级等待中的请求数
+	pauseUntil int64
+	lock       deadlock.Mutex
+}
+
+/*
+getScheduler return the shared scheduler of exchange market, rest hosts of different markets are limited separately
+返回交易所市场共享的调度器，不同市场的rest域名分别限流
+*/
+func getScheduler(name, market string) *ReqScheduler {
+	key := name + "@" + market
+	lockSchedMap.Lock()
+	defer lockSchedMap.Unlock()
+	sched, ok := schedulers[key]
+	if !ok {
+		sched = &ReqScheduler{Name: key, Exchange: name}
+		schedulers[key] = sched
+	}
+	return sched
+}
+
+/*
+weightMin weight budget per minute from config, 0 for unlimited. Read on each request to apply config reloads
+从配置读取每分钟权重预算，0不限制。每次请求时读取以应用配置的重新加载
+*/
+func (s *ReqScheduler) weightMin() int {
+	if config.RateLimit == nil {
+		return 0
+	}
+	return config.RateLimit.Weights[s.Exchange]
+}
+
+// usedWeight sum weights in last minute, should be called in lock 最近一分钟已用权重，需在锁中调用
+func (s *ReqScheduler) usedWeight(curSecs int64) int {
+	total := 0
+	for i, secs := range s.bucketSecs {
+		if secs > curSecs-60 {
+			total += s.buckets[i]
+		}
+	}
+	return total
+}
+
+// capOf max weight per minute usable by priority 指定优先级可使用的每分钟最大权重
+func (s *ReqScheduler) capOf(prior, weightMin int) int {
+	dataPct := 70
+	if config.RateLimit != nil && config.RateLimit.DataPct > 0 {
+		dataPct = config.RateLimit.DataPct
+	}
+	switch prior {
+	case PriorOrder:
+		return weightMin
+	case PriorAccount:
+		return weightMin * (100 + dataPct) / 200
+	default:
+		return weightMin * dataPct / 100
+	}
+}
+
+/*
+tryTake take the weight if allowed for the priority, should be called in lock.
+Requests wait when paused, over budget, or any higher priority request is waiting.
+在锁中调用，允许时占用权重。暂停中、超出预算或有更高优先级请求等待时需等待
+*/
+func (s *ReqScheduler) tryTake(prior, weight int, curMS int64) bool {
+	if curMS < s.pauseUntil {
+		return false
+	}
+	weightMin := s.weightMin()
+	if weightMin <= 0 {
+		return true
+	}
+	for p := 0; p < prior; p++ {
+		if s.waits[p] > 0 {
+			return false
+		}
+	}
+	curSecs := curMS / 1000
+	used := s.usedWeight(curSecs)
+	if used > 0 && used+weight > s.capOf(prior, weightMin) {
+		return false
+	}
+	idx := curSecs % 60
+	if s.bucketSecs[idx] != curSecs {
+		s.bucketSecs[idx] = curSecs
+		s.buckets[idx] = 0
+	}
+	s.buckets[idx] += weight
+	core.SetMetric(core.MetricReqWeight, float64(used+weight), "exchange", s.Name)
+	return true
+}
+
+/*
+Acquire
+Block until the request with weight is allowed to be sent. return false if stopped.
+阻塞直到指定权重的请求允许发送。机器人停止时返回false
+*/
+func (s *ReqScheduler) Acquire(prior, weight int) bool {
+	startMS := btime.UTCStamp()
+	s.lock.Lock()
+	if s.tryTake(prior, weight, startMS) {
+		s.lock.Unlock()
+		return true
+	}
+	s.waits[prior] += 1
+	s.lock.Unlock()
+	res := true
+	for {
+		if core.Ctx != nil && core.Ctx.Err() != nil {
+			res = false
+			break
+		}
+		time.Sleep(schedWaitIntv)
+		s.lock.Lock()
+		s.waits[prior] -= 1
+		if s.tryTake(prior, weight, btime.UTCStamp()) {
+			s.lock.Unlock()
+			break
+		}
+		s.waits[prior] += 1
+		s.lock.Unlock()
+	}
+	if !res {
+		s.lock.Lock()
+		s.waits[prior] -= 1
+		s.lock.Unlock()
+	}
+	waitSecs := float64(btime.UTCStamp()-startMS) / 1000
+	core.ObserveMetric(core.MetricReqWaitSecs, waitSecs, "exchange", s.Name, "priority", priorNames[prior])
+	return res
+}
+
+/*
+OnResult pause all requests when exchange responds 429/418, use Retry-After if exists
+交易所返回429/418时暂停所有请求，有Retry-After时使用该值
+*/
+func (s *ReqScheduler) OnResult(err *errs.Error) {
+	if err == nil || (err.Code != 429 && err.Code != 418) {
+		return
+	}
+	waitSecs, _ := err.Data.(int64)
+	if waitSecs <= 0 {
+		waitSecs = 30
+		if config.RateLimit != nil && config.RateLimit.BackoffSecs > 0 {
+			waitSecs = int64(config.RateLimit.BackoffSecs)
+		}
+	}
+	until := btime.UTCStamp() + waitSecs*1000
+	s.lock.Lock()
+	if until > s.pauseUntil {
+		s.pauseUntil = until
+	}
+	s.lock.Unlock()
+	core.AddMetric(core.MetricReqLimited, 1, "exchange", s.Name, "code", strconv.Itoa(err.Code))
+	log.Warn("exchange rate limited, pause requests", zap.String("exg", s.Name), zap.Int("code", err.Code),
+		zap.Int64("secs", waitSecs))
+}
+
+/*
+schedCall run a rest request through scheduler
+通过调度器执行rest请求
+*/
+func schedCall[T any](s *ReqScheduler, prior, weight int, call func() (T, *errs.Error)) (T, *errs.Error) {
+	if s == nil {
+		return call()
+	}
+	if !s.Acquire(prior, weight) {
+		var zero T
+		return zero, errs.NewMsg(errs.CodeRunTime, "request canceled as bot stopped")
+	}
+	res, err := call()
+	s.OnResult(err)
+	return res, err
+}
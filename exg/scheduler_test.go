@@ -0,0 +1,57 @@
+package exg
+
+import (
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banexg/errs"
+	"testing"
+)
+
+func TestReqScheduler(t *testing.T) {
+	config.RateLimit = &config.RateLimitConfig{DataPct: 50, BackoffSecs: 30}
+	s := getScheduler("test", "linear")
+	if !s.tryTake(PriorData, 1000, btime.UTCStamp()) {
+		t.Fatal("scheduler without budget should be unlimited")
+	}
+	// budget is applied when config is loaded after the scheduler created 调度器创建后加载配置时应用预算
+	config.RateLimit.Weights = map[string]int{"test": 100}
+	curMS := btime.UTCStamp()
+	if !s.tryTake(PriorData, 40, curMS) {
+		t.Fatal("first data request should pass")
+	}
+	if s.tryTake(PriorData, 20, curMS) {
+		t.Fatal("data request should be limited by data_pct")
+	}
+	if !s.tryTake(PriorAccount, 30, curMS) {
+		t.Fatal("account request should pass under 75%")
+	}
+	if !s.tryTake(PriorOrder, 30, curMS) {
+		t.Fatal("order request can use full budget")
+	}
+	if s.tryTake(PriorOrder, 1, curMS) {
+		t.Fatal("budget used up")
+	}
+	// weights expire after a minute 权重一分钟后过期
+	curMS += 61000
+	s.waits[PriorOrder] = 1
+	if s.tryTake(PriorData, 1, curMS) {
+		t.Fatal("data request should wait for waiting orders")
+	}
+	s.waits[PriorOrder] = 0
+	if !s.tryTake(PriorData, 1, curMS) {
+		t.Fatal("data request should pass after expired")
+	}
+	// back off on 429
+	err := errs.NewMsg(429, "too many requests")
+	err.Data = int64(5)
+	s.OnResult(err)
+	if s.pauseUntil < btime.UTCStamp()+4000 || s.tryTake(PriorOrder, 1, btime.UTCStamp()) {
+		t.Fatal("requests should pause after 429")
+	}
+	res, err := schedCall(&ReqScheduler{}, PriorData, 1000, func() (int, *errs.Error) {
+		return 1, nil
+	})
+	if res != 1 || err != nil {
+		t.Fatal("unlimited scheduler should pass")
+	}
+}
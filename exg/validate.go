@@ -0,0 +1,186 @@
+package exg
+
+import (
+	"fmt"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banexg"
+	"github.com/banbox/banexg/errs"
+	"strconv"
+)
+
+// Reasons of order rejected by pre-trade validation 下单前校验拒绝订单的原因
+const (
+	RejAmountMin    = "AmountMin"
+	RejAmountMax    = "AmountMax"
+	RejMarketAmtMax = "MarketAmountMax"
+	RejPriceMin     = "PriceMin"
+	RejPriceMax     = "PriceMax"
+	RejCostMin      = "CostMin"
+	RejCostMax      = "CostMax"
+	RejLeverage     = "LeverageTier"
+	RejPriceBand    = "PriceBand"
+)
+
+const limitTolerance = 1e-9
+
+/*
+OrderCheck
+Arguments of an order to be validated against market limits.
+待按市场限制校验的订单参数
+*/
+type OrderCheck struct {
+	Symbol    string
+	Side      string
+	OrderType string
+	Amount    float64
+	Price     float64 // order price, 0 for market order 订单价格，市价单为0
+	RefPrice  float64 // latest market price, for cost of market order and percent price band 最新市价，用于市价单金额和价格带校验
+	Leverage  float64
+	Account   string
+	Reduce    bool // exit order, leverage and min notional of contracts are not checked 平仓单，不检查合约的杠杆和最小名义价值
+}
+
+/*
+ValidateOrder
+Check order against the limits of market before submit, same in live and backtest.
+return the rejection reason and error, reason is empty when passed.
+下单前按市场限制检查订单，实盘和回测相同。返回拒绝原因和错误，通过时原因为空
+*/
+func ValidateOrder(exchange banexg.BanExchange, args *OrderCheck) (string, *errs.Error) {
+	if exchange == nil {
+		if Default == nil {
+			return "", errs.NewMsg(core.ErrExgNotInit, "exchange not loaded")
+		}
+		exchange = Default
+	}
+	market, err := exchange.GetMarket(args.Symbol)
+	if err != nil {
+		return "", err
+	}
+	var maxLvg float64
+	if market.Contract && !args.Reduce && args.Leverage > 0 {
+		notional := orderNotional(market, args)
+		_, maxLvg = exchange.GetLeverage(args.Symbol, notional, args.Account)
+	}
+	return CheckMarketLimits(market, args, maxLvg)
+}
+
+/*
+CheckMarketLimits
+Check order by amount/price/cost limits, the percent price band in market info, and the max leverage
+of current notional tier. maxLvg is ignored when <= 0
+按数量/价格/金额限制，市场信息中的百分比价格带，以及当前名义价值档位的最大杠杆检查订单。maxLvg<=0时忽略
+*/
+func CheckMarketLimits(market *banexg.Market, args *OrderCheck, maxLvg float64) (string, *errs.Error) {
+	reject := func(reason, format string, a ...any) (string, *errs.Error) {
+		return reason, errs.NewMsg(core.ErrOrderReject, "%s %s %s: %s", args.Symbol, args.Side, reason,
+			fmt.Sprintf(format, a...))
+	}
+	amount, price := args.Amount, args.Price
+	isMarket := price == 0 || args.OrderType == banexg.OdTypeMarket
+	lim := market.Limits
+	if lim != nil {
+		if r := lim.Amount; r != nil {
+			if r.Min > 0 && amount < r.Min*(1-limitTolerance) {
+				return reject(RejAmountMin, "amount %v < min %v", amount, r.Min)
+			}
+			if r.Max > 0 && amount > r.Max*(1+limitTolerance) {
+				return reject(RejAmountMax, "amount %v > max %v", amount, r.Max)
+			}
+		}
+		if r := lim.Market; isMarket && r != nil && r.Max > 0 && amount > r.Max*(1+limitTolerance) {
+			return reject(RejMarketAmtMax, "market amount %v > max %v", amount, r.Max)
+		}
+		if r := lim.Price; !isMarket && r != nil {
+			if r.Min > 0 && price < r.Min*(1-limitTolerance) {
+				return reject(RejPriceMin, "price %v < min %v", price, r.Min)
+			}
+			if r.Max > 0 && price > r.Max*(1+limitTolerance) {
+				return reject(RejPriceMax, "price %v > max %v", price, r.Max)
+			}
+		}
+		if r := lim.Cost; r != nil {
+			cost := orderNotional(market, args)
+			// reduce only orders of contracts are exempt from min notional 合约的只减仓订单不受最小名义价值限制
+			minCost := r.Min > 0 && !(args.Reduce && market.Contract)
+			if cost > 0 && minCost && cost < r.Min*(1-limitTolerance) {
+				return reject(RejCostMin, "notional %v < min %v", cost, r.Min)
+			}
+			if cost > 0 && r.Max > 0 && cost > r.Max*(1+limitTolerance) {
+				return reject(RejCostMax, "notional %v > max %v", cost, r.Max)
+			}
+		}
+		if r := lim.Leverage; market.Contract && !args.Reduce && r != nil && r.Max > 0 && args.Leverage > r.Max {
+			return reject(RejLeverage, "leverage %v > max %v", args.Leverage, r.Max)
+		}
+	}
+	if market.Contract && !args.Reduce && maxLvg > 0 && args.Leverage > maxLvg {
+		return reject(RejLeverage, "leverage %v > max %v of notional tier", args.Leverage, maxLvg)
+	}
+	if !isMarket && args.RefPrice > 0 {
+		lower, upper := priceBand(market, args.Side)
+		if lower > 0 && price < args.RefPrice*lower*(1-limitTolerance) {
+			return reject(RejPriceBand, "price %v < %v * %v", price, args.RefPrice, lower)
+		}
+		if upper > 0 && price > args.RefPrice*upper*(1+limitTolerance) {
+			return reject(RejPriceBand, "price %v > %v * %v", price, args.RefPrice, upper)
+		}
+	}
+	return "", nil
+}
+
+/*
+orderNotional return the notional value of order in quote, use RefPrice for market orders.
+For inverse contracts, amount is number of contracts valued in quote.
+返回订单以计价币计算的名义价值，市价单使用RefPrice。反向合约的数量为按计价币计价的合约张数
+*/
+func orderNotional(market *banexg.Market, args *OrderCheck) float64 {
+	size := market.ContractSize
+	if size == 0 {
+		size = 1
+	}
+	if market.Inverse {
+		return args.Amount * size
+	}
+	price := args.Price
+	if price == 0 {
+		price = args.RefPrice
+	}
+	return args.Amount * price * size
+}
+
+/*
+priceBand return multipliers of the percent price filter in raw market info, 0 when missing.
+Support PERCENT_PRICE and PERCENT_PRICE_BY_SIDE of binance
+返回原始市场信息中百分比价格过滤器的倍数，缺失时为0。支持币安的PERCENT_PRICE和PERCENT_PRICE_BY_SIDE
+*/
+func priceBand(market *banexg.Market, side string) (float64, float64) {
+	filters, _ := market.Info["filters"].([]interface{})
+	for _, item := range filters {
+		flt, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fltType, _ := flt["filterType"].(string)
+		if fltType == "PERCENT_PRICE" {
+			return anyFloat(flt["multiplierDown"]), anyFloat(flt["multiplierUp"])
+		} else if fltType == "PERCENT_PRICE_BY_SIDE" {
+			if side == banexg.OdSideBuy {
+				return anyFloat(flt["bidMultiplierDown"]), anyFloat(flt["bidMultiplierUp"])
+			}
+			return anyFloat(flt["askMultiplierDown"]), anyFloat(flt["askMultiplierUp"])
+		}
+	}
+	return 0, 0
+}
+
+func anyFloat(v interface{}) float64 {
+	switch val := v.(type) {
+	case float64:
+		return val
+	case string:
+		res, _ := strconv.ParseFloat(val, 64)
+		return res
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+package exg
+
+import (
+	"github.com/banbox/banexg"
+	"testing"
+)
+
+func TestCheckMarketLimits(t *testing.T) {
+	mar := &banexg.Market{
+		Symbol:       "BTC/USDT:USDT",
+		Contract:     true,
+		Linear:       true,
+		ContractSize: 1,
+		Limits: &banexg.MarketLimits{
+			Amount: &banexg.LimitRange{Min: 0.001, Max: 1000},
+			Market: &banexg.LimitRange{Min: 0.001, Max: 120},
+			Price:  &banexg.LimitRange{Min: 500, Max: 1000000},
+			Cost:   &banexg.LimitRange{Min: 100},
+		},
+		Info: map[string]interface{}{
+			"filters": []interface{}{
+				map[string]interface{}{"filterType": "PERCENT_PRICE", "multiplierUp": "1.0500",
+					"multiplierDown": "0.9500"},
+			},
+		},
+	}
+	base := OrderCheck{Symbol: mar.Symbol, Side: banexg.OdSideBuy, OrderType: banexg.OdTypeLimit,
+		Amount: 0.01, Price: 20000, RefPrice: 20000, Leverage: 10}
+	cases := []struct {
+		name   string
+		edit   func(a *OrderCheck)
+		maxLvg float64
+		reason string
+	}{
+		{"ok", func(a *OrderCheck) {}, 20, ""},
+		{"amount_min", func(a *OrderCheck) { a.Amount = 0.0005 }, 0, RejAmountMin},
+		{"market_max", func(a *OrderCheck) { a.OrderType = banexg.OdTypeMarket; a.Price = 0; a.Amount = 200 }, 0,
+			RejMarketAmtMax},
+		{"cost_min", func(a *OrderCheck) { a.Amount = 0.004 }, 0, RejCostMin},
+		{"cost_min_reduce", func(a *OrderCheck) { a.Amount = 0.004; a.Reduce = true }, 0, ""},
+		{"price_band", func(a *OrderCheck) { a.Price = 21500 }, 0, RejPriceBand},
+		{"leverage_tier", func(a *OrderCheck) {}, 5, RejLeverage},
+		{"leverage_reduce", func(a *OrderCheck) { a.Reduce = true }, 5, ""},
+	}
+	for _, c := range cases {
+		args := base
+		c.edit(&args)
+		reason, err := CheckMarketLimits(mar, &args, c.maxLvg)
+		if reason != c.reason || (reason == "") != (err == nil) {
+			t.Errorf("%s: expect %q, got %q %v", c.name, c.reason, reason, err)
+		}
+	}
+}
@@ -2,18 +2,61 @@ package goods
 
 import (
 	"fmt"
+	"sort"
+
 	"github.com/banbox/banbot/config"
 	"github.com/banbox/banbot/core"
 	"github.com/banbox/banbot/orm"
+	"github.com/banbox/banbot/utils"
 	"github.com/banbox/banexg/errs"
 	"github.com/banbox/banexg/log"
 	"github.com/go-viper/mapstructure/v2"
+	"github.com/sasha-s/go-deadlock"
 )
 
 var (
-	pairProducer IProducer
-	filters      = make([]IFilter, 0, 10)
-	ShowLog      = true
+	pairProducer   IProducer
+	filters        = make([]IFilter, 0, 10)
+	ShowLog        = true
+	lockFilterMake deadlock.Mutex
+	filterMakers   = map[string]FuncMakeFilter{
+		"AgeFilter": func(base BaseFilter) IFilter {
+			return &AgeFilter{BaseFilter: base}
+		},
+		"VolumePairList": func(base BaseFilter) IFilter {
+			return &VolumePairFilter{BaseFilter: base}
+		},
+		"PriceFilter": func(base BaseFilter) IFilter {
+			return &PriceFilter{BaseFilter: base}
+		},
+		"RateOfChangeFilter": func(base BaseFilter) IFilter {
+			return &RateOfChangeFilter{BaseFilter: base}
+		},
+		"VolatilityFilter": func(base BaseFilter) IFilter {
+			return &VolatilityFilter{BaseFilter: base}
+		},
+		"SpreadFilter": func(base BaseFilter) IFilter {
+			return &SpreadFilter{BaseFilter: base}
+		},
+		"OffsetFilter": func(base BaseFilter) IFilter {
+			return &OffsetFilter{BaseFilter: base}
+		},
+		"ShuffleFilter": func(base BaseFilter) IFilter {
+			return &ShuffleFilter{BaseFilter: base}
+		},
+		"CorrelationFilter": func(base BaseFilter) IFilter {
+			return &CorrelationFilter{BaseFilter: base}
+		},
+		"BlockFilter": func(base BaseFilter) IFilter {
+			return &BlockFilter{BaseFilter: base}
+		},
+		"TagFilter": func(base BaseFilter) IFilter {
+			return &TagFilter{BaseFilter: base}
+		},
+		"RelStrengthFilter": func(base BaseFilter) IFilter {
+			return &RelStrengthFilter{BaseFilter: base}
+		},
+	}
 )
 
 func Setup() *errs.Error {
@@ -33,47 +76,52 @@ func Setup() *errs.Error {
 	return nil
 }
 
+/*
+AddFilter
+Register a pair filter maker by name, so it can be referenced in `pairlists` and `run_policy.filters`.
+The maker should embed the given BaseFilter into the returned filter; config items are decoded into it by mapstructure.
+Implement `IFilterInit` to validate or prepare after decoding. Existing names are overwritten.
+按名称注册品种过滤器构造函数，以便在`pairlists`和`run_policy.filters`中引用。
+构造函数应将传入的BaseFilter嵌入返回的过滤器；配置项会通过mapstructure解码到其中。
+可实现`IFilterInit`在解码后校验或初始化。已存在的名称会被覆盖。
+*/
+func AddFilter(name string, maker FuncMakeFilter) {
+	lockFilterMake.Lock()
+	filterMakers[name] = maker
+	lockFilterMake.Unlock()
+}
+
+// FilterNames return all registered filter names 返回所有已注册的过滤器名称
+func FilterNames() []string {
+	lockFilterMake.Lock()
+	defer lockFilterMake.Unlock()
+	names := utils.KeysOfMap(filterMakers)
+	sort.Strings(names)
+	return names
+}
+
 func GetPairFilters(items []*config.CommonPairFilter, withInvalid bool) ([]IFilter, *errs.Error) {
 	fts := make([]IFilter, 0, len(items))
 	// 未启用定期刷新，则允许成交量为空的品种
 	allowEmpty := config.PairMgr.Cron == ""
-	var err *errs.Error
 	for _, cfg := range items {
-		var output IFilter
 		var base = BaseFilter{Name: cfg.Name, AllowEmpty: allowEmpty}
-		switch cfg.Name {
-		case "AgeFilter":
-			output = &AgeFilter{BaseFilter: base}
-		case "VolumePairList":
-			output = &VolumePairFilter{BaseFilter: base}
-		case "PriceFilter":
-			output = &PriceFilter{BaseFilter: base}
-		case "RateOfChangeFilter":
-			output = &RateOfChangeFilter{BaseFilter: base}
-		case "VolatilityFilter":
-			output = &VolatilityFilter{BaseFilter: base}
-		case "SpreadFilter":
-			output = &SpreadFilter{BaseFilter: base}
-		case "OffsetFilter":
-			output = &OffsetFilter{BaseFilter: base}
-		case "ShuffleFilter":
-			output = &ShuffleFilter{BaseFilter: base}
-		case "CorrelationFilter":
-			output = &CorrelationFilter{BaseFilter: base}
-		case "BlockFilter":
-			blockFts := &BlockFilter{BaseFilter: base}
-			blockFts.Pairs, err = config.ParsePairs(blockFts.Pairs...)
-			if err != nil {
-				return nil, err
-			}
-			output = blockFts
-		default:
+		lockFilterMake.Lock()
+		maker, ok := filterMakers[cfg.Name]
+		lockFilterMake.Unlock()
+		if !ok {
 			return nil, errs.NewMsg(errs.CodeParamInvalid, "unknown symbol filter: %s", cfg.Name)
 		}
+		output := maker(base)
 		err_ := mapstructure.Decode(cfg.Items, &output)
 		if err_ != nil {
 			return nil, errs.New(errs.CodeUnmarshalFail, err_)
 		}
+		if init, ok := output.(IFilterInit); ok {
+			if err := init.Init(); err != nil {
+				return nil, err
+			}
+		}
 		if withInvalid || !output.IsDisable() {
 			fts = append(fts, output)
 		}
@@ -1,6 +1,14 @@
 package goods
 
-import "testing"
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banexg"
+	"github.com/banbox/banexg/errs"
+)
 
 func TestBlockFilter(t *testing.T) {
 	f := BlockFilter{
@@ -18,3 +26,63 @@ func TestBlockFilter(t *testing.T) {
 		t.Errorf("FAIL BlockFilter, get: %v, expect: %v", out, []string{"ETH/USDT:USDT"})
 	}
 }
+
+type prefixFilter struct {
+	BaseFilter
+	Prefix string `yaml:"prefix" mapstructure:"prefix"`
+}
+
+func (f *prefixFilter) Filter(symbols []string, timeMS int64) ([]string, *errs.Error) {
+	res := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		if strings.HasPrefix(s, f.Prefix) {
+			res = append(res, s)
+		}
+	}
+	return res, nil
+}
+
+func TestAddFilter(t *testing.T) {
+	AddFilter("PrefixFilter", func(base BaseFilter) IFilter {
+		return &prefixFilter{BaseFilter: base}
+	})
+	if config.PairMgr == nil {
+		config.PairMgr = &config.PairMgrConfig{}
+	}
+	fts, err := GetPairFilters([]*config.CommonPairFilter{
+		{Name: "PrefixFilter", Items: map[string]interface{}{"prefix": "ETH"}},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := fts[0].Filter([]string{"BTC/USDT:USDT", "ETH/USDT:USDT"}, 0)
+	if fts[0].GetName() != "PrefixFilter" || len(out) != 1 || out[0] != "ETH/USDT:USDT" {
+		t.Errorf("FAIL PrefixFilter, get: %v", out)
+	}
+	_, err = GetPairFilters([]*config.CommonPairFilter{{Name: "NoSuchFilter"}}, false)
+	if err == nil {
+		t.Errorf("unknown filter should fail")
+	}
+}
+
+func TestCalcRelStrength(t *testing.T) {
+	var up, flat []*banexg.Kline
+	bench := make(map[int64]float64)
+	for i := 0; i < 10; i++ {
+		up = append(up, &banexg.Kline{Time: int64(i), Close: 100 * math.Pow(1.02, float64(i))})
+		flat = append(flat, &banexg.Kline{Time: int64(i), Close: 100})
+		bench[int64(i)] = 1.01
+	}
+	score, ok := calcRelStrength(up, nil, false)
+	if !ok || math.Abs(score-9*math.Log(1.02)) > 1e-9 {
+		t.Errorf("bad raw score: %v", score)
+	}
+	score, _ = calcRelStrength(up, bench, false)
+	if math.Abs(score-9*math.Log(1.02/1.01)) > 1e-9 {
+		t.Errorf("bad relative score: %v", score)
+	}
+	score, _ = calcRelStrength(flat, bench, false)
+	if score >= 0 {
+		t.Errorf("flat should be weaker than benchmark: %v", score)
+	}
+}
@@ -1,6 +1,7 @@
 package goods
 
 import (
+	"cmp"
 	"fmt"
 	"math"
 	"math/rand"
@@ -202,9 +203,17 @@ func filterByMinCost(symbols []SymbolVol) ([]string, map[string]float64) {
 }
 
 func (f *VolumePairFilter) GenSymbols(timeMS int64) ([]string, *errs.Error) {
-	symbols := make([]string, 0)
+	symbols, err := getStakeSymbols(f.Name)
+	if err != nil {
+		return nil, err
+	}
+	return f.Filter(symbols, timeMS)
+}
+
+// getStakeSymbols all symbols of current markets quoted in stake currencies 当前市场中以定价币计价的所有品种
+func getStakeSymbols(name string) ([]string, *errs.Error) {
 	markets := exg.Default.GetCurMarkets()
-	symbols = utils.KeysOfMap(markets)
+	symbols := utils.KeysOfMap(markets)
 	pairs := make([]string, 0, len(symbols))
 	for _, pair := range symbols {
 		_, quote, _, _ := core.SplitSymbol(pair)
@@ -212,11 +221,10 @@ func (f *VolumePairFilter) GenSymbols(timeMS int64) ([]string, *errs.Error) {
 			pairs = append(pairs, pair)
 		}
 	}
-	symbols = pairs
-	if len(symbols) == 0 {
-		return nil, errs.NewMsg(errs.CodeRunTime, "no symbols generate from VolumePairFilter")
+	if len(pairs) == 0 {
+		return nil, errs.NewMsg(errs.CodeRunTime, "no symbols generate from %s", name)
 	}
-	return f.Filter(symbols, timeMS)
+	return pairs, nil
 }
 
 func (f *PriceFilter) Filter(symbols []string, timeMS int64) ([]string, *errs.Error) {
@@ -467,10 +475,155 @@ func (f *VolatilityFilter) Filter(symbols []string, timeMS int64) ([]string, *er
 	})
 }
 
+func (f *RelStrengthFilter) GenSymbols(timeMS int64) ([]string, *errs.Error) {
+	symbols, err := getStakeSymbols(f.Name)
+	if err != nil {
+		return nil, err
+	}
+	return f.Filter(symbols, timeMS)
+}
+
+func (f *RelStrengthFilter) Filter(symbols []string, timeMS int64) ([]string, *errs.Error) {
+	if f.Timeframe == "" {
+		f.Timeframe = "1d"
+	}
+	if f.BackNum == 0 {
+		f.BackNum = 30
+	}
+	pairs := symbols
+	if f.Benchmark != "" && !slices.Contains(symbols, f.Benchmark) {
+		err := orm.EnsureCurSymbols([]string{f.Benchmark})
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(slices.Clone(symbols), f.Benchmark)
+	}
+	barsMap := make(map[string][]*banexg.Kline)
+	loadNum := f.BackNum + f.SkipNum + 1
+	_, err := filterByOHLCV(pairs, f.Timeframe, timeMS, loadNum, core.AdjFront, func(s string, klines []*banexg.Kline) bool {
+		if len(klines) > f.SkipNum {
+			barsMap[s] = klines[:len(klines)-f.SkipNum]
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	var benchRates map[int64]float64
+	if f.Benchmark != "" {
+		benchBars := barsMap[f.Benchmark]
+		if len(benchBars) < 2 {
+			return nil, errs.NewMsg(core.ErrRunTime, "no klines for benchmark %s at %v", f.Benchmark, timeMS)
+		}
+		benchRates = make(map[int64]float64, len(benchBars))
+		for i, b := range benchBars[1:] {
+			benchRates[b.Time] = b.Close / benchBars[i].Close
+		}
+	}
+	items := make([]SymbolVol, 0, len(symbols))
+	for _, pair := range symbols {
+		if pair == f.Benchmark {
+			// benchmark is not ranked against itself 基准不与自身比较排名
+			continue
+		}
+		klines := barsMap[pair]
+		if len(klines)*2 < f.BackNum+1 {
+			continue
+		}
+		score, ok := calcRelStrength(klines, benchRates, f.VolAdjust)
+		if ok {
+			items = append(items, SymbolVol{pair, score, klines[len(klines)-1].Close})
+		}
+	}
+	slices.SortFunc(items, func(a, b SymbolVol) int {
+		if f.Reverse {
+			return cmp.Compare(a.Vol, b.Vol)
+		}
+		return cmp.Compare(b.Vol, a.Vol)
+	})
+	resPairs, _ := filterByMinCost(items)
+	if f.LimitRate > 0 && f.LimitRate < 1 {
+		num := int(math.Round(f.LimitRate * float64(len(resPairs))))
+		resPairs = resPairs[:num]
+	}
+	if f.Limit > 0 && f.Limit < len(resPairs) {
+		resPairs = resPairs[:f.Limit]
+	}
+	return resPairs, nil
+}
+
+/*
+calcRelStrength
+Sum of log returns relative to benchmark rates (close/prev_close by bar time), divided by the volatility of
+excess returns when volAdjust. Bars missing in benchmark are ignored.
+相对基准涨跌比(按bar时间的close/prev_close)的对数收益之和，volAdjust时除以超额收益的波动率。基准缺失的bar被忽略
+*/
+func calcRelStrength(klines []*banexg.Kline, benchRates map[int64]float64, volAdjust bool) (float64, bool) {
+	rates := make([]float64, 0, len(klines))
+	for i, b := range klines[1:] {
+		prev := klines[i].Close
+		if prev <= 0 || b.Close <= 0 {
+			continue
+		}
+		rate := b.Close / prev
+		if benchRates != nil {
+			benchRate, ok := benchRates[b.Time]
+			if !ok || benchRate <= 0 {
+				continue
+			}
+			rate /= benchRate
+		}
+		rates = append(rates, rate)
+	}
+	if len(rates) == 0 {
+		return 0, false
+	}
+	score := 0.0
+	for _, r := range rates {
+		score += math.Log(r)
+	}
+	if volAdjust {
+		if vol := utils.StdDevVolatility(rates, 1); vol > 0 {
+			score /= vol
+		}
+	}
+	return score, true
+}
+
 func (f *SpreadFilter) Filter(symbols []string, timeMS int64) ([]string, *errs.Error) {
 	return symbols, nil
 }
 
+func (f *TagFilter) Filter(symbols []string, timeMS int64) ([]string, *errs.Error) {
+	if len(f.Include) == 0 && len(f.Exclude) == 0 {
+		return symbols, nil
+	}
+	res := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		tags := orm.GetPairTags(s)
+		if len(f.Include) > 0 && !hasAnyTag(tags, f.Include) || hasAnyTag(tags, f.Exclude) {
+			continue
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+func hasAnyTag(tags, targets []string) bool {
+	for _, t := range targets {
+		if slices.Contains(tags, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *BlockFilter) Init() *errs.Error {
+	var err *errs.Error
+	f.Pairs, err = config.ParsePairs(f.Pairs...)
+	return err
+}
+
 func (f *BlockFilter) Filter(symbols []string, timeMS int64) ([]string, *errs.Error) {
 	if len(f.Pairs) == 0 {
 		return symbols, nil
@@ -509,7 +662,14 @@ func (f *OffsetFilter) Filter(symbols []string, timeMS int64) ([]string, *errs.E
 }
 
 func (f *ShuffleFilter) Filter(symbols []string, timeMS int64) ([]string, *errs.Error) {
-	rand.Shuffle(len(symbols), func(i, j int) {
+	if f.rng == nil {
+		if f.Seed != 0 {
+			f.rng = rand.New(rand.NewSource(int64(f.Seed)))
+		} else {
+			f.rng = config.NewRand(f.Name)
+		}
+	}
+	f.rng.Shuffle(len(symbols), func(i, j int) {
 		symbols[i], symbols[j] = symbols[j], symbols[i]
 	})
 	return symbols, nil
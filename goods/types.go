@@ -1,6 +1,8 @@
 package goods
 
 import (
+	"math/rand"
+
 	"github.com/banbox/banexg/errs"
 )
 
@@ -15,6 +17,18 @@ type IProducer interface {
 	GenSymbols(timeMS int64) ([]string, *errs.Error)
 }
 
+/*
+IFilterInit
+Optional for filters, called after config items are decoded.
+过滤器可选实现，在配置项解码后调用
+*/
+type IFilterInit interface {
+	Init() *errs.Error
+}
+
+// FuncMakeFilter create a filter embedding the base 创建嵌入base的过滤器
+type FuncMakeFilter func(base BaseFilter) IFilter
+
 type BaseFilter struct {
 	Name       string `yaml:"name" mapstructure:"name"`
 	Disable    bool   `yaml:"disable" mapstructure:"disable,omitempty"`
@@ -80,6 +94,30 @@ type VolatilityFilter struct {
 	Min      float64 `yaml:"min" mapstructure:"min,omitempty"`             // 波动分数最小值
 }
 
+/*
+RelStrengthFilter Rank pairs by return over the lookback, relative to the stake currency or a benchmark symbol,
+optionally adjusted by volatility. Can be used as the producer for rotational strategies.
+按回顾期内收益率排序品种，相对定价币或基准品种，可选按波动率调整。可作为轮动策略的品种生成器
+*/
+type RelStrengthFilter struct {
+	BaseFilter
+	Timeframe string  `yaml:"timeframe" mapstructure:"timeframe,omitempty"`   // default 1d 默认1d
+	BackNum   int     `yaml:"back_num" mapstructure:"back_num,omitempty"`     // bars to calculate return, default 30 计算收益的K线数量，默认30
+	SkipNum   int     `yaml:"skip_num" mapstructure:"skip_num,omitempty"`     // skip latest n bars 忽略最近n个K线
+	Benchmark string  `yaml:"benchmark" mapstructure:"benchmark,omitempty"`   // benchmark symbol, empty for stake currency 基准品种，为空表示定价币
+	VolAdjust bool    `yaml:"vol_adjust" mapstructure:"vol_adjust,omitempty"` // divide by volatility of excess returns 除以超额收益的波动率
+	Limit     int     `yaml:"limit" mapstructure:"limit,omitempty"`           // max pairs to return 最多返回数量
+	LimitRate float64 `yaml:"limit_rate" mapstructure:"limit_rate,omitempty"` // rate of pairs to return 返回品种的比例
+	Reverse   bool    `yaml:"reverse" mapstructure:"reverse,omitempty"`       // true to return weakest first 为true时返回最弱的品种
+}
+
+// TagFilter include or exclude pairs by symbol tags in exsymbol_tag 按exsymbol_tag中的品种标签包含或排除品种
+type TagFilter struct {
+	BaseFilter
+	Include []string `yaml:"include" mapstructure:"include,omitempty"` // keep pairs with any of the tags 保留带有任一标签的品种
+	Exclude []string `yaml:"exclude" mapstructure:"exclude,omitempty"` // drop pairs with any of the tags 丢弃带有任一标签的品种
+}
+
 type BlockFilter struct {
 	BaseFilter
 	Pairs   []string `yaml:"pairs" mapstructure:"pairs,omitempty"`
@@ -102,5 +140,6 @@ type OffsetFilter struct {
 
 type ShuffleFilter struct {
 	BaseFilter
-	Seed int `yaml:"seed" mapstructure:"seed,omitempty"`
+	Seed int `yaml:"seed" mapstructure:"seed,omitempty"` // use run-level seed if 0 为0时使用运行级种子
+	rng  *rand.Rand
 }
@@ -2,12 +2,14 @@ package live
 
 import (
 	"fmt"
+	"github.com/anyongjin/cron"
 	"github.com/banbox/banbot/biz"
 	"github.com/banbox/banbot/btime"
 	"github.com/banbox/banbot/config"
 	"github.com/banbox/banbot/core"
 	"github.com/banbox/banbot/data"
 	"github.com/banbox/banbot/exg"
+	"github.com/banbox/banbot/goods"
 	"github.com/banbox/banbot/opt"
 	"github.com/banbox/banbot/orm"
 	"github.com/banbox/banbot/orm/ormo"
@@ -15,24 +17,35 @@ import (
 	"github.com/banbox/banbot/strat"
 	"github.com/banbox/banbot/utils"
 	"github.com/banbox/banexg"
+	"github.com/banbox/banexg/errs"
 	"github.com/banbox/banexg/log"
+	"github.com/sasha-s/go-deadlock"
 	"go.uber.org/zap"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 )
 
 var (
 	lastNotifyDelay = int64(0)
 	lastRefreshMS   = int64(0)
+	lockRefresh     deadlock.Mutex // Prevent concurrent refreshing of jobs 防止并发刷新任务
+	refreshCronID   cron.ID
+	refreshCronSpec string
 )
 
 func CronRefreshPairs(dp data.IProvider) {
+	refreshCronSpec = config.PairMgr.Cron
 	if config.PairMgr.Cron != "" {
-		_, err_ := core.Cron.Add(config.PairMgr.Cron, func() {
+		var err_ error
+		refreshCronID, err_ = core.Cron.Add(config.PairMgr.Cron, func() {
+			lockRefresh.Lock()
+			defer lockRefresh.Unlock()
 			curMS := btime.TimeMS()
 			if curMS-lastRefreshMS < config.MinPairCronGapMS {
 				return
@@ -49,6 +62,93 @@ func CronRefreshPairs(dp data.IProvider) {
 	}
 }
 
+/*
+ReloadConfig
+Re-parse config files and apply changes of run_policy and pairlists to the running bot without restart.
+Jobs of changed policies are re-parameterized, jobs of removed policies are handled by OrderOnRotation.
+The cron of refreshing pairs is re-registered when pairmgr.cron changes.
+Return IDs of added, removed and changed policies.
+重新解析配置文件，将run_policy和pairlists的变化应用到运行中的机器人，无需重启。
+修改的策略任务会更新参数，删除的策略任务按OrderOnRotation处理。pairmgr.cron变化时重新注册刷新品种的定时任务。
+返回新增、删除、修改的策略ID
+*/
+func ReloadConfig(dp data.IProvider) (map[string][]string, *errs.Error) {
+	lockRefresh.Lock()
+	defer lockRefresh.Unlock()
+	cfg, err := config.GetConfig(config.Args, false)
+	if err != nil {
+		return nil, err
+	}
+	adds, removes, changes, err := config.ReloadRunPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+	err = goods.Setup()
+	if err != nil {
+		return nil, err
+	}
+	accOds := strat.ReloadPolicies(removes, changes)
+	if len(accOds) > 0 {
+		sess, conn, err := ormo.Conn(orm.DbTrades, true)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		for acc, odList := range accOds {
+			if len(odList) == 0 {
+				continue
+			}
+			err = biz.GetOdMgr(acc).ExitAndFill(sess, odList, &strat.ExitReq{Tag: core.ExitTagPairDel})
+			if err != nil {
+				return nil, err
+			}
+			log.Info("exit orders of removed policies", zap.String("acc", acc), zap.Int("num", len(odList)))
+		}
+	}
+	err = opt.RefreshPairJobs(dp, true, false, nil)
+	lastRefreshMS = btime.TimeMS()
+	if err != nil {
+		return nil, err
+	}
+	if config.PairMgr.Cron != refreshCronSpec {
+		if refreshCronID > 0 {
+			core.Cron.Remove(refreshCronID)
+			refreshCronID = 0
+		}
+		CronRefreshPairs(dp)
+		log.Info("refresh pairs cron changed", zap.String("cron", config.PairMgr.Cron))
+	}
+	log.Info("config reloaded", zap.Strings("adds", adds), zap.Strings("removes", removes),
+		zap.Strings("changes", changes))
+	return map[string][]string{
+		"adds":    adds,
+		"removes": removes,
+		"changes": changes,
+	}, nil
+}
+
+/*
+ListenReloadSignal
+Reload config when receiving SIGHUP
+收到SIGHUP信号时重新加载配置
+*/
+func ListenReloadSignal() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+	go func() {
+		for range sigChan {
+			if core.ReloadConfig == nil {
+				continue
+			}
+			log.Info("receive SIGHUP, reloading config")
+			_, err := core.ReloadConfig()
+			if err != nil {
+				log.Error("reload config fail", zap.Error(err))
+			}
+		}
+	}()
+}
+
 func CronLoadMarkets() {
 	// 2小时更新一次市场行情
 	_, err := core.Cron.Add("30 3 */2 * * *", func() {
@@ -94,6 +194,12 @@ func CronKlineDelays() {
 	stuckCount := 0
 	_, err_ := core.Cron.Add("30 * * * * *", func() {
 		curMS := btime.TimeMS()
+		if !anyPairInSession(curMS) {
+			// All symbols are in trading breaks, no klines are expected
+			// 所有标的都处于休市时段，不应收到K线
+			stuckCount = 0
+			return
+		}
 		delaySecs := int((curMS - core.LastCopiedMs) / 1000)
 		if delaySecs > 120 {
 			// It should be received every minute, alert if haven't been received for more than 2 minutes
@@ -128,10 +234,16 @@ func CronKlineDelays() {
 		}
 		stuckCount = 0
 		var fails = make(map[string][]string)
-		for pair, wait := range core.PairCopiedMs {
+		for pair, wait := range core.GetPairCopiedMs() {
 			if wait[0]+wait[1]*2 > curMS {
 				continue
 			}
+			exs, err := orm.GetExSymbolCur(pair)
+			if err == nil && orm.SessionMSBetween(exs, wait[0], curMS) < wait[1]*2 {
+				// Skip non-trading sessions like lunch breaks or nights
+				// 跳过午休、夜间等非交易时段
+				continue
+			}
 			timeoutMin := strconv.Itoa(int((curMS-wait[0])/60000)) + "mins"
 			arr, _ := fails[timeoutMin]
 			fails[timeoutMin] = append(arr, pair)
@@ -146,6 +258,25 @@ func CronKlineDelays() {
 	}
 }
 
+/*
+anyPairInSession
+whether any subscribed symbol is in a trading session at curMS
+是否有任意订阅的标的在curMS处于交易时段
+*/
+func anyPairInSession(curMS int64) bool {
+	pairMs := core.GetPairCopiedMs()
+	if len(pairMs) == 0 {
+		return true
+	}
+	for pair := range pairMs {
+		exs, err := orm.GetExSymbolCur(pair)
+		if err != nil || orm.InTradeSession(exs, curMS) {
+			return true
+		}
+	}
+	return false
+}
+
 func CronKlineSummary() {
 	_, err_ := core.Cron.Add("30 1-59/10 * * * *", func() {
 		core.TfPairHitsLock.Lock()
@@ -173,6 +304,44 @@ func CronKlineSummary() {
 	}
 }
 
+/*
+CronSnapshots
+Periodically save live states (job More/TPMaxs, dry-run wallets, batch jobs) for fast restart
+定期保存实盘状态（任务More/TPMaxs、模拟钱包、批量任务），用于快速重启
+*/
+func CronSnapshots() {
+	if config.SnapshotCron == "-" {
+		return
+	}
+	_, err_ := core.Cron.Add(config.SnapshotCron, func() {
+		err := biz.SaveSnapshots()
+		if err != nil {
+			log.Error("save snapshots fail", zap.Error(err))
+		}
+	})
+	if err_ != nil {
+		log.Error("add CronSnapshots fail", zap.Error(err_))
+	}
+}
+
+/*
+CronPerfReports
+Send performance reports configured by `reports` to rpc channels
+按`reports`配置定期发送绩效报告到rpc渠道
+*/
+func CronPerfReports() {
+	for _, cfg := range config.Reports {
+		item := cfg
+		_, err_ := core.Cron.Add(item.Cron, func() {
+			biz.SendPerfReports(item)
+		})
+		if err_ != nil {
+			log.Error("add CronPerfReports fail", zap.String("name", item.Name), zap.String("cron", item.Cron),
+				zap.Error(err_))
+		}
+	}
+}
+
 func CronDumpStratOutputs() {
 	_, err_ := core.Cron.Add("31 * * * * *", func() {
 		groups := make(map[string][]string)
@@ -67,6 +67,17 @@ func (t *CryptoTrader) Init() *errs.Error {
 	}
 	err = opt.RefreshPairJobs(dp, true, true, nil)
 	lastRefreshMS = btime.TimeMS()
+	if err == nil {
+		// Restore job states, wallets and batch jobs saved before last exit
+		// 恢复上次退出前保存的任务状态、钱包和批量任务
+		err2 := biz.RestoreSnapshots()
+		if err2 != nil {
+			log.Warn("restore snapshots fail", zap.Error(err2))
+		}
+	}
+	core.ReloadConfig = func() (map[string][]string, *errs.Error) {
+		return ReloadConfig(dp)
+	}
 	// add exit callback
 	core.ExitCalls = append(core.ExitCalls, exitCleanUp)
 	strat.WsSubUnWatch = func(m map[string][]string) {
@@ -85,6 +96,7 @@ func (t *CryptoTrader) initOdMgr() *errs.Error {
 		biz.InitFakeWallets()
 		biz.InitLocalLiveOrderMgr(t.orderCB, true)
 		t.dp.OnMinKlines = biz.CallLocalLiveOdMgrsKline
+		t.dp.OnPairTrades = biz.CallLocalLiveOdMgrsTrades
 		return nil
 	}
 	biz.InitLiveOrderMgr(t.orderCB)
@@ -190,6 +202,12 @@ func (t *CryptoTrader) startJobs() {
 	CronDumpStratOutputs()
 	// 实盘中定期回测对比
 	CronBacktestInLive()
+	// 定期保存实盘状态快照，用于快速重启
+	CronSnapshots()
+	// 定时发送绩效报告到rpc渠道
+	CronPerfReports()
+	// 收到SIGHUP时热加载run_policy和pairlists
+	ListenReloadSignal()
 	if core.EnvReal {
 		// Check if the limit order submission is triggered at 15th secs of every minute
 		// 每分钟第15s检查是否触发限价单提交
@@ -212,6 +230,10 @@ func (t *CryptoTrader) markUnWarm() {
 }
 
 func exitCleanUp() {
+	err2 := biz.SaveSnapshots()
+	if err2 != nil {
+		log.Error("save snapshots fail", zap.Error(err2))
+	}
 	orm.FlushDumps()
 	orm.CloseDump()
 	err := biz.CleanUpOdMgr()
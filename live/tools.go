@@ -9,12 +9,17 @@ import (
 	"github.com/banbox/banbot/core"
 	"github.com/banbox/banbot/exg"
 	"github.com/banbox/banbot/orm/ormo"
+	"github.com/banbox/banbot/rpc"
 	"github.com/banbox/banbot/strat"
 	"github.com/banbox/banbot/utils"
 	"github.com/banbox/banexg"
+	"github.com/banbox/banexg/errs"
 	"github.com/banbox/banexg/log"
 	"go.uber.org/zap"
 	"math/rand"
+	"os"
+	"os/user"
+	"strings"
 	"time"
 )
 
@@ -46,18 +51,82 @@ func RunTradeClose(args []string) error {
 	var pairMap = utils.SplitToMap(pairStr, ",")
 	var stratMap = utils.SplitToMap(stratStr, ",")
 
-	// 初始化订单管理器
-	err = biz.SetupComsExg(&config.CmdArgs{LogLevel: "info"})
+	run := func() (interface{}, error) {
+		// 初始化订单管理器
+		err := biz.SetupComsExg(&config.CmdArgs{LogLevel: "info"})
+		if err != nil {
+			return nil, err
+		}
+
+		// 查找符合要求的订单并平仓
+		if isExg {
+			return nil, closeOrdersByPos(accMap, pairMap)
+		} else {
+			return nil, closeOrdersByLocal(accMap, pairMap, stratMap)
+		}
+	}
+	if !rpc.ConfirmEnabled() {
+		_, err_ = run()
+		return err_
+	}
+	detail := fmt.Sprintf("account: %s, pair: %s, strat: %s, exg: %v", accountStr, pairStr, stratStr, isExg)
+	return confirmByCli(rpc.ActCloseOrder, detail, run)
+}
+
+/*
+confirmByCli 命令行危险操作确认：需输入一次性确认码；配置了审批人时，还需输入审批人的api用户名和密码
+*/
+func confirmByCli(action, detail string, run func() (interface{}, error)) error {
+	requester := "cli:" + cliUser()
+	p := rpc.RequestConfirm(action, detail, requester, run)
+	code, err_ := utils.ReadInput([]string{
+		fmt.Sprintf("[%s] %s", action, detail),
+		fmt.Sprintf("input confirm code %s to continue (valid in %d secs):", p.Code, (p.ExpireMS-p.CreateMS)/1000),
+	})
+	if err_ != nil {
+		_, _ = rpc.CancelAction(p.Code, requester)
+		return err_
+	}
+	approver := requester
+	if p.NeedApprover {
+		name, err_ := utils.ReadInput([]string{"approver api username:"})
+		if err_ != nil {
+			_, _ = rpc.CancelAction(p.Code, requester)
+			return err_
+		}
+		pwd, err_ := utils.ReadInput([]string{"approver password:"})
+		if err_ != nil {
+			_, _ = rpc.CancelAction(p.Code, requester)
+			return err_
+		}
+		approver = ""
+		for _, u := range config.GetApiUsers() {
+			if u.Username == name && u.Password == pwd {
+				approver = "api:" + name
+				break
+			}
+		}
+		if approver == "" {
+			rpc.AddAudit(&rpc.AuditRecord{Event: rpc.AuditDeny, Action: action, Detail: detail, Code: p.Code,
+				User: "api:" + name, Requester: requester, Result: "invalid password"})
+			_, _ = rpc.CancelAction(p.Code, requester)
+			return errs.NewMsg(core.ErrAuthFail, "invalid approver username or password")
+		}
+	}
+	_, _, err := rpc.ConfirmAction(strings.TrimSpace(code), approver)
 	if err != nil {
+		_, _ = rpc.CancelAction(p.Code, requester)
 		return err
 	}
+	return nil
+}
 
-	// 查找符合要求的订单并平仓
-	if isExg {
-		return closeOrdersByPos(accMap, pairMap)
-	} else {
-		return closeOrdersByLocal(accMap, pairMap, stratMap)
+// cliUser 返回当前系统用户名，用于确认和审计日志
+func cliUser() string {
+	if u, err_ := user.Current(); err_ == nil && u.Username != "" {
+		return u.Username
 	}
+	return os.Getenv("USER")
 }
 
 func closeOrdersByPos(accMap map[string]bool, pairMap map[string]bool) error {
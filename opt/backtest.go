@@ -155,6 +155,9 @@ func NewBackTest(isOpt bool, outDir string) *BackTest {
 		outDir = fmt.Sprintf("%s/backtest/%s", config.GetDataDir(), hash)
 	}
 	b.OutDir = config.ParsePath(outDir)
+	if !isOpt {
+		b.klineRec = newKlineRecorder()
+	}
 	config.LoadPerfs(config.GetDataDir())
 	return b
 }
@@ -189,6 +192,9 @@ func (b *BackTest) Init() *errs.Error {
 
 func (b *BackTest) FeedKLine(bar *orm.InfoKline) {
 	curTime := btime.TimeMS()
+	if b.klineRec != nil {
+		b.klineRec.add(bar)
+	}
 	ok := b.BackTestLite.FeedKLine(bar)
 	if !bar.IsWarmUp && core.CheckWallets {
 		core.CheckWallets = false
@@ -0,0 +1,268 @@
+package opt
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banbot/orm"
+	"github.com/banbox/banbot/orm/ormo"
+	"github.com/banbox/banbot/utils"
+	"github.com/banbox/banexg/errs"
+	"github.com/banbox/banexg/log"
+	utils2 "github.com/banbox/banexg/utils"
+	"go.uber.org/zap"
+	"gonum.org/v1/gonum/stat"
+)
+
+const (
+	benchCash   = "cash"
+	benchBasket = "basket"
+)
+
+/*
+BenchMetrics
+Metrics of backtest relative to the benchmark (bt_benchmark), all computed from Plots series.
+回测相对基准(bt_benchmark)的指标，全部基于Plots序列计算
+*/
+type BenchMetrics struct {
+	Name         string  `json:"name"`         // cash/basket/symbol
+	ProfitPct    float64 `json:"profitPct"`    // Total return of benchmark 基准总收益率
+	Alpha        float64 `json:"alpha"`        // Annualized jensen alpha 年化詹森alpha
+	Beta         float64 `json:"beta"`         // 相对基准的beta
+	InfoRatio    float64 `json:"infoRatio"`    // Annualized information ratio 年化信息比率
+	CalmarRatio  float64 `json:"calmarRatio"`  // Annualized return / max drawdown 年化收益/最大回撤
+	ProfitFactor float64 `json:"profitFactor"` // Gross profit / gross loss of orders 订单总盈利/总亏损
+	ExposurePct  float64 `json:"exposurePct"`  // Percentage of time with open orders 有持仓的时间占比
+	UpCapture    float64 `json:"upCapture"`    // 上行捕获率
+	DownCapture  float64 `json:"downCapture"`  // 下行捕获率
+}
+
+func (r *BTResult) calcBenchMetrics(orders []*ormo.InOutOrder) {
+	reals := r.Plots.Real
+	if len(reals) < 2 || reals[0] <= 0 {
+		return
+	}
+	res := &BenchMetrics{Name: config.BtBenchmark}
+	if res.Name == "" {
+		res.Name = benchCash
+	}
+	var bench []float64
+	if res.Name != benchCash {
+		var err *errs.Error
+		bench, err = r.loadBenchmark(res.Name, orders)
+		if err != nil {
+			log.Warn("load benchmark fail, use cash instead", zap.String("name", res.Name), zap.Error(err))
+			res.Name = benchCash
+		}
+	}
+	if bench == nil {
+		bench = make([]float64, len(reals))
+		for i := range bench {
+			bench[i] = reals[0]
+		}
+	}
+	r.Plots.Benchmark = bench
+	res.ProfitPct = (bench[len(bench)-1]/bench[0] - 1) * 100
+	// profit factor
+	var winSum, lossSum float64
+	for _, od := range orders {
+		if od.Profit > 0 {
+			winSum += od.Profit
+		} else {
+			lossSum -= od.Profit
+		}
+	}
+	if lossSum > 0 {
+		res.ProfitFactor = winSum / lossSum
+	}
+	// exposure time
+	if len(r.Plots.OdNum) > 0 {
+		holdNum := 0
+		for _, n := range r.Plots.OdNum {
+			if n > 0 {
+				holdNum += 1
+			}
+		}
+		res.ExposurePct = float64(holdNum) * 100 / float64(len(r.Plots.OdNum))
+	}
+	years := float64(r.EndMS-r.StartMS) / float64(utils2.TFToSecs("1y")*1000)
+	if years > 0 {
+		annRet := math.Pow(reals[len(reals)-1]/reals[0], 1/years) - 1
+		if r.ShowDrawDownPct > 0 {
+			res.CalmarRatio = annRet * 100 / r.ShowDrawDownPct
+		}
+		periods := float64(len(reals)-1) / years
+		res.Alpha, res.Beta, res.InfoRatio, res.UpCapture, res.DownCapture = calcRelMetrics(
+			toReturns(reals), toReturns(bench), periods)
+	}
+	r.Bench = res
+}
+
+/*
+loadBenchmark
+Load equity curve of buy-and-hold the symbol, or equal-weight basket of traded pairs, aligned with Plots.Times.
+加载买入持有标的或交易品种等权组合的净值曲线，与Plots.Times对齐
+*/
+func (r *BTResult) loadBenchmark(name string, orders []*ormo.InOutOrder) ([]float64, *errs.Error) {
+	times := r.Plots.Times
+	if len(times) != len(r.Plots.Real) {
+		return nil, errs.NewMsg(errs.CodeRunTime, "plot times not match")
+	}
+	var pairs []string
+	if name == benchBasket {
+		pairMap := make(map[string]bool)
+		for _, od := range orders {
+			pairMap[od.Symbol] = true
+		}
+		if len(pairMap) == 0 {
+			for _, p := range core.Pairs {
+				pairMap[p] = true
+			}
+		}
+		for p := range pairMap {
+			pairs = append(pairs, p)
+		}
+	} else {
+		var err *errs.Error
+		pairs, err = config.ParsePairs(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(pairs) == 0 {
+		return nil, errs.NewMsg(errs.CodeParamRequired, "no pairs for benchmark")
+	}
+	// pick the largest timeframe not bigger than plot interval 选择不大于绘图间隔的最大周期
+	gapMS := (times[len(times)-1] - times[0]) / int64(len(times)-1)
+	tf := "1m"
+	for _, it := range []string{"1d", "4h", "1h", "15m", "5m"} {
+		if int64(utils2.TFToSecs(it)*1000) <= gapMS {
+			tf = it
+			break
+		}
+	}
+	tfMSecs := int64(utils2.TFToSecs(tf) * 1000)
+	ratios := make([]float64, len(times))
+	pairNum := 0
+	for _, pair := range pairs {
+		exs, err := orm.GetExSymbolCur(pair)
+		if err != nil {
+			return nil, err
+		}
+		_, bars, err := orm.GetOHLCV(exs, tf, times[0]-tfMSecs, times[len(times)-1]+tfMSecs, 0, false)
+		if err != nil {
+			return nil, err
+		}
+		if len(bars) == 0 {
+			log.Warn("no klines for benchmark", zap.String("pair", pair))
+			continue
+		}
+		pairNum += 1
+		base := float64(0)
+		for i, t := range times {
+			// close of the last bar finished before t 在t之前完成的最后一根bar的收盘价
+			idx := sort.Search(len(bars), func(j int) bool {
+				return bars[j].Time+tfMSecs > t
+			}) - 1
+			if idx < 0 {
+				ratios[i] += 1
+				continue
+			}
+			if base == 0 {
+				base = bars[idx].Close
+			}
+			ratios[i] += bars[idx].Close / base
+		}
+	}
+	if pairNum == 0 {
+		return nil, errs.NewMsg(errs.CodeRunTime, "no klines for benchmark %s", name)
+	}
+	baseVal := r.Plots.Real[0]
+	res := make([]float64, len(ratios))
+	for i, v := range ratios {
+		res[i] = baseVal * v / float64(pairNum)
+	}
+	return res, nil
+}
+
+func toReturns(vals []float64) []float64 {
+	res := make([]float64, 0, len(vals))
+	for i := 1; i < len(vals); i++ {
+		if vals[i-1] == 0 {
+			res = append(res, 0)
+		} else {
+			res = append(res, vals[i]/vals[i-1]-1)
+		}
+	}
+	return res
+}
+
+/*
+calcRelMetrics
+Calculate annualized alpha, beta, information ratio, up/down capture of returns relative to benchmark returns.
+periods is the number of returns in a year.
+计算收益相对基准收益的年化alpha、beta、信息比率、上行/下行捕获率。periods为一年内的收益数量
+*/
+func calcRelMetrics(rets, bench []float64, periods float64) (float64, float64, float64, float64, float64) {
+	if len(rets) < 2 || len(rets) != len(bench) {
+		return 0, 0, 0, 0, 0
+	}
+	meanR, meanB := stat.Mean(rets, nil), stat.Mean(bench, nil)
+	var beta float64
+	if varB := stat.Variance(bench, nil); varB > 0 {
+		beta = stat.Covariance(rets, bench, nil) / varB
+	}
+	alpha := (meanR - beta*meanB) * periods
+	diffs := make([]float64, len(rets))
+	for i, v := range rets {
+		diffs[i] = v - bench[i]
+	}
+	var infoRatio float64
+	if std := stat.StdDev(diffs, nil); std > 0 {
+		infoRatio = stat.Mean(diffs, nil) / std * math.Sqrt(periods)
+	}
+	var upR, upB, downR, downB float64
+	for i, b := range bench {
+		if b > 0 {
+			upR += rets[i]
+			upB += b
+		} else if b < 0 {
+			downR += rets[i]
+			downB += b
+		}
+	}
+	var upCap, downCap float64
+	if upB != 0 {
+		upCap = upR / upB
+	}
+	if downB != 0 {
+		downCap = downR / downB
+	}
+	return utils.NanInfTo(alpha, 0), utils.NanInfTo(beta, 0), utils.NanInfTo(infoRatio, 0), upCap, downCap
+}
+
+func (r *BTResult) textBenchRows() [][]string {
+	b := r.Bench
+	if b == nil {
+		return nil
+	}
+	fmtF := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	rows := [][]string{
+		{"Calmar", fmtF(b.CalmarRatio)},
+		{"Profit Factor", fmtF(b.ProfitFactor)},
+		{"Exposure Time", strconv.FormatFloat(b.ExposurePct, 'f', 1, 64) + "%"},
+		{"Benchmark", fmt.Sprintf("%s  %s%%", b.Name, strconv.FormatFloat(b.ProfitPct, 'f', 1, 64))},
+		{"Alpha/Beta", fmtF(b.Alpha) + " / " + fmtF(b.Beta)},
+		{"Information Ratio", fmtF(b.InfoRatio)},
+	}
+	if b.Name != benchCash {
+		rows = append(rows, []string{"Up/Down Capture", fmtF(b.UpCapture) + " / " + fmtF(b.DownCapture)})
+	}
+	return rows
+}
@@ -0,0 +1,24 @@
+package opt
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalcRelMetrics(t *testing.T) {
+	bench := []float64{0.01, -0.02, 0.03, -0.01, 0.02}
+	rets := make([]float64, len(bench))
+	for i, v := range bench {
+		rets[i] = v*2 + 0.001
+	}
+	alpha, beta, _, upCap, downCap := calcRelMetrics(rets, bench, 365)
+	if math.Abs(beta-2) > 1e-9 {
+		t.Errorf("beta should be 2, got %v", beta)
+	}
+	if math.Abs(alpha-0.365) > 1e-9 {
+		t.Errorf("alpha should be 0.365, got %v", alpha)
+	}
+	if upCap <= 2 || downCap >= 2 {
+		t.Errorf("bad capture: %v %v", upCap, downCap)
+	}
+}
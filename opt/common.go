@@ -397,6 +397,9 @@ var LineChartData []byte
 //go:embed barStat.html
 var BarStatChartData []byte
 
+//go:embed scatter.html
+var ScatterChartData []byte
+
 /*
 DumpChart dump a chart html with datasets. draw line chart if tplData is nil
 */
@@ -524,6 +527,38 @@ type Chart struct {
 	Datasets  []*ChartDs `json:"datasets"`
 }
 
+type ScatterChart struct {
+	Title    string       `json:"title"`
+	XTitle   string       `json:"xTitle"`
+	YTitle   string       `json:"yTitle"`
+	Datasets []*ScatterDs `json:"datasets"`
+}
+
+type ScatterDs struct {
+	Label           string          `json:"label"`
+	Data            []*ScatterPoint `json:"data"`
+	BackgroundColor string          `json:"backgroundColor,omitempty"`
+}
+
+type ScatterPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// DumpScatter generate scatter chart html 生成散点图html
+func DumpScatter(path string, g *ScatterChart) *errs.Error {
+	data, err_ := utils2.Marshal(g)
+	if err_ != nil {
+		return errs.New(errs.CodeMarshalFail, err_)
+	}
+	content := strings.Replace(string(ScatterChartData), "{'inject': 1}", string(data), 1)
+	err_ = os.WriteFile(path, []byte(content), 0644)
+	if err_ != nil {
+		return errs.New(errs.CodeIOWriteFail, err_)
+	}
+	return nil
+}
+
 type ChartDs struct {
 	Label           string    `json:"label"`
 	Data            []float64 `json:"data"`
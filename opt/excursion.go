@@ -0,0 +1,184 @@
+package opt
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/banbox/banbot/orm/ormo"
+	"github.com/banbox/banexg/log"
+	"github.com/olekukonko/tablewriter/tw"
+	"go.uber.org/zap"
+)
+
+/*
+ExcurItem
+MAE/MFE statistics of a group of orders. All rates are percentages without leverage.
+一组订单的MAE/MFE统计，所有比率均为不含杠杆的百分比
+*/
+type ExcurItem struct {
+	Title    string  `json:"title"`
+	OrderNum int     `json:"orderNum"`
+	AvgMAE   float64 `json:"avgMAE"`
+	AvgMFE   float64 `json:"avgMFE"`
+	WinMAE   float64 `json:"winMAE"`   // avg MAE of winning orders, reference for stop loss 盈利订单平均MAE，止损参考
+	LossMFE  float64 `json:"lossMFE"`  // avg MFE of losing orders, reference for take profit 亏损订单平均MFE，止盈参考
+	Capture  float64 `json:"capture"`  // avg profit/MFE of winning orders 盈利订单平均利润/MFE，止盈效率
+	LossRate float64 `json:"lossRate"` // avg loss/MAE of losing orders 亏损订单平均亏损/MAE，止损效率
+	MFEMins  float64 `json:"mfeMins"`  // avg minutes from entry to MFE 入场到MFE的平均分钟数
+}
+
+type excurSum struct {
+	num, winNum, lossNum, mfeNum int
+	mae, mfe, winMAE, lossMFE    float64
+	capture, lossRate, mfeMins   float64
+	captureNum, lossRateNum      int
+}
+
+func (s *excurSum) add(od *ormo.InOutOrder) {
+	mae, _, mfe, mfeAt := od.GetExcursions()
+	s.num += 1
+	s.mae += mae
+	s.mfe += mfe
+	if od.ProfitRate >= 0 {
+		s.winNum += 1
+		s.winMAE += mae
+		if mfe > 0 {
+			s.capture += od.ProfitRate / mfe
+			s.captureNum += 1
+		}
+	} else {
+		s.lossNum += 1
+		s.lossMFE += mfe
+		if mae > 0 {
+			s.lossRate += -od.ProfitRate / mae
+			s.lossRateNum += 1
+		}
+	}
+	if mfeAt > 0 {
+		s.mfeMins += float64(mfeAt-od.RealEnterMS()) / 60000
+		s.mfeNum += 1
+	}
+}
+
+func (s *excurSum) toItem(title string) *ExcurItem {
+	div := func(a float64, b int) float64 {
+		if b == 0 {
+			return 0
+		}
+		return a / float64(b)
+	}
+	return &ExcurItem{
+		Title:    title,
+		OrderNum: s.num,
+		AvgMAE:   div(s.mae*100, s.num),
+		AvgMFE:   div(s.mfe*100, s.num),
+		WinMAE:   div(s.winMAE*100, s.winNum),
+		LossMFE:  div(s.lossMFE*100, s.lossNum),
+		Capture:  div(s.capture, s.captureNum),
+		LossRate: div(s.lossRate, s.lossRateNum),
+		MFEMins:  div(s.mfeMins, s.mfeNum),
+	}
+}
+
+func hasExcursions(orders []*ormo.InOutOrder) bool {
+	for _, od := range orders {
+		mae, _, mfe, _ := od.GetExcursions()
+		if mae > 0 || mfe > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+groupByExcursions
+Calculate MAE/MFE statistics grouped by strategy and exit tag, with a total row at the end.
+按策略和退出标签分组计算MAE/MFE统计，最后一行为汇总
+*/
+func (r *BTResult) groupByExcursions(orders []*ormo.InOutOrder) {
+	if !hasExcursions(orders) {
+		r.ExcurGrps = nil
+		return
+	}
+	groups := make(map[string]*excurSum)
+	total := &excurSum{}
+	for _, od := range orders {
+		tag := fmt.Sprintf("%s:%s", od.Strategy, od.ExitTag)
+		sta, ok := groups[tag]
+		if !ok {
+			sta = &excurSum{}
+			groups[tag] = sta
+		}
+		sta.add(od)
+		total.add(od)
+	}
+	res := make([]*ExcurItem, 0, len(groups)+1)
+	for tag, sta := range groups {
+		res = append(res, sta.toItem(tag))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Title < res[j].Title
+	})
+	r.ExcurGrps = append(res, total.toItem("Total"))
+}
+
+func textGroupExcursions(r *BTResult) string {
+	if len(r.ExcurGrps) == 0 {
+		return ""
+	}
+	heads := []string{"Exit Tag", "Count", "Avg MAE %", "Avg MFE %", "Win MAE %", "Loss MFE %", "TP Capture",
+		"SL Loss/MAE", "To MFE(m)"}
+	fmtF := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	rows := make([][]string, 0, len(r.ExcurGrps))
+	for _, it := range r.ExcurGrps {
+		rows = append(rows, []string{it.Title, strconv.Itoa(it.OrderNum), fmtF(it.AvgMAE), fmtF(it.AvgMFE),
+			fmtF(it.WinMAE), fmtF(it.LossMFE), fmtF(it.Capture), fmtF(it.LossRate),
+			strconv.FormatFloat(it.MFEMins, 'f', 0, 64)})
+	}
+	return renderTable(heads, rows, tw.AlignCenter)
+}
+
+/*
+dumpExcursions
+Dump scatter charts of MAE/MFE against final profit rate into mae.html and mfe.html
+将MAE/MFE与最终利润率的散点图保存到mae.html和mfe.html
+*/
+func (r *BTResult) dumpExcursions(orders []*ormo.InOutOrder) {
+	if !hasExcursions(orders) {
+		return
+	}
+	var maeWin, maeLoss, mfeWin, mfeLoss []*ScatterPoint
+	for _, od := range orders {
+		mae, _, mfe, _ := od.GetExcursions()
+		pft := od.ProfitRate * 100
+		maePt := &ScatterPoint{X: mae * 100, Y: pft}
+		mfePt := &ScatterPoint{X: mfe * 100, Y: pft}
+		if od.ProfitRate >= 0 {
+			maeWin = append(maeWin, maePt)
+			mfeWin = append(mfeWin, mfePt)
+		} else {
+			maeLoss = append(maeLoss, maePt)
+			mfeLoss = append(mfeLoss, mfePt)
+		}
+	}
+	charts := map[string]*ScatterChart{
+		"mae.html": {Title: "MAE vs Profit", XTitle: "MAE %", YTitle: "Profit %", Datasets: []*ScatterDs{
+			{Label: "Win", Data: maeWin, BackgroundColor: "rgb(75 192 192)"},
+			{Label: "Loss", Data: maeLoss, BackgroundColor: "rgb(255 99 132)"},
+		}},
+		"mfe.html": {Title: "MFE vs Profit", XTitle: "MFE %", YTitle: "Profit %", Datasets: []*ScatterDs{
+			{Label: "Win", Data: mfeWin, BackgroundColor: "rgb(75 192 192)"},
+			{Label: "Loss", Data: mfeLoss, BackgroundColor: "rgb(255 99 132)"},
+		}},
+	}
+	for name, chart := range charts {
+		err := DumpScatter(filepath.Join(r.OutDir, name), chart)
+		if err != nil {
+			log.Error("dump excursion chart fail", zap.String("name", name), zap.Error(err))
+		}
+	}
+}
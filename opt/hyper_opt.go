@@ -616,7 +616,7 @@ func runBTOnce() (*BackTest, float64) {
 func runGOptuna(name string, rounds int, params []*core.Param, loop FuncOptTask) *errs.Error {
 	var sampler goptuna.Sampler
 	var options []goptuna.StudyOption
-	var seed = int64(0)
+	var seed = config.Seed
 	if name == "random" {
 		sampler = goptuna.NewRandomSampler(goptuna.RandomSamplerOptionSeed(seed))
 	} else if name == "cmaes" {
@@ -634,7 +634,7 @@ func runGOptuna(name string, rounds int, params []*core.Param, loop FuncOptTask)
 			cmaes.SamplerOptionBIPop(2))
 		options = append(options, goptuna.StudyOptionRelativeSampler(rs))
 	} else if name == "tpe" {
-		sampler = tpe.NewSampler()
+		sampler = tpe.NewSampler(tpe.SamplerOptionSeed(seed))
 	} else {
 		panic("invalid sampler")
 	}
@@ -670,14 +670,30 @@ func runGOptuna(name string, rounds int, params []*core.Param, loop FuncOptTask)
 	return nil
 }
 
+// bayesParam is a uniform param sampling from the given random source 从指定随机源采样的均匀分布参数
+type bayesParam struct {
+	bayesopt.UniformParam
+	rng *rand.Rand
+}
+
+func (p *bayesParam) Sample() float64 {
+	return p.rng.Float64()*(p.Max-p.Min) + p.Min
+}
+
 func runBayes(rounds int, params []*core.Param, loop FuncOptTask) *errs.Error {
+	// bayesopt samples from the global source by default, use a dedicated one for reproducibility
+	// bayesopt默认从全局随机源采样，使用独立随机源以便复现
+	rng := config.NewRand("bayes")
 	bysParams := make([]bayesopt.Param, 0, len(params))
 	for _, p := range params {
 		minVal, maxVal := p.OptSpace()
-		bysParams = append(bysParams, bayesopt.UniformParam{
-			Name: p.Name,
-			Min:  minVal,
-			Max:  maxVal,
+		bysParams = append(bysParams, &bayesParam{
+			UniformParam: bayesopt.UniformParam{
+				Name: p.Name,
+				Min:  minVal,
+				Max:  maxVal,
+			},
+			rng: rng,
 		})
 	}
 	options := []bayesopt.OptimizerOption{
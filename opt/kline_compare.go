@@ -0,0 +1,472 @@
+package opt
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banbot/data"
+	"github.com/banbox/banbot/exg"
+	"github.com/banbox/banbot/orm"
+	utils2 "github.com/banbox/banbot/utils"
+	"github.com/banbox/banexg"
+	"github.com/banbox/banexg/errs"
+	"github.com/banbox/banexg/log"
+	"github.com/banbox/banexg/utils"
+	"github.com/olekukonko/tablewriter/tw"
+	"go.uber.org/zap"
+)
+
+const (
+	cmpPriceTol = 1e-4 // per-bar price deviation to report 输出的单bar价格偏差阈值
+	cmpVolTol   = 0.01 // per-bar volume deviation to report 输出的单bar成交量偏差阈值
+	cmpMaxLag   = 5    // max bars to search systematic lag 搜索系统性滞后的最大bar数
+)
+
+/*
+klineSource
+A source of klines to compare:
+`db[:exchange.market]`: klines in database;
+`exg[:exchange.market]`: klines downloaded from exchange api;
+`zip:<dir>`: 1m csv files in zip packages, e.g. output of `tick to_kline`;
+`proto:<dir>`: protobuf files exported by `data export`.
+Exchange and market default to the current ones.
+用于对比的K线来源：
+`db[:exchange.market]`：数据库中的K线；
+`exg[:exchange.market]`：从交易所接口下载的K线；
+`zip:<dir>`：zip包中的1m csv文件，如`tick to_kline`的输出；
+`proto:<dir>`：`data export`导出的protobuf文件。
+交易所和市场默认使用当前的
+*/
+type klineSource struct {
+	Kind     string
+	Exchange string
+	Market   string
+	Path     string
+	zipBars  map[string][]*banexg.Kline // 1m bars by symbol for zip source
+}
+
+/*
+KlineCmpSta
+Comparison summary of a symbol and timeframe between source A and B
+品种某周期在来源A和B之间的对比汇总
+*/
+type KlineCmpSta struct {
+	Pair        string
+	TimeFrame   string
+	NumA        int
+	NumB        int
+	Matched     int
+	PriceDevAvg float64 // mean of max abs relative deviation in OHLC 各bar OHLC最大相对偏差绝对值的均值
+	PriceDevMax float64
+	MaxDevAt    int64
+	VolDevAvg   float64 // mean abs relative volume deviation 成交量相对偏差绝对值的均值
+	VolRatio    float64 // sum volume of B / A B与A的总成交量之比
+	Corr        float64 // correlation of close returns 收盘价收益率相关性
+	Lag         int     // bars of B lagging behind A with max return correlation 收益率相关性最大时B滞后A的bar数
+	LagCorr     float64
+}
+
+func parseKlineSource(text string) (*klineSource, *errs.Error) {
+	kind, arg, _ := strings.Cut(strings.TrimSpace(text), ":")
+	res := &klineSource{Kind: kind, Exchange: core.ExgName, Market: core.Market}
+	switch kind {
+	case "db", "exg":
+		if arg != "" {
+			exgName, market, ok := strings.Cut(arg, ".")
+			if !ok || exgName == "" || market == "" {
+				return nil, errs.NewMsg(errs.CodeParamInvalid, "source should be %s:exchange.market, got %s", kind, text)
+			}
+			res.Exchange, res.Market = exgName, market
+		}
+	case "zip", "proto":
+		if arg == "" {
+			return nil, errs.NewMsg(errs.CodeParamRequired, "dir is required for source %s", text)
+		}
+		res.Path = config.ParsePath(arg)
+	default:
+		return nil, errs.NewMsg(errs.CodeParamInvalid, "unknown kline source: %s, expect db/exg/zip/proto", text)
+	}
+	return res, nil
+}
+
+func (s *klineSource) Name() string {
+	if s.Path != "" {
+		return s.Kind + ":" + filepath.Base(s.Path)
+	}
+	return fmt.Sprintf("%s:%s.%s", s.Kind, s.Exchange, s.Market)
+}
+
+func (s *klineSource) load(symbol, tf string, startMS, endMS int64) ([]*banexg.Kline, *errs.Error) {
+	switch s.Kind {
+	case "db":
+		exs := orm.GetExSymbol2(s.Exchange, s.Market, symbol)
+		if exs == nil {
+			return nil, errs.NewMsg(core.ErrInvalidSymbol, "%s not found in %s", symbol, s.Name())
+		}
+		_, bars, err := orm.GetOHLCV(exs, tf, startMS, endMS, 0, false)
+		return bars, err
+	case "exg":
+		return s.fetchApi(symbol, tf, startMS, endMS)
+	case "proto":
+		return orm.ReadProtoKlines(s.Path, s.Exchange, s.Market, symbol, tf, startMS, endMS)
+	default:
+		bars, err := s.loadZip1m(symbol)
+		if err != nil {
+			return nil, err
+		}
+		res := make([]*banexg.Kline, 0, len(bars))
+		for _, b := range bars {
+			if b.Time >= startMS && b.Time < endMS {
+				res = append(res, b)
+			}
+		}
+		if tf != "1m" {
+			tfMSecs := int64(utils.TFToSecs(tf) * 1000)
+			infoBy := "sum"
+			if exs := orm.GetExSymbol2(s.Exchange, s.Market, symbol); exs != nil {
+				infoBy = exs.InfoBy()
+			}
+			res, _ = utils2.BuildOHLCV(res, tfMSecs, 0, nil, 60000, 0, infoBy)
+		}
+		return res, nil
+	}
+}
+
+func (s *klineSource) fetchApi(symbol, tf string, startMS, endMS int64) ([]*banexg.Kline, *errs.Error) {
+	exchange, err := exg.GetWith(s.Exchange, s.Market, "")
+	if err != nil {
+		return nil, err
+	}
+	_, err = orm.LoadMarkets(exchange, false)
+	if err != nil {
+		return nil, err
+	}
+	tfMSecs := int64(utils.TFToSecs(tf) * 1000)
+	endMS = min(endMS, utils.AlignTfMSecs(btime.UTCStamp(), tfMSecs))
+	var res []*banexg.Kline
+	for since := startMS; since < endMS; {
+		bars, err := exchange.FetchOHLCV(symbol, tf, since, core.KBatchSize, nil)
+		if err != nil {
+			return nil, err
+		}
+		nextMS := since
+		for _, b := range bars {
+			if b.Time >= since && b.Time < endMS {
+				res = append(res, b)
+			}
+			nextMS = max(nextMS, b.Time+tfMSecs)
+		}
+		if nextMS <= since {
+			break
+		}
+		since = nextMS
+	}
+	return res, nil
+}
+
+/*
+loadZip1m
+Load 1m bars of the symbol from zip packages, the file name in zip can be the symbol or exchange id of it.
+从zip包中加载品种的1m数据，zip中的文件名可以是品种名或其交易所ID
+*/
+func (s *klineSource) loadZip1m(symbol string) ([]*banexg.Kline, *errs.Error) {
+	if bars, ok := s.zipBars[symbol]; ok {
+		return bars, nil
+	}
+	if s.zipBars == nil {
+		s.zipBars = make(map[string][]*banexg.Kline)
+	}
+	names, err := data.FindPathNames(s.Path, ".zip")
+	if err != nil {
+		return nil, err
+	}
+	exchange, _ := exg.GetWith(s.Exchange, s.Market, "")
+	var res []*banexg.Kline
+	for _, name := range names[1:] {
+		inPath := filepath.Join(names[0], name)
+		year, _ := strconv.Atoi(strings.Split(filepath.Base(inPath), ".")[0])
+		r, err_ := zip.OpenReader(inPath)
+		if err_ != nil {
+			return nil, errs.New(errs.CodeIOReadFail, err_)
+		}
+		for _, f := range r.File {
+			cleanName := strings.Split(filepath.Base(f.Name), ".")[0]
+			if cleanName != symbol {
+				if exchange == nil {
+					continue
+				}
+				mar, err := exchange.MapMarket(cleanName, year)
+				if err != nil || mar.Symbol != symbol {
+					continue
+				}
+			}
+			bars, err := readZipBars(f)
+			if err != nil {
+				_ = r.Close()
+				return nil, err
+			}
+			res = append(res, bars...)
+		}
+		_ = r.Close()
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Time < res[j].Time
+	})
+	s.zipBars[symbol] = res
+	return res, nil
+}
+
+func readZipBars(f *zip.File) ([]*banexg.Kline, *errs.Error) {
+	reader, err_ := f.Open()
+	if err_ != nil {
+		return nil, errs.New(errs.CodeIOReadFail, err_)
+	}
+	defer reader.Close()
+	rows, err_ := csv.NewReader(reader).ReadAll()
+	if err_ != nil {
+		return nil, errs.New(errs.CodeIOReadFail, err_)
+	}
+	res := make([]*banexg.Kline, 0, len(rows))
+	for _, r := range rows {
+		if len(r) < 6 {
+			continue
+		}
+		barTime, _ := strconv.ParseInt(r[0], 10, 64)
+		if barTime == 0 {
+			continue
+		}
+		bar := &banexg.Kline{Time: barTime}
+		bar.Open, _ = strconv.ParseFloat(r[1], 64)
+		bar.High, _ = strconv.ParseFloat(r[2], 64)
+		bar.Low, _ = strconv.ParseFloat(r[3], 64)
+		bar.Close, _ = strconv.ParseFloat(r[4], 64)
+		bar.Volume, _ = strconv.ParseFloat(r[5], 64)
+		if len(r) > 6 {
+			bar.Info, _ = strconv.ParseFloat(r[6], 64)
+		}
+		res = append(res, bar)
+	}
+	return res, nil
+}
+
+/*
+CompareKlines
+Compare klines of pairs from two sources (`--src-a`, `--src-b`) in time range, write per-bar deviations and a
+summary per symbol with price/volume deviations, return correlation and systematic lag.
+对比两个来源(`--src-a`, `--src-b`)在时间范围内品种的K线，输出单bar偏差，以及每个品种的价格/成交量偏差、收益率相关性和系统性滞后汇总
+*/
+func CompareKlines(args *config.CmdArgs) *errs.Error {
+	if len(args.Pairs) == 0 {
+		return errs.NewMsg(errs.CodeParamRequired, "--pairs is required")
+	}
+	if args.SrcA == "" || args.SrcB == "" {
+		return errs.NewMsg(errs.CodeParamRequired, "--src-a and --src-b are required")
+	}
+	srcA, err := parseKlineSource(args.SrcA)
+	if err != nil {
+		return err
+	}
+	srcB, err := parseKlineSource(args.SrcB)
+	if err != nil {
+		return err
+	}
+	tfs := args.TimeFrames
+	if len(tfs) == 0 {
+		tfs = []string{"1m"}
+	}
+	outDir := args.OutPath
+	if outDir == "" {
+		outDir = filepath.Join(config.GetDataDir(), "kline_compare")
+	}
+	if err_ := utils2.EnsureDir(outDir, 0755); err_ != nil {
+		return errs.New(errs.CodeIOWriteFail, err_)
+	}
+	start, stop := config.TimeRange.StartMS, config.TimeRange.EndMS
+	log.Info("compare klines", zap.String("a", srcA.Name()), zap.String("b", srcB.Name()),
+		zap.Strings("tfs", tfs), zap.Int("pairs", len(args.Pairs)))
+	barRows := [][]string{{"pair", "timeframe", "time", "date", "open", "high", "low", "close", "volume"}}
+	var stas []*KlineCmpSta
+	for _, pair := range args.Pairs {
+		for _, tf := range tfs {
+			barsA, err := srcA.load(pair, tf, start, stop)
+			if err != nil {
+				log.Warn("load klines fail", zap.String("src", srcA.Name()), zap.String("pair", pair), zap.Error(err))
+				continue
+			}
+			barsB, err := srcB.load(pair, tf, start, stop)
+			if err != nil {
+				log.Warn("load klines fail", zap.String("src", srcB.Name()), zap.String("pair", pair), zap.Error(err))
+				continue
+			}
+			sta, devs := compareBars(barsA, barsB, int64(utils.TFToSecs(tf)*1000))
+			sta.Pair, sta.TimeFrame = pair, tf
+			stas = append(stas, sta)
+			for _, d := range devs {
+				barRows = append(barRows, append([]string{pair, tf, strconv.FormatInt(int64(d[0]), 10),
+					btime.ToDateStr(int64(d[0]), "")}, fmtDevs(d[1:])...))
+			}
+		}
+	}
+	err = utils2.WriteCsvFile(filepath.Join(outDir, "deviations.csv"), barRows, false)
+	if err != nil {
+		return err
+	}
+	heads := []string{"Pair", "TF", "NumA", "NumB", "Matched", "PriceDev%", "MaxDev%", "MaxDevAt", "VolDev%",
+		"VolRatio", "Corr", "Lag", "LagCorr"}
+	rows := make([][]string, 0, len(stas))
+	for _, s := range stas {
+		maxAt := ""
+		if s.MaxDevAt > 0 {
+			maxAt = btime.ToDateStr(s.MaxDevAt, "")
+		}
+		rows = append(rows, []string{s.Pair, s.TimeFrame, strconv.Itoa(s.NumA), strconv.Itoa(s.NumB),
+			strconv.Itoa(s.Matched), fmtFloat(s.PriceDevAvg*100, 4), fmtFloat(s.PriceDevMax*100, 4), maxAt,
+			fmtFloat(s.VolDevAvg*100, 2), fmtFloat(s.VolRatio, 4), fmtFloat(s.Corr, 4), strconv.Itoa(s.Lag),
+			fmtFloat(s.LagCorr, 4)})
+	}
+	err = utils2.WriteCsvFile(filepath.Join(outDir, "summary.csv"), append([][]string{heads}, rows...), false)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("A: %s    B: %s\n", srcA.Name(), srcB.Name())
+	fmt.Println(renderTable(heads, rows, tw.AlignRight))
+	log.Info("compare klines done", zap.String("out", outDir))
+	return nil
+}
+
+/*
+compareBars
+Compare bars of same timeframe, return the summary and per-bar deviations
+[time, open, high, low, close, volume] which exceed tolerance.
+对比相同周期的bar，返回汇总和超过阈值的单bar偏差[time, open, high, low, close, volume]
+*/
+func compareBars(barsA, barsB []*banexg.Kline, tfMSecs int64) (*KlineCmpSta, [][6]float64) {
+	sta := &KlineCmpSta{NumA: len(barsA), NumB: len(barsB)}
+	mapB := make(map[int64]*banexg.Kline, len(barsB))
+	for _, b := range barsB {
+		mapB[b.Time] = b
+	}
+	var devs [][6]float64
+	var sumVolA, sumVolB float64
+	for _, a := range barsA {
+		sumVolA += a.Volume
+		b, ok := mapB[a.Time]
+		if !ok {
+			continue
+		}
+		sta.Matched += 1
+		d := [6]float64{float64(a.Time), relDev(a.Open, b.Open), relDev(a.High, b.High), relDev(a.Low, b.Low),
+			relDev(a.Close, b.Close), relDev(a.Volume, b.Volume)}
+		priceDev := max(math.Abs(d[1]), math.Abs(d[2]), math.Abs(d[3]), math.Abs(d[4]))
+		sta.PriceDevAvg += priceDev
+		sta.VolDevAvg += math.Abs(d[5])
+		if priceDev > sta.PriceDevMax {
+			sta.PriceDevMax = priceDev
+			sta.MaxDevAt = a.Time
+		}
+		if priceDev > cmpPriceTol || math.Abs(d[5]) > cmpVolTol {
+			devs = append(devs, d)
+		}
+	}
+	for _, b := range barsB {
+		sumVolB += b.Volume
+	}
+	if sta.Matched > 0 {
+		sta.PriceDevAvg /= float64(sta.Matched)
+		sta.VolDevAvg /= float64(sta.Matched)
+	}
+	if sumVolA > 0 {
+		sta.VolRatio = sumVolB / sumVolA
+	}
+	retA, retB := barReturns(barsA, tfMSecs), barReturns(barsB, tfMSecs)
+	sta.Corr = lagCorr(retA, retB, 0, tfMSecs)
+	sta.LagCorr = sta.Corr
+	for lag := -cmpMaxLag; lag <= cmpMaxLag; lag++ {
+		corr := lagCorr(retA, retB, lag, tfMSecs)
+		if corr > sta.LagCorr+1e-9 {
+			sta.Lag, sta.LagCorr = lag, corr
+		}
+	}
+	return sta, devs
+}
+
+// relDev relative deviation of b from a 计算b相对a的偏差
+func relDev(a, b float64) float64 {
+	if a == b {
+		return 0
+	}
+	if a == 0 {
+		return 1
+	}
+	return (b - a) / math.Abs(a)
+}
+
+// barReturns log returns of close by bar time, only for consecutive bars 按bar时间的收盘价对数收益率，仅连续bar
+func barReturns(bars []*banexg.Kline, tfMSecs int64) map[int64]float64 {
+	res := make(map[int64]float64, len(bars))
+	for i := 1; i < len(bars); i++ {
+		a, b := bars[i-1], bars[i]
+		if b.Time-a.Time == tfMSecs && a.Close > 0 && b.Close > 0 {
+			res[b.Time] = math.Log(b.Close / a.Close)
+		}
+	}
+	return res
+}
+
+// lagCorr correlation of retA(t) and retB(t+lag) 计算retA(t)与retB(t+lag)的相关性
+func lagCorr(retA, retB map[int64]float64, lag int, tfMSecs int64) float64 {
+	xs := make([]float64, 0, len(retA))
+	ys := make([]float64, 0, len(retA))
+	for t, va := range retA {
+		if vb, ok := retB[t+int64(lag)*tfMSecs]; ok {
+			xs = append(xs, va)
+			ys = append(ys, vb)
+		}
+	}
+	return pearsonCorr(xs, ys)
+}
+
+func pearsonCorr(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	if n < 3 {
+		return 0
+	}
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+func fmtDevs(arr []float64) []string {
+	res := make([]string, len(arr))
+	for i, v := range arr {
+		res[i] = fmtFloat(v*100, 4) + "%"
+	}
+	return res
+}
+
+func fmtFloat(v float64, prec int) string {
+	return strconv.FormatFloat(v, 'f', prec, 64)
+}
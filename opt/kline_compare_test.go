@@ -0,0 +1,38 @@
+package opt
+
+import (
+	"math"
+	"testing"
+
+	"github.com/banbox/banexg"
+)
+
+func TestCompareBars(t *testing.T) {
+	var barsA, barsB []*banexg.Kline
+	price := 100.0
+	for i := 0; i < 200; i++ {
+		price *= 1 + 0.01*math.Sin(float64(i*i))
+		barsA = append(barsA, &banexg.Kline{Time: int64(i) * 60000, Open: price, High: price, Low: price,
+			Close: price, Volume: 10})
+	}
+	// B lags A by 2 bars 来源B滞后A两个bar
+	for i := 2; i < len(barsA); i++ {
+		a := barsA[i-2]
+		barsB = append(barsB, &banexg.Kline{Time: int64(i) * 60000, Open: a.Open, High: a.High, Low: a.Low,
+			Close: a.Close, Volume: 12})
+	}
+	sta, devs := compareBars(barsA, barsB, 60000)
+	if sta.Matched != 198 || sta.NumB != 198 {
+		t.Errorf("bad matched: %v", sta.Matched)
+	}
+	if sta.Lag != 2 || sta.LagCorr < 0.99 {
+		t.Errorf("lag should be 2, got %v %v", sta.Lag, sta.LagCorr)
+	}
+	if math.Abs(sta.VolDevAvg-0.2) > 1e-9 || len(devs) != 198 {
+		t.Errorf("bad vol dev: %v %v", sta.VolDevAvg, len(devs))
+	}
+	sta, devs = compareBars(barsA, barsA, 60000)
+	if sta.Lag != 0 || math.Abs(sta.Corr-1) > 1e-9 || sta.PriceDevMax != 0 || len(devs) != 0 {
+		t.Errorf("same bars should match: %+v", sta)
+	}
+}
@@ -0,0 +1,184 @@
+package opt
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/orm"
+	"github.com/banbox/banbot/orm/ormo"
+	"github.com/banbox/banbot/strat"
+	"github.com/banbox/banexg/errs"
+	"github.com/banbox/banexg/log"
+	utils2 "github.com/banbox/banexg/utils"
+	"go.uber.org/zap"
+)
+
+//go:embed klines.html
+var KlineChartData []byte
+
+// number of bars shown before the first order and after the last order 首个订单前和最后订单后显示的bar数量
+const klinePadBars = 100
+
+type klineOrder struct {
+	EnterAt    int64     `json:"enterAt"`
+	ExitAt     int64     `json:"exitAt"`
+	Short      bool      `json:"short"`
+	EnterTag   string    `json:"enterTag"`
+	ExitTag    string    `json:"exitTag"`
+	ProfitPct  jsonFloat `json:"profitPct"`
+	StopLoss   jsonFloat `json:"stopLoss"`
+	TakeProfit jsonFloat `json:"takeProfit"`
+}
+
+type klineLine struct {
+	Name   string         `json:"name"`
+	Sub    bool           `json:"sub"`
+	Points [][2]jsonFloat `json:"points"`
+}
+
+type klineChart struct {
+	Title  string         `json:"title"`
+	Klines [][6]jsonFloat `json:"klines"`
+	Orders []*klineOrder  `json:"orders"`
+	Lines  []*klineLine   `json:"lines"`
+}
+
+// jsonFloat is marshaled as null for NaN/Inf, which json doesn't support NaN/Inf在json中不支持，序列化为null
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return []byte("null"), nil
+	}
+	return strconv.AppendFloat(nil, v, 'f', -1, 64), nil
+}
+
+/*
+dumpKlineHtmls
+Dump candlestick html with order markers, stop loss/take profit levels and indicators from StratJob.Plot for
+pairs into the `klines` dir. Controlled by bt_kline_html: -1 for all pairs, N for top N pairs by order count.
+为品种输出带订单标记、止损止盈价位和StratJob.Plot指标的K线html到`klines`目录。
+由bt_kline_html控制：-1全部品种，N为订单数最多的N个品种
+*/
+func (r *BTResult) dumpKlineHtmls(orders []*ormo.InOutOrder) {
+	if config.BtKlineHtml == 0 || len(orders) == 0 {
+		return
+	}
+	pairOrders := make(map[string][]*ormo.InOutOrder)
+	for _, od := range orders {
+		pairOrders[od.Symbol] = append(pairOrders[od.Symbol], od)
+	}
+	pairs := utils2.KeysOfMap(pairOrders)
+	sort.Slice(pairs, func(i, j int) bool {
+		a, b := len(pairOrders[pairs[i]]), len(pairOrders[pairs[j]])
+		if a != b {
+			return a > b
+		}
+		return pairs[i] < pairs[j]
+	})
+	if config.BtKlineHtml > 0 && len(pairs) > config.BtKlineHtml {
+		pairs = pairs[:config.BtKlineHtml]
+	}
+	outDir := filepath.Join(r.OutDir, "klines")
+	if err_ := os.MkdirAll(outDir, 0755); err_ != nil {
+		log.Error("create klines dir fail", zap.Error(err_))
+		return
+	}
+	for _, pair := range pairs {
+		err := dumpPairKlineHtml(outDir, pair, pairOrders[pair])
+		if err != nil {
+			log.Warn("dump kline html fail", zap.String("pair", pair), zap.Error(err))
+		}
+	}
+}
+
+func dumpPairKlineHtml(outDir, pair string, orders []*ormo.InOutOrder) *errs.Error {
+	// use the most used timeframe of orders 使用订单中最常用的周期
+	tfNums := make(map[string]int)
+	tf := ""
+	for _, od := range orders {
+		tfNums[od.Timeframe] += 1
+		if tf == "" || tfNums[od.Timeframe] > tfNums[tf] {
+			tf = od.Timeframe
+		}
+	}
+	tfMSecs := int64(utils2.TFToSecs(tf) * 1000)
+	startMS, endMS := orders[0].RealEnterMS(), int64(0)
+	for _, od := range orders {
+		startMS = min(startMS, od.RealEnterMS())
+		endMS = max(endMS, od.RealExitMS(), od.RealEnterMS())
+	}
+	exs, err := orm.GetExSymbolCur(pair)
+	if err != nil {
+		return err
+	}
+	_, bars, err := orm.GetOHLCV(exs, tf, startMS-tfMSecs*klinePadBars, endMS+tfMSecs*klinePadBars, 0, false)
+	if err != nil {
+		return err
+	}
+	if len(bars) == 0 {
+		return errs.NewMsg(errs.CodeRunTime, "no klines for %s %s", pair, tf)
+	}
+	res := &klineChart{
+		Title:  fmt.Sprintf("%s %s", pair, tf),
+		Klines: make([][6]jsonFloat, 0, len(bars)),
+		Lines:  make([]*klineLine, 0),
+	}
+	for _, b := range bars {
+		res.Klines = append(res.Klines, [6]jsonFloat{jsonFloat(b.Time), jsonFloat(b.Open), jsonFloat(b.High),
+			jsonFloat(b.Low), jsonFloat(b.Close), jsonFloat(b.Volume)})
+	}
+	for _, l := range strat.GetPlotLines(pair, tf) {
+		line := &klineLine{Name: l.Name, Sub: l.Sub, Points: make([][2]jsonFloat, 0, len(l.Points))}
+		for _, p := range l.Points {
+			line.Points = append(line.Points, [2]jsonFloat{jsonFloat(p[0]), jsonFloat(p[1])})
+		}
+		res.Lines = append(res.Lines, line)
+	}
+	// markers should be placed at the time of existing bars 标记需放在已有bar的时间上
+	barTime := func(timeMS int64) int64 {
+		if timeMS == 0 {
+			return 0
+		}
+		idx := sort.Search(len(bars), func(i int) bool {
+			return bars[i].Time > timeMS
+		}) - 1
+		return bars[max(idx, 0)].Time
+	}
+	for _, od := range orders {
+		item := &klineOrder{
+			EnterAt:   barTime(od.RealEnterMS()),
+			ExitAt:    barTime(od.RealExitMS()),
+			Short:     od.Short,
+			EnterTag:  od.EnterTag,
+			ExitTag:   od.ExitTag,
+			ProfitPct: jsonFloat(od.ProfitRate * 100),
+		}
+		if sl := od.GetStopLoss(); sl != nil && sl.ExitTrigger != nil {
+			item.StopLoss = jsonFloat(sl.Price)
+		}
+		if tp := od.GetTakeProfit(); tp != nil && tp.ExitTrigger != nil {
+			item.TakeProfit = jsonFloat(tp.Price)
+		}
+		res.Orders = append(res.Orders, item)
+	}
+	data, err_ := utils2.Marshal(res)
+	if err_ != nil {
+		return errs.New(errs.CodeMarshalFail, err_)
+	}
+	content := strings.Replace(string(KlineChartData), "{'inject': 1}", string(data), 1)
+	name := strings.NewReplacer("/", "_", ":", "_").Replace(pair) + ".html"
+	err_ = os.WriteFile(filepath.Join(outDir, name), []byte(content), 0644)
+	if err_ != nil {
+		return errs.New(errs.CodeIOWriteFail, err_)
+	}
+	return nil
+}
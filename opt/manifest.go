@@ -0,0 +1,254 @@
+package opt
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banbot/orm"
+	"github.com/banbox/banbot/strat"
+	"github.com/banbox/banbot/utils"
+	"github.com/banbox/banexg/errs"
+	"github.com/banbox/banexg/log"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/sasha-s/go-deadlock"
+	"go.uber.org/zap"
+)
+
+const manifestName = "manifest.json"
+
+/*
+RunManifest
+Everything needed to reproduce and verify a backtest: seed, versions, klines consumed and key results.
+复现和校验回测所需的全部信息：种子、版本、使用的K线和关键结果
+*/
+type RunManifest struct {
+	Seed       int64           `json:"seed"`
+	Version    string          `json:"version"`
+	ConfigHash string          `json:"config_hash"`
+	Strats     map[string]int  `json:"strats"` // strategy versions 策略版本
+	StartMS    int64           `json:"start_ms"`
+	EndMS      int64           `json:"end_ms"`
+	Klines     []*KlineUsage   `json:"klines"`
+	Result     *ManifestResult `json:"result"`
+}
+
+type ManifestResult struct {
+	OrderNum       int     `json:"order_num"`
+	BarNum         int     `json:"bar_num"`
+	TotProfit      float64 `json:"tot_profit"`
+	TotFee         float64 `json:"tot_fee"`
+	FinBalance     float64 `json:"fin_balance"`
+	MaxDrawDownPct float64 `json:"max_drawdown_pct"`
+	WinRatePct     float64 `json:"win_rate_pct"`
+	SharpeRatio    float64 `json:"sharpe_ratio"`
+	OrdersHash     string  `json:"orders_hash"` // hash of orders.csv orders.csv的哈希
+}
+
+/*
+KlineUsage
+Summary of klines of a symbol and timeframe fed to strategies (warm up included). Hash is the sum of per-bar
+hashes, so it's independent of feeding order but changes when any bar is different.
+喂给策略的某品种某周期K线汇总(包含预热)。Hash为每个bar哈希之和，与顺序无关，但任一bar不同时会变化
+*/
+type KlineUsage struct {
+	Symbol    string `json:"symbol"`
+	TimeFrame string `json:"timeframe"`
+	StartMS   int64  `json:"start_ms"`
+	EndMS     int64  `json:"end_ms"`
+	BarNum    int    `json:"bar_num"`
+	Hash      string `json:"hash"`
+	hashSum   uint64
+}
+
+type klineRecorder struct {
+	items map[string]*KlineUsage
+	buf   []byte
+	lock  deadlock.Mutex
+}
+
+func newKlineRecorder() *klineRecorder {
+	return &klineRecorder{items: make(map[string]*KlineUsage), buf: make([]byte, 8)}
+}
+
+func (r *klineRecorder) add(bar *orm.InfoKline) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	key := bar.Symbol + "_" + bar.TimeFrame
+	it, ok := r.items[key]
+	if !ok {
+		it = &KlineUsage{Symbol: bar.Symbol, TimeFrame: bar.TimeFrame, StartMS: bar.Time}
+		r.items[key] = it
+	}
+	it.StartMS = min(it.StartMS, bar.Time)
+	it.EndMS = max(it.EndMS, bar.Time)
+	it.BarNum += 1
+	h := fnv.New64a()
+	for _, v := range []uint64{uint64(bar.Time), math.Float64bits(bar.Open), math.Float64bits(bar.High),
+		math.Float64bits(bar.Low), math.Float64bits(bar.Close), math.Float64bits(bar.Volume),
+		math.Float64bits(bar.Info)} {
+		binary.LittleEndian.PutUint64(r.buf, v)
+		_, _ = h.Write(r.buf)
+	}
+	it.hashSum += h.Sum64()
+}
+
+func (r *klineRecorder) list() []*KlineUsage {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	res := make([]*KlineUsage, 0, len(r.items))
+	for _, it := range r.items {
+		cp := *it
+		cp.Hash = fmt.Sprintf("%016x", it.hashSum)
+		res = append(res, &cp)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Symbol != res[j].Symbol {
+			return res[i].Symbol < res[j].Symbol
+		}
+		return res[i].TimeFrame < res[j].TimeFrame
+	})
+	return res
+}
+
+func (r *BTResult) dumpManifest() {
+	if r.klineRec == nil {
+		return
+	}
+	cfgHash, err := config.Data.HashCode()
+	if err != nil {
+		log.Warn("hash config fail", zap.Error(err))
+	}
+	res := &RunManifest{
+		Seed:       config.Seed,
+		Version:    core.Version,
+		ConfigHash: cfgHash,
+		Strats:     strat.Versions,
+		StartMS:    config.TimeRange.StartMS,
+		EndMS:      config.TimeRange.EndMS,
+		Klines:     r.klineRec.list(),
+		Result: &ManifestResult{
+			OrderNum:       r.OrderNum,
+			BarNum:         r.BarNum,
+			TotProfit:      r.TotProfit,
+			TotFee:         r.TotFee,
+			FinBalance:     r.FinBalance,
+			MaxDrawDownPct: r.MaxDrawDownPct,
+			WinRatePct:     r.WinRatePct,
+			SharpeRatio:    r.SharpeRatio,
+		},
+	}
+	csvData, err_ := os.ReadFile(filepath.Join(r.OutDir, "orders.csv"))
+	if err_ == nil {
+		res.Result.OrdersHash = utils.MD5(csvData)
+	}
+	data, err_ := json.MarshalIndent(res, "", "  ")
+	if err_ == nil {
+		err_ = os.WriteFile(filepath.Join(r.OutDir, manifestName), data, 0644)
+	}
+	if err_ != nil {
+		log.Error("dump manifest fail", zap.Error(err_))
+	}
+}
+
+/*
+LoadManifest
+Load the run manifest from backtest output dir
+从回测输出目录加载运行清单
+*/
+func LoadManifest(outDir string) (*RunManifest, *errs.Error) {
+	data, err_ := os.ReadFile(filepath.Join(outDir, manifestName))
+	if err_ != nil {
+		return nil, errs.New(errs.CodeIOReadFail, err_)
+	}
+	var res RunManifest
+	err_ = json.Unmarshal(data, &res)
+	if err_ != nil {
+		return nil, errs.New(errs.CodeUnmarshalFail, err_)
+	}
+	return &res, nil
+}
+
+/*
+DiffManifests
+Compare two manifests, return rows of [item, old, new] which are different.
+对比两个运行清单，返回不同项的[item, old, new]行
+*/
+func DiffManifests(old, cur *RunManifest) [][]string {
+	var rows [][]string
+	addDiff := func(name, a, b string) {
+		if a != b {
+			rows = append(rows, []string{name, a, b})
+		}
+	}
+	fmtF := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 6, 64)
+	}
+	addDiff("seed", strconv.FormatInt(old.Seed, 10), strconv.FormatInt(cur.Seed, 10))
+	addDiff("version", old.Version, cur.Version)
+	addDiff("time_range", fmt.Sprintf("%d-%d", old.StartMS, old.EndMS), fmt.Sprintf("%d-%d", cur.StartMS, cur.EndMS))
+	for name, v := range old.Strats {
+		addDiff("strat:"+name, strconv.Itoa(v), strconv.Itoa(cur.Strats[name]))
+	}
+	for name, v := range cur.Strats {
+		if _, ok := old.Strats[name]; !ok {
+			addDiff("strat:"+name, "", strconv.Itoa(v))
+		}
+	}
+	curKlines := make(map[string]*KlineUsage, len(cur.Klines))
+	for _, k := range cur.Klines {
+		curKlines[k.Symbol+"_"+k.TimeFrame] = k
+	}
+	kStr := func(k *KlineUsage) string {
+		if k == nil {
+			return ""
+		}
+		return fmt.Sprintf("%d-%d n=%d %s", k.StartMS, k.EndMS, k.BarNum, k.Hash)
+	}
+	for _, k := range old.Klines {
+		key := k.Symbol + "_" + k.TimeFrame
+		addDiff("kline:"+key, kStr(k), kStr(curKlines[key]))
+		delete(curKlines, key)
+	}
+	for key, k := range curKlines {
+		addDiff("kline:"+key, "", kStr(k))
+	}
+	a, b := old.Result, cur.Result
+	if a == nil || b == nil {
+		addDiff("result", fmt.Sprint(a != nil), fmt.Sprint(b != nil))
+		return rows
+	}
+	addDiff("order_num", strconv.Itoa(a.OrderNum), strconv.Itoa(b.OrderNum))
+	addDiff("bar_num", strconv.Itoa(a.BarNum), strconv.Itoa(b.BarNum))
+	addDiff("tot_profit", fmtF(a.TotProfit), fmtF(b.TotProfit))
+	addDiff("tot_fee", fmtF(a.TotFee), fmtF(b.TotFee))
+	addDiff("fin_balance", fmtF(a.FinBalance), fmtF(b.FinBalance))
+	addDiff("max_drawdown_pct", fmtF(a.MaxDrawDownPct), fmtF(b.MaxDrawDownPct))
+	addDiff("win_rate_pct", fmtF(a.WinRatePct), fmtF(b.WinRatePct))
+	addDiff("sharpe_ratio", fmtF(a.SharpeRatio), fmtF(b.SharpeRatio))
+	addDiff("orders_hash", a.OrdersHash, b.OrdersHash)
+	return rows
+}
+
+/*
+PrintManifestDiff
+Print differences between manifests as a table, return the number of differences.
+以表格打印运行清单差异，返回差异数量
+*/
+func PrintManifestDiff(old, cur *RunManifest) int {
+	rows := DiffManifests(old, cur)
+	if len(rows) == 0 {
+		fmt.Println("backtest reproduced, no difference found")
+		return 0
+	}
+	fmt.Println(renderTable([]string{"Item", "Manifest", "Rerun"}, rows, tw.AlignLeft))
+	return len(rows)
+}
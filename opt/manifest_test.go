@@ -0,0 +1,35 @@
+package opt
+
+import (
+	"testing"
+
+	"github.com/banbox/banbot/orm"
+	"github.com/banbox/banexg"
+)
+
+func TestKlineRecorder(t *testing.T) {
+	bars := make([]*orm.InfoKline, 0, 3)
+	for i := 0; i < 3; i++ {
+		bars = append(bars, &orm.InfoKline{PairTFKline: &banexg.PairTFKline{Symbol: "BTC/USDT", TimeFrame: "1h",
+			Kline: banexg.Kline{Time: int64(i) * 3600000, Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 10}}})
+	}
+	recA, recB := newKlineRecorder(), newKlineRecorder()
+	for i := range bars {
+		recA.add(bars[i])
+		recB.add(bars[len(bars)-1-i])
+	}
+	old := &RunManifest{Klines: recA.list(), Result: &ManifestResult{OrderNum: 3}}
+	cur := &RunManifest{Klines: recB.list(), Result: &ManifestResult{OrderNum: 3}}
+	if rows := DiffManifests(old, cur); len(rows) != 0 {
+		t.Errorf("order of bars should not matter: %v", rows)
+	}
+	bars[1].Close = 1.6
+	recC := newKlineRecorder()
+	for _, b := range bars {
+		recC.add(b)
+	}
+	cur = &RunManifest{Klines: recC.list(), Result: &ManifestResult{OrderNum: 4}}
+	if rows := DiffManifests(old, cur); len(rows) != 2 {
+		t.Errorf("expect kline and order_num diff, got: %v", rows)
+	}
+}
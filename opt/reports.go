@@ -64,6 +64,7 @@ type BTResult struct {
 	EnterGrps       []*RowItem     `json:"enterGrps"`
 	ExitGrps        []*RowItem     `json:"exitGrps"`
 	ProfitGrps      []*RowItem     `json:"profitGrps"`
+	ExcurGrps       []*ExcurItem   `json:"excurGrps"`
 	TotProfit       float64        `json:"totProfit"`
 	TotCost         float64        `json:"totCost"`
 	TotFee          float64        `json:"totFee"`
@@ -75,6 +76,8 @@ type BTResult struct {
 	SharpeRatio     float64        `json:"sharpeRatio"`
 	SortinoRatio    float64        `json:"sortinoRatio"`
 	CalcDiff        float64        `json:"calcDiff"`
+	Bench           *BenchMetrics  `json:"bench"` // metrics relative to benchmark 相对基准的指标
+	klineRec        *klineRecorder // klines fed to strategies, for manifest 喂给策略的K线，用于运行清单
 }
 
 type PlotData struct {
@@ -86,6 +89,8 @@ type PlotData struct {
 	Profit        []float64 `json:"profit"`
 	UnrealizedPOL []float64 `json:"unrealizedPOL"`
 	WithDraw      []float64 `json:"withDraw"`
+	Benchmark     []float64 `json:"benchmark"` // equity of benchmark, same length as Real 基准净值，与Real长度相同
+	Times         []int64   `json:"times"`     // 13-digit timestamps of each point 每个点的13位时间戳
 	tmpOdNum      int
 }
 
@@ -146,6 +151,7 @@ func (r *BTResult) cmdReports(orders []*ormo.InOutOrder) string {
 			{Title: " Profit Ranges ", Handle: textGroupProfitRanges},
 			{Title: " Enter Tag ", Handle: textGroupEntTags},
 			{Title: " Exit Tag ", Handle: textGroupExitTags},
+			{Title: " MAE/MFE ", Handle: textGroupExcursions},
 		}
 		for _, item := range items {
 			tblText = item.Handle(r)
@@ -179,12 +185,18 @@ func (r *BTResult) dumpBtFiles() {
 
 	r.dumpConfig()
 
+	r.dumpManifest()
+
 	r.dumpStrategy()
 
 	r.dumpStratOutputs()
 
 	r.DumpCharts()
 
+	r.dumpExcursions(ormo.HistODs)
+
+	r.dumpKlineHtmls(ormo.HistODs)
+
 	r.dumpDetail("")
 }
 
@@ -229,6 +241,7 @@ func (r *BTResult) Collect() {
 		r.groupByProfits(orders)
 		r.groupByEnters(orders)
 		r.groupByExits(orders)
+		r.groupByExcursions(orders)
 		labels, dsList, err := CalcGroupCumProfits(orders, func(o *ormo.InOutOrder) string {
 			return fmt.Sprintf("%v:%v", o.Strategy, o.EnterTag)
 		}, ShowNum)
@@ -249,6 +262,7 @@ func (r *BTResult) Collect() {
 	} else {
 		r.SharpeRatio, r.SortinoRatio = sharpe, sortino
 	}
+	r.calcBenchMetrics(orders)
 	r.CalcDiff = math.Abs((r.FinBalance-r.TotProfit)/r.TotalInvest - 1)
 }
 
@@ -292,6 +306,7 @@ func (r *BTResult) textMetrics(orders []*ormo.InOutOrder) string {
 		{"Win Rate", strconv.FormatFloat(r.WinRatePct, 'f', 1, 64) + "%"},
 		{"Sharpe/Sortino", sharpeStr + " / " + sortinoStr},
 	}
+	rows2 = append(rows2, r.textBenchRows()...)
 	if len(orders) > 0 {
 		worstVal := strconv.FormatFloat(orders[0].Profit, 'f', 1, 64)
 		worstPct := strconv.FormatFloat(orders[0].ProfitRate*100, 'f', 1, 64)
@@ -703,7 +718,7 @@ func DumpOrdersCSV(orders []*ormo.InOutOrder, outPath string) error {
 	defer writer.Flush()
 	heads := []string{"sid", "symbol", "timeframe", "direction", "leverage", "entAt", "entTag", "entPrice",
 		"entAmount", "entCost", "entFee", "exitAt", "exitTag", "exitPrice", "exitAmount", "exitGot",
-		"exitFee", "maxPftRate", "maxDrawDown", "profitRate", "profit", "strategy"}
+		"exitFee", "maxPftRate", "maxDrawDown", "profitRate", "profit", "strategy", "mae", "maeAt", "mfe", "mfeAt"}
 	if err_ = writer.Write(heads); err_ != nil {
 		return err_
 	}
@@ -733,6 +748,15 @@ func DumpOrdersCSV(orders []*ormo.InOutOrder, outPath string) error {
 		row[19] = strconv.FormatFloat(od.ProfitRate, 'f', 4, 64)
 		row[20] = strconv.FormatFloat(od.Profit, 'f', 8, 64)
 		row[21] = od.Strategy
+		mae, maeAt, mfe, mfeAt := od.GetExcursions()
+		row[22] = strconv.FormatFloat(mae, 'f', 4, 64)
+		row[24] = strconv.FormatFloat(mfe, 'f', 4, 64)
+		if maeAt > 0 {
+			row[23] = btime.ToDateStrLoc(maeAt, "")
+		}
+		if mfeAt > 0 {
+			row[25] = btime.ToDateStrLoc(mfeAt, "")
+		}
 		if err_ = writer.Write(row); err_ != nil {
 			return err_
 		}
@@ -832,7 +856,7 @@ func (r *BTResult) DumpCharts() {
 	title := "Real-time Assets/Balances/Unrealized P&L/Withdrawals/Concurrent Orders"
 	tplPath := fmt.Sprintf("%s/lines.html", config.GetDataDir())
 	tplData, _ := os.ReadFile(tplPath)
-	err := DumpChart(outPath, title, r.Plots.Labels, 5, tplData, []*ChartDs{
+	dsList := []*ChartDs{
 		{Label: "Real", Data: r.Plots.Real},
 		{Label: "Available", Data: r.Plots.Available},
 		{Label: "Profit", Data: r.Plots.Profit, Hidden: true},
@@ -840,7 +864,12 @@ func (r *BTResult) DumpCharts() {
 		{Label: "Withdraw", Data: r.Plots.WithDraw, Hidden: true},
 		{Label: "OrderNum", Data: odNum, YAxisID: "yRight", Hidden: true},
 		{Label: "JobNum", Data: jobNum, YAxisID: "yRight", Hidden: true},
-	})
+	}
+	if r.Bench != nil && len(r.Plots.Benchmark) == len(r.Plots.Real) {
+		// overlay equity curve of benchmark 叠加基准净值曲线
+		dsList = append(dsList, &ChartDs{Label: "Benchmark", Data: r.Plots.Benchmark, Hidden: r.Bench.Name == benchCash})
+	}
+	err := DumpChart(outPath, title, r.Plots.Labels, 5, tplData, dsList)
 	if err != nil {
 		log.Error("save assets.html fail", zap.Error(err))
 	}
@@ -934,6 +963,7 @@ func (r *BTResult) logState(startMS, timeMS int64, odNum int) {
 			Available:     make([]float64, 0, newNum),
 			UnrealizedPOL: make([]float64, 0, newNum),
 			WithDraw:      make([]float64, 0, newNum),
+			Times:         make([]int64, 0, newNum),
 		}
 		for i := 0; i < oldNum; i += splStep {
 			plots.Labels = append(plots.Labels, r.Plots.Labels[i])
@@ -944,6 +974,9 @@ func (r *BTResult) logState(startMS, timeMS int64, odNum int) {
 			plots.Profit = append(plots.Profit, r.Plots.Profit[i])
 			plots.UnrealizedPOL = append(plots.UnrealizedPOL, r.Plots.UnrealizedPOL[i])
 			plots.WithDraw = append(plots.WithDraw, r.Plots.WithDraw[i])
+			if i < len(r.Plots.Times) {
+				plots.Times = append(plots.Times, r.Plots.Times[i])
+			}
 		}
 		r.Plots = plots
 		return
@@ -982,6 +1015,7 @@ func (r *BTResult) logPlot(wallets *biz.BanWallets, timeMS int64, odNum int, tot
 	r.Plots.Profit = append(r.Plots.Profit, r.donePftLegal)
 	r.Plots.UnrealizedPOL = append(r.Plots.UnrealizedPOL, profitLegal)
 	r.Plots.WithDraw = append(r.Plots.WithDraw, drawLegal)
+	r.Plots.Times = append(r.Plots.Times, timeMS)
 }
 
 // CalcMeasuresByReal real & rangeSecs are required, tf=1d, factor=365, riskFree=0
@@ -32,12 +32,16 @@ var (
 	pool       *pgxpool.Pool
 	dbPathMap  = make(map[string]string)
 	dbPathInit = make(map[string]bool)
+	dbPathMigr = make(map[string]bool)
 	dbPathLock = deadlock.Mutex{}
 )
 
 //go:embed sql/trade_schema.sql
 var ddlTrade string
 
+//go:embed sql/trade_migrations.sql
+var ddlTradeMigrations string
+
 //go:embed sql/ui_schema.sql
 var ddlUi string
 
@@ -122,6 +126,10 @@ func Setup() *errs.Error {
 		return err2
 	}
 	defer conn.Release()
+	err2 = sess.LoadSymbolTags()
+	if err2 != nil {
+		return err2
+	}
 	if exg.Default != nil {
 		_, err2 = LoadMarkets(exg.Default, false)
 		if err2 != nil {
@@ -249,6 +257,12 @@ func DbLite(src string, path string, write bool, timeoutMs int64) (*sql.DB, *err
 		}
 		dbPathInit[path] = true
 	}
+	if _, ok := dbPathMigr[path]; !ok && write && src == DbTrades {
+		if _, err_ = db.Exec(ddlTradeMigrations); err_ != nil {
+			return nil, errs.New(core.ErrDbExecFail, err_)
+		}
+		dbPathMigr[path] = true
+	}
 	return db, nil
 }
 
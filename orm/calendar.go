@@ -0,0 +1,300 @@
+package orm
+
+import (
+	"context"
+	"sort"
+
+	"github.com/banbox/banbot/exg"
+	"github.com/banbox/banbot/utils"
+	"github.com/banbox/banexg"
+	"github.com/banbox/banexg/errs"
+	utils2 "github.com/banbox/banexg/utils"
+	"github.com/sasha-s/go-deadlock"
+)
+
+/*
+TradeSession
+A continuous trading range of a symbol. Day is the start of the trading day (from calendars) it belongs to.
+Sessions starting after the day close (night sessions of china futures) belong to the next trading day,
+so friday night belongs to monday.
+标的的一个连续可交易时间段。Day是所属交易日的开始时间（来自calendars）。
+在日盘收盘后开始的时段（中国期货夜盘）属于下一交易日，周五夜盘属于周一
+*/
+type TradeSession struct {
+	Day   int64
+	Start int64
+	Stop  int64
+}
+
+type sessWindow struct {
+	start int64
+	stop  int64
+	items []*TradeSession
+}
+
+var (
+	sessCache = make(map[int32]*sessWindow)
+	sessLock  deadlock.Mutex
+)
+
+const (
+	sessLoadBefore = int64(10 * 86400000)
+	sessLoadAfter  = int64(30 * 86400000)
+)
+
+/*
+IsFullTime
+Whether the exchange of the symbol can be traded 24 hours a day, 365 days a year
+标的所属交易所是否全年无休24小时可交易
+*/
+func IsFullTime(exchange banexg.BanExchange) bool {
+	exInfo := exchange.Info()
+	return exInfo.FullDay && exInfo.NoHoliday
+}
+
+/*
+GetExSessions
+Retrieve all trading sessions for the specified symbol within [start, stop). Return nil for 24/7 exchanges.
+获取指定标的在[start, stop)内所有的交易时段，对于全年无休的交易所返回nil
+*/
+func (q *Queries) GetExSessions(exchange banexg.BanExchange, exs *ExSymbol, start, stop int64) ([]*TradeSession, *errs.Error) {
+	if IsFullTime(exchange) {
+		return nil, nil
+	}
+	mar, err := exchange.GetMarket(exs.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	times := mar.GetTradeTimes()
+	if len(times) == 0 {
+		times = [][2]int64{{0, 24 * 60 * 60000}}
+	}
+	// Sessions may start on the previous natural day (night session), and night sessions need the next
+	// trading day which may be after weekend or holidays, extend range to include them
+	// 交易时段可能开始于前一自然日（夜盘），夜盘需要下一交易日（可能在周末或节假日后），扩展查询范围以包含
+	dayMSecs := int64(utils2.TFToSecs("1d") * 1000)
+	dtList, err := q.GetCalendars(mar.ExgReal, start-dayMSecs, stop+dayMSecs*15)
+	if err != nil {
+		return nil, err
+	}
+	var dayClose int64
+	for _, rg := range mar.DayTimes {
+		dayClose = max(dayClose, rg[1])
+	}
+	return buildSessions(dtList, times, dayClose, start, stop), nil
+}
+
+/*
+buildSessions
+Build trading sessions within [start, stop) from trading days and trade times. Sessions starting at or after
+dayClose belong to the next trading day, they are dropped when the next trading day is unknown.
+dayClose is 0 when there are no night sessions.
+根据交易日和交易时间构建[start, stop)内的交易时段。在dayClose及之后开始的时段属于下一交易日，下一交易日未知时丢弃。
+无夜盘时dayClose为0
+*/
+func buildSessions(dtList [][2]int64, times [][2]int64, dayClose, start, stop int64) []*TradeSession {
+	res := make([]*TradeSession, 0, len(dtList)*len(times))
+	for i, dt := range dtList {
+		for _, rg := range times {
+			sess := &TradeSession{Day: dt[0], Start: dt[0] + rg[0], Stop: dt[0] + rg[1]}
+			if sess.Stop <= start || sess.Start >= stop {
+				continue
+			}
+			if dayClose > 0 && rg[0] >= dayClose {
+				if i+1 >= len(dtList) {
+					continue
+				}
+				sess.Day = dtList[i+1][0]
+			}
+			res = append(res, sess)
+		}
+	}
+	return res
+}
+
+/*
+getSessions
+Return cached trading sessions covering timeMS, and whether the symbol is traded all the time.
+返回覆盖timeMS的已缓存交易时段，以及是否全天候交易
+*/
+func getSessions(exs *ExSymbol, timeMS int64) ([]*TradeSession, bool, *errs.Error) {
+	exchange, err := exg.GetWith(exs.Exchange, exs.Market, "")
+	if err != nil {
+		return nil, false, err
+	}
+	if IsFullTime(exchange) {
+		return nil, true, nil
+	}
+	sessLock.Lock()
+	win, ok := sessCache[exs.ID]
+	sessLock.Unlock()
+	if ok && win.start <= timeMS && timeMS < win.stop {
+		return win.items, false, nil
+	}
+	start, stop := timeMS-sessLoadBefore, timeMS+sessLoadAfter
+	sess, conn, err := Conn(context.Background())
+	if err != nil {
+		return nil, false, err
+	}
+	items, err := sess.GetExSessions(exchange, exs, start, stop)
+	conn.Release()
+	if err != nil {
+		return nil, false, err
+	}
+	sessLock.Lock()
+	sessCache[exs.ID] = &sessWindow{start: start, stop: stop, items: items}
+	sessLock.Unlock()
+	return items, false, nil
+}
+
+/*
+GetSessionAt
+Return the trading session containing timeMS; nil if timeMS is outside sessions. The second value is true for 24/7 symbols.
+返回包含timeMS的交易时段，不在交易时段返回nil；第二个返回值为true表示全天候交易
+*/
+func GetSessionAt(exs *ExSymbol, timeMS int64) (*TradeSession, bool, *errs.Error) {
+	items, always, err := getSessions(exs, timeMS)
+	if err != nil || always {
+		return nil, always, err
+	}
+	idx := sort.Search(len(items), func(i int) bool {
+		return items[i].Stop > timeMS
+	})
+	if idx < len(items) && items[idx].Start <= timeMS {
+		return items[idx], false, nil
+	}
+	return nil, false, nil
+}
+
+/*
+NextSessionAt
+Return the first trading session which has not stopped at timeMS (the current one or the next one)
+返回在timeMS时尚未结束的第一个交易时段（当前或下一个）
+*/
+func NextSessionAt(exs *ExSymbol, timeMS int64) (*TradeSession, bool, *errs.Error) {
+	items, always, err := getSessions(exs, timeMS)
+	if err != nil || always {
+		return nil, always, err
+	}
+	idx := sort.Search(len(items), func(i int) bool {
+		return items[i].Stop > timeMS
+	})
+	if idx < len(items) {
+		return items[idx], false, nil
+	}
+	return nil, false, nil
+}
+
+/*
+InTradeSession
+Whether the symbol is tradable at timeMS. Returns true when calendars are unavailable.
+标的在timeMS时是否可交易。无法获取日历时返回true
+*/
+func InTradeSession(exs *ExSymbol, timeMS int64) bool {
+	sess, always, err := GetSessionAt(exs, timeMS)
+	if err != nil || always {
+		return true
+	}
+	return sess != nil
+}
+
+/*
+SessionMSBetween
+Total trading milliseconds of the symbol within [start, stop)
+标的在[start, stop)之间的可交易毫秒数
+*/
+func SessionMSBetween(exs *ExSymbol, start, stop int64) int64 {
+	if stop <= start {
+		return 0
+	}
+	items, always, err := getSessions(exs, start)
+	if err != nil || always {
+		return stop - start
+	}
+	sessLock.Lock()
+	win := sessCache[exs.ID]
+	sessLock.Unlock()
+	if win != nil && win.stop < stop {
+		// The range exceeds the cache window, query again from the end of the window
+		// 超出缓存窗口，从窗口结束处再次查询
+		return sessMSIn(items, start, win.stop) + SessionMSBetween(exs, win.stop, stop)
+	}
+	return sessMSIn(items, start, stop)
+}
+
+func sessMSIn(items []*TradeSession, start, stop int64) int64 {
+	var total int64
+	for _, s := range items {
+		if s.Stop <= start {
+			continue
+		}
+		if s.Start >= stop {
+			break
+		}
+		total += min(s.Stop, stop) - max(s.Start, start)
+	}
+	return total
+}
+
+/*
+BuildSessOHLCV
+Aggregate sub klines into bigger timeframe. For daily and weekly bars of non-24/7 symbols, bars are grouped
+by their trading day in calendars, otherwise this is equal to utils.BuildOHLCV.
+聚合子周期K线到更大周期。对于非全天候交易标的的日线、周线，按日历中的交易日分组；否则等同于utils.BuildOHLCV
+*/
+func BuildSessOHLCV(exs *ExSymbol, arr []*banexg.Kline, tfMSecs int64, preFire float64, resOHLCV []*banexg.Kline,
+	fromTfMSecs, offMS int64) ([]*banexg.Kline, bool) {
+	infoBy := exs.InfoBy()
+	dayMSecs := int64(utils2.TFToSecs("1d") * 1000)
+	if tfMSecs < dayMSecs || len(arr) == 0 {
+		return utils.BuildOHLCV(arr, tfMSecs, preFire, resOHLCV, fromTfMSecs, offMS, infoBy)
+	}
+	items, always, err := getSessions(exs, arr[0].Time)
+	if err != nil || always || len(items) == 0 {
+		return utils.BuildOHLCV(arr, tfMSecs, preFire, resOHLCV, fromTfMSecs, offMS, infoBy)
+	}
+	if fromTfMSecs == 0 && len(arr) >= 2 {
+		fromTfMSecs = arr[len(arr)-1].Time - arr[len(arr)-2].Time
+	}
+	// Relabel each sub bar with its trading day, then aggregate without offset
+	// 将每个子bar的时间替换为所属交易日，然后无偏移聚合
+	dayBars := make([]*banexg.Kline, 0, len(arr))
+	var lastSess *TradeSession
+	for _, bar := range arr {
+		if lastSess == nil || bar.Time >= lastSess.Stop {
+			lastSess, _, err = NextSessionAt(exs, bar.Time)
+			if err != nil || lastSess == nil {
+				break
+			}
+		}
+		day := bar.Clone()
+		day.Time = lastSess.Day
+		dayBars = append(dayBars, day)
+	}
+	if len(dayBars) < len(arr) {
+		return utils.BuildOHLCV(arr, tfMSecs, preFire, resOHLCV, fromTfMSecs, offMS, infoBy)
+	}
+	res, _ := utils.BuildOHLCV(dayBars, tfMSecs, 0, resOHLCV, dayMSecs, 0, infoBy)
+	// The last big bar is finished when the next session belongs to the next big bar
+	// 下一个交易时段属于下一个大周期bar时，最后一个大周期bar完成
+	// With preFire, it's also finished when the left sessions of the big bar will stop before fireAt
+	// 有preFire时，大周期bar剩余的交易时段在fireAt前结束也视为完成
+	lastDone := false
+	if len(res) > 0 {
+		lastEnd := arr[len(arr)-1].Time + fromTfMSecs
+		fireAt := lastEnd + int64(float64(tfMSecs)*preFire)
+		bigEnd := res[len(res)-1].Time + tfMSecs
+		next, _, err := NextSessionAt(exs, lastEnd)
+		for err == nil && next != nil && next.Day < bigEnd && fireAt >= next.Stop {
+			next, _, err = NextSessionAt(exs, next.Stop)
+		}
+		if err == nil {
+			if next == nil {
+				lastDone = fireAt >= lastSess.Stop
+			} else {
+				lastDone = next.Day >= bigEnd
+			}
+		}
+	}
+	return res, lastDone
+}
@@ -0,0 +1,199 @@
+package orm
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banbot/utils"
+	"github.com/banbox/banexg/errs"
+	"github.com/banbox/banexg/log"
+	"github.com/sasha-s/go-deadlock"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	symbolTags  = make(map[int32][]string) // sector/category tags by sid 按sid的板块/分类标签
+	lockSymTags deadlock.Mutex
+)
+
+/*
+LoadSymbolTags
+Load tags of all symbols from exsymbol_tag into cache
+从exsymbol_tag加载所有品种的标签到缓存
+*/
+func (q *Queries) LoadSymbolTags() *errs.Error {
+	ctx := context.Background()
+	rows, err_ := q.db.Query(ctx, "select sid, tag from exsymbol_tag order by sid, tag")
+	if err_ != nil {
+		return NewDbErr(core.ErrDbReadFail, err_)
+	}
+	defer rows.Close()
+	res := make(map[int32][]string)
+	for rows.Next() {
+		var sid int32
+		var tag string
+		if err_ = rows.Scan(&sid, &tag); err_ != nil {
+			return NewDbErr(core.ErrDbReadFail, err_)
+		}
+		res[sid] = append(res[sid], tag)
+	}
+	if err_ = rows.Err(); err_ != nil {
+		return NewDbErr(core.ErrDbReadFail, err_)
+	}
+	lockSymTags.Lock()
+	symbolTags = res
+	lockSymTags.Unlock()
+	return nil
+}
+
+/*
+SetSymbolTags
+Replace tags of a symbol, empty tags means removing all. Should be called in a transaction.
+替换品种的标签，标签为空表示全部删除。应在事务中调用
+*/
+func (q *Queries) SetSymbolTags(sid int32, tags []string) *errs.Error {
+	ctx := context.Background()
+	tags = utils.NormTags(tags)
+	_, err_ := q.db.Exec(ctx, "delete from exsymbol_tag where sid=$1", sid)
+	if err_ != nil {
+		return NewDbErr(core.ErrDbExecFail, err_)
+	}
+	for _, tag := range tags {
+		_, err_ = q.db.Exec(ctx, "insert into exsymbol_tag (sid, tag) values ($1, $2)", sid, tag)
+		if err_ != nil {
+			return NewDbErr(core.ErrDbExecFail, err_)
+		}
+	}
+	lockSymTags.Lock()
+	if len(tags) == 0 {
+		delete(symbolTags, sid)
+	} else {
+		symbolTags[sid] = tags
+	}
+	lockSymTags.Unlock()
+	return nil
+}
+
+/*
+GetSymbolTags
+Get cached tags of a symbol by sid
+按sid获取缓存中品种的标签
+*/
+func GetSymbolTags(sid int32) []string {
+	lockSymTags.Lock()
+	defer lockSymTags.Unlock()
+	return symbolTags[sid]
+}
+
+/*
+GetPairTags
+Get tags of a symbol in current exchange and market
+获取当前交易所和市场中品种的标签
+*/
+func GetPairTags(pair string) []string {
+	exs := GetExSymbol2(core.ExgName, core.Market, pair)
+	if exs == nil {
+		return nil
+	}
+	return GetSymbolTags(exs.ID)
+}
+
+/*
+ImportSymbolTags
+Import tags of symbols in current exchange and market from csv or yaml file, replace existing tags of listed symbols.
+csv: `symbol,tag1,tag2...`, tags in one cell can also be separated by `|`; a first row starting with `symbol` is skipped.
+yaml: `symbol: [tag1, tag2]`.
+从csv或yaml文件导入当前交易所和市场中品种的标签，替换所列品种的已有标签。
+csv：`symbol,tag1,tag2...`，一个单元格中的标签也可用`|`分隔；以`symbol`开头的首行会被跳过。
+yaml：`symbol: [tag1, tag2]`。
+*/
+func ImportSymbolTags(path string) (int, *errs.Error) {
+	pairTags, err := readSymbolTags(path)
+	if err != nil {
+		return 0, err
+	}
+	tagMap := make(map[string][]string, len(pairTags))
+	for raw, tags := range pairTags {
+		parsed, err := config.ParsePairs(raw)
+		if err != nil {
+			return 0, err
+		}
+		tagMap[parsed[0]] = append(tagMap[parsed[0]], tags...)
+	}
+	pairs := utils.KeysOfMap(tagMap)
+	slices.Sort(pairs)
+	err = EnsureCurSymbols(pairs)
+	if err != nil {
+		return 0, err
+	}
+	ctx := context.Background()
+	sess, conn, err := Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	tx, sess, err := sess.NewTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, pair := range pairs {
+		exs, err := GetExSymbolCur(pair)
+		if err == nil {
+			err = sess.SetSymbolTags(exs.ID, tagMap[pair])
+		}
+		if err != nil {
+			_ = tx.Close(ctx, false)
+			return 0, err
+		}
+	}
+	err = tx.Close(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+	log.Info("imported symbol tags", zap.Int("num", len(pairs)), zap.String("path", path))
+	return len(pairs), nil
+}
+
+func readSymbolTags(path string) (map[string][]string, *errs.Error) {
+	data, err_ := os.ReadFile(path)
+	if err_ != nil {
+		return nil, errs.New(errs.CodeIOReadFail, err_)
+	}
+	res := make(map[string][]string)
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".yml" || ext == ".yaml" {
+		err_ = yaml.Unmarshal(data, &res)
+		if err_ != nil {
+			return nil, errs.New(errs.CodeUnmarshalFail, err_)
+		}
+		return res, nil
+	}
+	reader := csv.NewReader(strings.NewReader(string(data)))
+	reader.FieldsPerRecord = -1
+	rows, err_ := reader.ReadAll()
+	if err_ != nil {
+		return nil, errs.New(errs.CodeIOReadFail, err_)
+	}
+	for i, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		pair := strings.TrimSpace(row[0])
+		if pair == "" || i == 0 && strings.EqualFold(pair, "symbol") {
+			continue
+		}
+		var tags []string
+		for _, cell := range row[1:] {
+			tags = append(tags, strings.Split(cell, "|")...)
+		}
+		res[pair] = append(res[pair], tags...)
+	}
+	return res, nil
+}
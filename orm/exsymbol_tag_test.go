@@ -0,0 +1,40 @@
+package orm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadSymbolTags(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "tags.csv")
+	err_ := os.WriteFile(csvPath, []byte("symbol,tags\nBTC/USDT:USDT,L1|major\nDOGE/USDT:USDT,meme,pow\n"), 0644)
+	if err_ != nil {
+		t.Fatal(err_)
+	}
+	res, err := readSymbolTags(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string][]string{
+		"BTC/USDT:USDT":  {"L1", "major"},
+		"DOGE/USDT:USDT": {"meme", "pow"},
+	}
+	if !reflect.DeepEqual(res, expect) {
+		t.Errorf("csv tags: %v", res)
+	}
+	ymlPath := filepath.Join(dir, "tags.yml")
+	err_ = os.WriteFile(ymlPath, []byte("BTC/USDT:USDT: [L1, major]\nDOGE/USDT:USDT: [meme, pow]\n"), 0644)
+	if err_ != nil {
+		t.Fatal(err_)
+	}
+	res, err = readSymbolTags(ymlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, expect) {
+		t.Errorf("yaml tags: %v", res)
+	}
+}
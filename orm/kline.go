@@ -65,6 +65,16 @@ func init() {
 	}
 }
 
+/*
+IsStoredTF
+Whether the timeframe is stored in its own kline table, other timeframes are aggregated when querying
+该周期是否存储在单独的K线表中，其他周期在查询时聚合
+*/
+func IsStoredTF(timeFrame string) bool {
+	_, ok := aggMap[timeFrame]
+	return ok
+}
+
 func aggCol(name, by string) string {
 	if by == "first" || by == "last" {
 		return fmt.Sprintf("%s(%s, time) AS %s", by, name, name)
@@ -119,9 +129,7 @@ order by time`, exs.ID, startMs, finishEndMS)
 	if subTF != "" && len(klines) > 0 {
 		fromTfMSecs := int64(utils2.TFToSecs(subTF) * 1000)
 		var lastFinish bool
-		offMS := GetAlignOff(exs.ID, tfMSecs)
-		infoBy := exs.InfoBy()
-		klines, lastFinish = utils.BuildOHLCV(klines, tfMSecs, 0, nil, fromTfMSecs, offMS, infoBy)
+		klines, lastFinish = BuildSessOHLCV(exs, klines, tfMSecs, 0, nil, fromTfMSecs, GetAlignOff(exs.ID, tfMSecs))
 		if !lastFinish && len(klines) > 0 {
 			klines = klines[:len(klines)-1]
 		}
@@ -194,9 +202,8 @@ order by sid,time`, startMs, finishEndMS, sidText)
 	callBack := func() {
 		if fromTfMSecs > 0 {
 			var lastDone bool
-			offMS := GetAlignOff(curSid, tfMSecs)
-			infoBy := exsMap[curSid].InfoBy()
-			klineArr, lastDone = utils.BuildOHLCV(klineArr, tfMSecs, 0, nil, fromTfMSecs, offMS, infoBy)
+			klineArr, lastDone = BuildSessOHLCV(exsMap[curSid], klineArr, tfMSecs, 0, nil, fromTfMSecs,
+				GetAlignOff(curSid, tfMSecs))
 			if !lastDone && len(klineArr) > 0 {
 				klineArr = klineArr[:len(klineArr)-1]
 			}
@@ -327,9 +334,7 @@ where sid=%d and time >= %v and time < %v`, aggFrom, sid, startMS, endMS)
 		if err_ != nil {
 			return nil, 0, err_
 		}
-		offMS := GetAlignOff(sid, tfMSecs)
-		infoBy := GetSymbolByID(sid).InfoBy()
-		bigKlines, _ = utils.BuildOHLCV(klines, tfMSecs, 0, nil, 0, offMS, infoBy)
+		bigKlines, _ = BuildSessOHLCV(GetSymbolByID(sid), klines, tfMSecs, 0, nil, 0, GetAlignOff(sid, tfMSecs))
 		if len(klines) > 0 {
 			barEndMS = klines[len(klines)-1].Time + int64(utils2.TFToSecs(fromTF)*1000)
 		}
@@ -395,9 +400,7 @@ Calculate the unfinished bars of large cycles from sub cycles
 从子周期计算大周期的未完成bar
 */
 func calcUnFinish(sid int32, tfMSecs, fromTfMSecs int64, arr []*banexg.Kline) *banexg.Kline {
-	offMS := GetAlignOff(sid, tfMSecs)
-	infoBy := GetSymbolByID(sid).InfoBy()
-	merged, _ := utils.BuildOHLCV(arr, tfMSecs, 0, nil, fromTfMSecs, offMS, infoBy)
+	merged, _ := BuildSessOHLCV(GetSymbolByID(sid), arr, tfMSecs, 0, nil, fromTfMSecs, GetAlignOff(sid, tfMSecs))
 	if len(merged) == 0 {
 		return nil
 	}
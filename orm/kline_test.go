@@ -36,3 +36,48 @@ func TestGetKrange(t *testing.T) {
 	start, stop := sess.GetKlineRange(12, "1m")
 	log.Info("krange", zap.Int64("start", start), zap.Int64("stop", stop))
 }
+
+func TestSessMSIn(t *testing.T) {
+	items := []*TradeSession{
+		{Day: 0, Start: 100, Stop: 200},
+		{Day: 0, Start: 300, Stop: 400},
+		{Day: 1000, Start: 900, Stop: 1000},
+	}
+	cases := [][3]int64{
+		{0, 100, 0},
+		{150, 350, 100},
+		{0, 2000, 300},
+		{200, 300, 0},
+		{950, 960, 10},
+	}
+	for _, c := range cases {
+		res := sessMSIn(items, c[0], c[1])
+		if res != c[2] {
+			t.Errorf("FAIL sessMSIn [%v, %v), get: %v, expect: %v", c[0], c[1], res, c[2])
+		}
+	}
+}
+
+func TestBuildSessions(t *testing.T) {
+	hour := int64(3600000)
+	day := hour * 24
+	// 2024-01-04 Thursday, 2024-01-05 Friday, 2024-01-08 Monday
+	thu := int64(1704326400000)
+	fri, mon := thu+day, thu+day*4
+	dtList := [][2]int64{{thu, thu + day}, {fri, fri + day}, {mon, mon + day}}
+	times := [][2]int64{{hour, hour * 7}, {hour * 13, hour * 19}}
+	items := buildSessions(dtList, times, hour*7, thu, mon+day)
+	expects := [][2]int64{
+		{thu + hour, thu}, {thu + hour*13, fri},
+		{fri + hour, fri}, {fri + hour*13, mon},
+		{mon + hour, mon},
+	}
+	if len(items) != len(expects) {
+		t.Fatalf("FAIL buildSessions num: %v, expect: %v", len(items), len(expects))
+	}
+	for i, c := range expects {
+		if items[i].Start != c[0] || items[i].Day != c[1] {
+			t.Errorf("FAIL session %v start: %v day: %v, expect: %v %v", i, items[i].Start, items[i].Day, c[0], c[1])
+		}
+	}
+}
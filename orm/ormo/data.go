@@ -52,6 +52,10 @@ const (
 	OdInfoStopLoss   = "StopLoss"
 	OdInfoTakeProfit = "TakeProfit"
 	OdInfoClientID   = "ClientID"
+	OdInfoMAE        = "MAE"   // max adverse excursion rate (without leverage) 最大不利偏移率（不含杠杆）
+	OdInfoMAEAt      = "MAEAt" // 13-digit timestamp of the bar reaching MAE 达到MAE的bar的13位时间戳
+	OdInfoMFE        = "MFE"   // max favorable excursion rate (without leverage) 最大有利偏移率（不含杠杆）
+	OdInfoMFEAt      = "MFEAt" // 13-digit timestamp of the bar reaching MFE 达到MFE的bar的13位时间戳
 )
 
 const (
@@ -238,6 +238,50 @@ func (i *InOutOrder) UpdateProfits(price float64) {
 	i.DirtyMain = true
 }
 
+/*
+UpdateExcursion
+Update the max adverse/favorable excursion (MAE/MFE) of the order with the high/low price of a bar.
+The rates are relative to the entry price without leverage, same as ProfitRate, and are saved in Info.
+使用bar的最高最低价更新订单的最大不利/有利偏移(MAE/MFE)。比率相对于入场价格且不含杠杆，和ProfitRate一致，保存在Info中
+*/
+func (i *InOutOrder) UpdateExcursion(high, low float64, timeMS int64) {
+	if i.Enter == nil || i.Enter.Filled == 0 {
+		return
+	}
+	entPrice := i.Enter.Average
+	if entPrice == 0 {
+		entPrice = i.Enter.Price
+	}
+	if entPrice == 0 {
+		entPrice = i.InitPrice
+	}
+	if entPrice <= 0 || high <= 0 || low <= 0 {
+		return
+	}
+	adverse, favor := (entPrice-low)/entPrice, (high-entPrice)/entPrice
+	if i.Short {
+		adverse, favor = (high-entPrice)/entPrice, (entPrice-low)/entPrice
+	}
+	if adverse > 0 && adverse > i.GetInfoFloat64(OdInfoMAE) {
+		i.SetInfo(OdInfoMAE, adverse)
+		i.SetInfo(OdInfoMAEAt, timeMS)
+	}
+	if favor > 0 && favor > i.GetInfoFloat64(OdInfoMFE) {
+		i.SetInfo(OdInfoMFE, favor)
+		i.SetInfo(OdInfoMFEAt, timeMS)
+	}
+}
+
+/*
+GetExcursions
+Return MAE, time of MAE, MFE, time of MFE. see UpdateExcursion
+返回MAE、MAE时间、MFE、MFE时间，参见UpdateExcursion
+*/
+func (i *InOutOrder) GetExcursions() (float64, int64, float64, int64) {
+	return i.GetInfoFloat64(OdInfoMAE), i.GetInfoInt64(OdInfoMAEAt), i.GetInfoFloat64(OdInfoMFE),
+		i.GetInfoInt64(OdInfoMFEAt)
+}
+
 /*
 UpdateFee
 Calculates commission for entry/exit orders. Must be called after Filled is assigned a value, otherwise the calculation is empty
@@ -1096,8 +1140,9 @@ type GetOrdersArgs struct {
 	Dirt        int   // core.OdDirtLong/core.OdDirtShort/core.OdDirtBoth
 	EnterTag    string
 	ExitTag     string
-	CloseAfter  int64 // Start timestamp 开始时间戳
-	CloseBefore int64 // End timestamp 结束时间戳
+	UserTags    []string // match orders with any of the user tags 匹配有任一用户标签的订单
+	CloseAfter  int64    // Start timestamp 开始时间戳
+	CloseBefore int64    // End timestamp 结束时间戳
 	Limit       int
 	AfterID     int // position means after; negative means before
 	OrderBy     string
@@ -1173,6 +1218,17 @@ func (q *Queries) GetOrders(args GetOrdersArgs) ([]*InOutOrder, *errs.Error) {
 		b.WriteString(fmt.Sprintf("and exit_tag=$%v ", len(sqlParams)+1))
 		sqlParams = append(sqlParams, args.ExitTag)
 	}
+	if len(args.UserTags) > 0 {
+		b.WriteString("and id in (select inout_id from order_tag where tag in(")
+		for i, tag := range args.UserTags {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(fmt.Sprintf("$%v", len(sqlParams)+1))
+			sqlParams = append(sqlParams, tag)
+		}
+		b.WriteString(")) ")
+	}
 	if args.OrderBy == "" {
 		args.OrderBy = "id desc"
 	}
@@ -0,0 +1,148 @@
+package ormo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banbot/utils"
+	"github.com/banbox/banexg/errs"
+)
+
+/*
+OrderNote
+Free-form note and user tags of an InOutOrder, added by humans through the live API.
+InOutOrder的自由备注和用户标签，由用户通过实盘API添加
+*/
+type OrderNote struct {
+	InoutID  int64    `json:"inout_id"`
+	Note     string   `json:"note"`
+	Tags     []string `json:"tags"`
+	UpdateAt int64    `json:"update_at"`
+}
+
+/*
+SetOrderNote
+Replace the note and tags of an order. The note is deleted when it's empty.
+Should be called in a transaction.
+替换订单的备注和标签。备注为空时删除。应在事务中调用
+*/
+func (q *Queries) SetOrderNote(inoutID int64, note string, tags []string) *errs.Error {
+	ctx := context.Background()
+	var err_ error
+	if note == "" {
+		_, err_ = q.db.ExecContext(ctx, "delete from order_note where inout_id=?", inoutID)
+	} else {
+		_, err_ = q.db.ExecContext(ctx, `insert into order_note ("inout_id", "note", "update_at") values (?, ?, ?)
+on conflict(inout_id) do update set note=excluded.note, update_at=excluded.update_at`,
+			inoutID, note, btime.UTCStamp())
+	}
+	if err_ != nil {
+		return errs.New(core.ErrDbExecFail, err_)
+	}
+	_, err_ = q.db.ExecContext(ctx, "delete from order_tag where inout_id=?", inoutID)
+	if err_ != nil {
+		return errs.New(core.ErrDbExecFail, err_)
+	}
+	for _, tag := range utils.NormTags(tags) {
+		_, err_ = q.db.ExecContext(ctx, `insert into order_tag ("inout_id", "tag") values (?, ?)`, inoutID, tag)
+		if err_ != nil {
+			return errs.New(core.ErrDbExecFail, err_)
+		}
+	}
+	return nil
+}
+
+// noteBatchSize limits the ids queried at once, to stay under bound-variable limit of sqlite
+// 单次查询的订单数量上限，避免超过sqlite的绑定变量数限制
+const noteBatchSize = 500
+
+/*
+GetOrderNotes
+Get notes and tags of orders, orders without note or tag are not included.
+获取订单的备注和标签，无备注和标签的订单不包含在结果中
+*/
+func (q *Queries) GetOrderNotes(inoutIDs []int64) (map[int64]*OrderNote, *errs.Error) {
+	res := make(map[int64]*OrderNote)
+	for start := 0; start < len(inoutIDs); start += noteBatchSize {
+		stop := min(start+noteBatchSize, len(inoutIDs))
+		err := q.getOrderNotes(inoutIDs[start:stop], res)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (q *Queries) getOrderNotes(inoutIDs []int64, res map[int64]*OrderNote) *errs.Error {
+	holders := strings.TrimSuffix(strings.Repeat("?,", len(inoutIDs)), ",")
+	args := make([]interface{}, 0, len(inoutIDs))
+	for _, id := range inoutIDs {
+		args = append(args, id)
+	}
+	getItem := func(id int64) *OrderNote {
+		item, ok := res[id]
+		if !ok {
+			item = &OrderNote{InoutID: id}
+			res[id] = item
+		}
+		return item
+	}
+	ctx := context.Background()
+	rows, err_ := q.db.QueryContext(ctx, "select inout_id, note, update_at from order_note where inout_id in ("+
+		holders+")", args...)
+	if err_ != nil {
+		return errs.New(core.ErrDbReadFail, err_)
+	}
+	for rows.Next() {
+		var id, updateAt int64
+		var note string
+		if err_ = rows.Scan(&id, &note, &updateAt); err_ != nil {
+			_ = rows.Close()
+			return errs.New(core.ErrDbReadFail, err_)
+		}
+		item := getItem(id)
+		item.Note, item.UpdateAt = note, updateAt
+	}
+	_ = rows.Close()
+	rows, err_ = q.db.QueryContext(ctx, "select inout_id, tag from order_tag where inout_id in ("+holders+
+		") order by id", args...)
+	if err_ != nil {
+		return errs.New(core.ErrDbReadFail, err_)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var tag string
+		if err_ = rows.Scan(&id, &tag); err_ != nil {
+			return errs.New(core.ErrDbReadFail, err_)
+		}
+		item := getItem(id)
+		item.Tags = append(item.Tags, tag)
+	}
+	return nil
+}
+
+/*
+GetTaskOrderTags
+Get all distinct user tags used by orders of the task
+获取任务订单使用的所有不重复用户标签
+*/
+func (q *Queries) GetTaskOrderTags(taskID int64) ([]string, *errs.Error) {
+	rows, err_ := q.db.QueryContext(context.Background(), `select distinct t.tag from order_tag t
+join iorder o on o.id=t.inout_id where o.task_id=? order by t.tag`, taskID)
+	if err_ != nil {
+		return nil, errs.New(core.ErrDbReadFail, err_)
+	}
+	defer rows.Close()
+	var res []string
+	for rows.Next() {
+		var tag string
+		if err_ = rows.Scan(&tag); err_ != nil {
+			return nil, errs.New(core.ErrDbReadFail, err_)
+		}
+		res = append(res, tag)
+	}
+	return res, nil
+}
@@ -27,3 +27,20 @@ func TestGetOrders(t *testing.T) {
 	defer conn.Close()
 	sess.GetOrders(GetOrdersArgs{})
 }
+
+func TestUpdateExcursion(t *testing.T) {
+	od := &InOutOrder{
+		IOrder: &IOrder{Short: true},
+		Enter:  &ExOrder{Average: 100, Filled: 1},
+	}
+	od.UpdateExcursion(103, 98, 1000)
+	od.UpdateExcursion(101, 95, 2000)
+	od.UpdateExcursion(102, 97, 3000)
+	mae, maeAt, mfe, mfeAt := od.GetExcursions()
+	if mae != 0.03 || maeAt != 1000 {
+		t.Errorf("bad mae: %v %v", mae, maeAt)
+	}
+	if mfe != 0.05 || mfeAt != 2000 {
+		t.Errorf("bad mfe: %v %v", mfe, mfeAt)
+	}
+}
@@ -0,0 +1,78 @@
+package ormo
+
+import (
+	"context"
+
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banexg/errs"
+)
+
+const (
+	SnapKindJob    = "job"    // strategy job custom state 策略任务自定义状态
+	SnapKindWallet = "wallet" // wallets of account 账户钱包
+	SnapKindBatch  = "batch"  // pending batch jobs 待执行的批量任务
+)
+
+type Snapshot struct {
+	ID       int64  `json:"id"`
+	TaskID   int64  `json:"task_id"`
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Data     string `json:"data"`
+	UpdateAt int64  `json:"update_at"`
+}
+
+/*
+SetSnapshots
+Replace all snapshots of the specified kind for the task
+替换任务指定类型的所有快照
+*/
+func (q *Queries) SetSnapshots(taskID int64, kind string, items map[string]string) *errs.Error {
+	ctx := context.Background()
+	_, err_ := q.db.ExecContext(ctx, "delete from snapshot where task_id=? and kind=?", taskID, kind)
+	if err_ != nil {
+		return errs.New(core.ErrDbExecFail, err_)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	stmt, err_ := q.db.PrepareContext(ctx, `insert into snapshot ("task_id", "kind", "name", "data", "update_at")
+values (?, ?, ?, ?, ?)`)
+	if err_ != nil {
+		return errs.New(core.ErrDbExecFail, err_)
+	}
+	defer stmt.Close()
+	curMS := btime.UTCStamp()
+	for name, data := range items {
+		_, err_ = stmt.ExecContext(ctx, taskID, kind, name, data, curMS)
+		if err_ != nil {
+			return errs.New(core.ErrDbExecFail, err_)
+		}
+	}
+	return nil
+}
+
+/*
+GetSnapshots
+Get all snapshots of the specified kind for the task, returns name: Snapshot
+获取任务指定类型的所有快照，返回name: Snapshot
+*/
+func (q *Queries) GetSnapshots(taskID int64, kind string) (map[string]*Snapshot, *errs.Error) {
+	sql := "select id,task_id,kind,name,data,update_at from snapshot where task_id=? and kind=?"
+	rows, err_ := q.db.QueryContext(context.Background(), sql, taskID, kind)
+	if err_ != nil {
+		return nil, errs.New(core.ErrDbReadFail, err_)
+	}
+	defer rows.Close()
+	var res = make(map[string]*Snapshot)
+	for rows.Next() {
+		var it Snapshot
+		err_ = rows.Scan(&it.ID, &it.TaskID, &it.Kind, &it.Name, &it.Data, &it.UpdateAt)
+		if err_ != nil {
+			return nil, errs.New(core.ErrDbReadFail, err_)
+		}
+		res[it.Name] = &it
+	}
+	return res, nil
+}
@@ -0,0 +1,80 @@
+package ormo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banexg/errs"
+)
+
+type StratState struct {
+	TaskID    int64  `json:"task_id"`
+	Strategy  string `json:"strategy"`
+	Pair      string `json:"pair"`
+	Timeframe string `json:"timeframe"`
+	Key       string `json:"key"`
+	Version   int64  `json:"version"`
+	Data      string `json:"data"`
+	UpdateAt  int64  `json:"update_at"`
+}
+
+/*
+SetStratState
+Insert or update a persistent state item of strategy job
+插入或更新策略任务的一个持久化状态项
+*/
+func (q *Queries) SetStratState(arg *StratState) *errs.Error {
+	if arg.UpdateAt == 0 {
+		arg.UpdateAt = btime.UTCStamp()
+	}
+	_, err_ := q.db.ExecContext(context.Background(), `insert into strat_state
+("task_id", "strategy", "pair", "timeframe", "key", "version", "data", "update_at") values (?, ?, ?, ?, ?, ?, ?, ?)
+on conflict(task_id, strategy, pair, timeframe, key) do update set version=excluded.version,
+data=excluded.data, update_at=excluded.update_at`, arg.TaskID, arg.Strategy, arg.Pair, arg.Timeframe, arg.Key,
+		arg.Version, arg.Data, arg.UpdateAt)
+	if err_ != nil {
+		return errs.New(core.ErrDbExecFail, err_)
+	}
+	return nil
+}
+
+/*
+GetStratState
+Get a persistent state item of strategy job, return nil if not found
+获取策略任务的一个持久化状态项，不存在时返回nil
+*/
+func (q *Queries) GetStratState(taskID int64, strategy, pair, timeframe, key string) (*StratState, *errs.Error) {
+	row := q.db.QueryRowContext(context.Background(), `select version, data, update_at from strat_state
+where task_id=? and strategy=? and pair=? and timeframe=? and key=?`, taskID, strategy, pair, timeframe, key)
+	var res = &StratState{TaskID: taskID, Strategy: strategy, Pair: pair, Timeframe: timeframe, Key: key}
+	err_ := row.Scan(&res.Version, &res.Data, &res.UpdateAt)
+	if err_ != nil {
+		if errors.Is(err_, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errs.New(core.ErrDbReadFail, err_)
+	}
+	return res, nil
+}
+
+/*
+DelStratState
+Delete persistent state items of strategy job. Delete all keys of the job if key is empty.
+删除策略任务的持久化状态项，key为空时删除该任务所有状态
+*/
+func (q *Queries) DelStratState(taskID int64, strategy, pair, timeframe, key string) *errs.Error {
+	sqlText := "delete from strat_state where task_id=? and strategy=? and pair=? and timeframe=?"
+	args := []interface{}{taskID, strategy, pair, timeframe}
+	if key != "" {
+		sqlText += " and key=?"
+		args = append(args, key)
+	}
+	_, err_ := q.db.ExecContext(context.Background(), sqlText, args...)
+	if err_ != nil {
+		return errs.New(core.ErrDbExecFail, err_)
+	}
+	return nil
+}
@@ -17,3 +17,13 @@ BEGIN
     ALTER TABLE public.exsymbol ALTER COLUMN symbol TYPE varchar(50);
     END IF;
 END $$;
+
+-- version 3
+-- 添加exsymbol_tag表，存储品种的板块/分类标签
+CREATE TABLE IF NOT EXISTS public.exsymbol_tag
+(
+    sid  int4        not null,
+    tag  varchar(50) not null,
+    PRIMARY KEY (sid, tag)
+);
+CREATE INDEX IF NOT EXISTS idx_exsymbol_tag_tag ON public.exsymbol_tag USING btree (tag);
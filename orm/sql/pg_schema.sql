@@ -66,6 +66,18 @@ CREATE TABLE "public"."exsymbol"
 );
 CREATE UNIQUE INDEX "ix_exsymbol_unique" ON "public"."exsymbol" ("exchange", "market", "symbol");
 
+-- ----------------------------
+-- Table structure for exsymbol_tag
+-- ----------------------------
+DROP TABLE IF EXISTS "public"."exsymbol_tag";
+CREATE TABLE "public"."exsymbol_tag"
+(
+    "sid"  int4        not null,
+    "tag"  varchar(50) not null,
+    PRIMARY KEY ("sid", "tag")
+);
+CREATE INDEX "idx_exsymbol_tag_tag" ON "public"."exsymbol_tag" USING btree ("tag");
+
 
 -- ----------------------------
 -- Table structure for calendars
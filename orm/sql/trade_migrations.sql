@@ -0,0 +1,58 @@
+-- Tables added after the initial trade schema, executed on every writable connection.
+-- Statements must be idempotent. 在初始交易表结构之后新增的表，每次可写连接时执行，语句必须可重复执行
+
+-- ----------------------------
+-- Table structure for snapshot
+-- ----------------------------
+CREATE TABLE IF NOT EXISTS snapshot
+(
+    id        INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id   INTEGER NOT NULL,
+    kind      TEXT    NOT NULL,
+    name      TEXT    NOT NULL,
+    data      TEXT    NOT NULL,
+    update_at INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_snap_key ON snapshot (task_id, kind, name);
+
+-- ----------------------------
+-- Table structure for strat_state
+-- ----------------------------
+CREATE TABLE IF NOT EXISTS strat_state
+(
+    id        INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id   INTEGER NOT NULL,
+    strategy  TEXT    NOT NULL,
+    pair      TEXT    NOT NULL,
+    timeframe TEXT    NOT NULL,
+    key       TEXT    NOT NULL,
+    version   INTEGER NOT NULL,
+    data      TEXT    NOT NULL,
+    update_at INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_strat_state_key ON strat_state (task_id, strategy, pair, timeframe, key);
+
+-- ----------------------------
+-- Table structure for order_note
+-- ----------------------------
+CREATE TABLE IF NOT EXISTS order_note
+(
+    inout_id  INTEGER PRIMARY KEY,
+    note      TEXT    NOT NULL,
+    update_at INTEGER NOT NULL
+);
+
+-- ----------------------------
+-- Table structure for order_tag
+-- ----------------------------
+CREATE TABLE IF NOT EXISTS order_tag
+(
+    id       INTEGER PRIMARY KEY AUTOINCREMENT,
+    inout_id INTEGER NOT NULL,
+    tag      TEXT    NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_tag_key ON order_tag (inout_id, tag);
+CREATE INDEX IF NOT EXISTS idx_order_tag_tag ON order_tag (tag);
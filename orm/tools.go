@@ -834,6 +834,76 @@ func ImportData(dataDir string, numWorkers int, pb *utils2.StagedPrg) *errs.Erro
 	}
 }
 
+/*
+ReadProtoKlines
+Read klines of a symbol from protobuf files exported by ExportKData, without importing them into database.
+从ExportKData导出的protobuf文件中读取某个品种的K线，不导入数据库
+*/
+func ReadProtoKlines(dataDir, exgName, market, symbol, timeFrame string, startMS, endMS int64) ([]*banexg.Kline, *errs.Error) {
+	exInfoFile, err_ := os.Open(filepath.Join(dataDir, "exInfo1.dat"))
+	if err_ != nil {
+		return nil, errs.New(errs.CodeIOReadFail, err_)
+	}
+	exInfo := &EXInfo{}
+	err := readProtoMessage(exInfoFile, exInfo)
+	exInfoFile.Close()
+	if err != nil {
+		return nil, err
+	}
+	exsID := int32(0)
+	for _, it := range exInfo.Symbols {
+		if it.Exchange == exgName && it.Market == market && it.Symbol == symbol {
+			exsID = it.Id
+			break
+		}
+	}
+	if exsID == 0 {
+		return nil, errs.NewMsg(core.ErrInvalidSymbol, "%s not found in %s", symbol, dataDir)
+	}
+	files, err_ := filepath.Glob(filepath.Join(dataDir, "kline*.dat"))
+	if err_ != nil {
+		return nil, errs.New(errs.CodeIOReadFail, err_)
+	}
+	tfMSecs := int64(utils.TFToSecs(timeFrame) * 1000)
+	var res []*banexg.Kline
+	for _, path := range files {
+		file, err_ := os.Open(path)
+		if err_ != nil {
+			return nil, errs.New(errs.CodeIOReadFail, err_)
+		}
+		for {
+			block := KlineBlock{}
+			err = readProtoMessage(file, &block)
+			if err != nil || len(block.Open) == 0 {
+				break
+			}
+			if block.ExsId != exsID || block.Timeframe != timeFrame || block.End <= startMS || block.Start >= endMS {
+				continue
+			}
+			barMS := block.Start
+			for i := range block.Open {
+				if barMS >= startMS && barMS < endMS {
+					bar := &banexg.Kline{Time: barMS, Open: block.Open[i], High: block.High[i], Low: block.Low[i],
+						Close: block.Close[i], Volume: block.Volume[i]}
+					if len(block.Info) > i {
+						bar.Info = block.Info[i]
+					}
+					res = append(res, bar)
+				}
+				barMS += tfMSecs
+			}
+		}
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Time < res[j].Time
+	})
+	return res, nil
+}
+
 func readProtoMessage(file *os.File, msg proto.Message) *errs.Error {
 	// 读取消息大小
 	sizeBuf := make([]byte, 4)
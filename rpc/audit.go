@@ -0,0 +1,109 @@
+package rpc
+
+import (
+	"bufio"
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banexg/errs"
+	"github.com/banbox/banexg/log"
+	"github.com/banbox/banexg/utils"
+	"github.com/sasha-s/go-deadlock"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+)
+
+const (
+	AuditRequest = "request" // 发起危险操作
+	AuditApprove = "approve" // 确认码校验通过
+	AuditDeny    = "deny"    // 确认用户无权限
+	AuditCancel  = "cancel"  // 取消
+	AuditExpire  = "expire"  // 过期未确认
+	AuditDone    = "done"    // 执行完成
+	AuditFail    = "fail"    // 执行失败
+)
+
+var (
+	lockAudit deadlock.Mutex
+)
+
+// AuditRecord 审计日志中的一条记录，记录谁在何时发起/批准了什么操作
+type AuditRecord struct {
+	TimeMS    int64  `json:"time_ms"`
+	Event     string `json:"event"`
+	Action    string `json:"action"`
+	Detail    string `json:"detail,omitempty"`
+	Code      string `json:"code,omitempty"`
+	User      string `json:"user,omitempty"`
+	Requester string `json:"requester,omitempty"`
+	Result    string `json:"result,omitempty"`
+}
+
+func auditPath() string {
+	return filepath.Join(config.GetLogsDir(), "audit.log")
+}
+
+// AddAudit 追加一条审计记录到logs/audit.log(每行一个json)
+func AddAudit(rec *AuditRecord) {
+	if rec.TimeMS == 0 {
+		rec.TimeMS = btime.UTCStamp()
+	}
+	log.Info("audit", zap.String("event", rec.Event), zap.String("action", rec.Action),
+		zap.String("code", rec.Code), zap.String("user", rec.User), zap.String("requester", rec.Requester),
+		zap.String("detail", rec.Detail), zap.String("res", rec.Result))
+	data, err_ := utils.Marshal(rec)
+	if err_ != nil {
+		log.Warn("marshal audit fail", zap.Error(err_))
+		return
+	}
+	lockAudit.Lock()
+	defer lockAudit.Unlock()
+	file, err_ := os.OpenFile(auditPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err_ != nil {
+		log.Warn("open audit log fail", zap.Error(err_))
+		return
+	}
+	defer file.Close()
+	data = append(data, '\n')
+	if _, err_ = file.Write(data); err_ != nil {
+		log.Warn("write audit log fail", zap.Error(err_))
+	}
+}
+
+// ReadAudits 读取最近的limit条审计记录，按时间倒序
+func ReadAudits(limit int) ([]*AuditRecord, *errs.Error) {
+	lockAudit.Lock()
+	defer lockAudit.Unlock()
+	file, err_ := os.Open(auditPath())
+	if err_ != nil {
+		if os.IsNotExist(err_) {
+			return nil, nil
+		}
+		return nil, errs.New(core.ErrIOReadFail, err_)
+	}
+	defer file.Close()
+	var res []*AuditRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var rec AuditRecord
+		if err_ = utils.Unmarshal(line, &rec, utils.JsonNumDefault); err_ != nil {
+			continue
+		}
+		res = append(res, &rec)
+	}
+	if err_ = scanner.Err(); err_ != nil {
+		return nil, errs.New(core.ErrIOReadFail, err_)
+	}
+	if limit > 0 && len(res) > limit {
+		res = res[len(res)-limit:]
+	}
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res, nil
+}
@@ -0,0 +1,173 @@
+package rpc
+
+import (
+	"crypto/rand"
+	"fmt"
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banexg/errs"
+	"github.com/sasha-s/go-deadlock"
+	"math/big"
+	"slices"
+)
+
+const (
+	ActCloseAll   = "close_all"   // 平仓所有订单
+	ActForceExit  = "force_exit"  // 强制平仓指定品种
+	ActExitOrders = "exit_orders" // api平仓所有订单
+	ActClosePos   = "close_pos"   // api平仓交易所所有仓位
+	ActCloseOrder = "close_order" // 命令行平仓
+)
+
+var (
+	pendings    = map[string]*PendingAction{}
+	lockPending deadlock.Mutex
+)
+
+// PendingAction 等待确认码确认的危险操作
+type PendingAction struct {
+	Code         string `json:"code"`
+	Action       string `json:"action"`
+	Detail       string `json:"detail"`
+	Requester    string `json:"requester"`
+	NeedApprover bool   `json:"need_approver"` // 是否需要其他审批人确认
+	CreateMS     int64  `json:"create_ms"`
+	ExpireMS     int64  `json:"expire_ms"`
+	run          func() (interface{}, error)
+}
+
+// ConfirmEnabled 危险操作是否需要确认码
+func ConfirmEnabled() bool {
+	return config.Confirm != nil && config.Confirm.Enable
+}
+
+func isApprover(user string) bool {
+	return config.Confirm != nil && slices.Contains(config.Confirm.Approvers, user)
+}
+
+func newConfirmCode() string {
+	for {
+		num, err_ := rand.Int(rand.Reader, big.NewInt(1000000))
+		var code string
+		if err_ != nil {
+			code = fmt.Sprintf("%06d", btime.UTCStamp()%1000000)
+		} else {
+			code = fmt.Sprintf("%06d", num.Int64())
+		}
+		if _, ok := pendings[code]; !ok {
+			return code
+		}
+	}
+}
+
+// purgeExpired 清理过期的待确认操作，需在lockPending中调用
+func purgeExpired(curMS int64) {
+	for code, p := range pendings {
+		if p.ExpireMS <= curMS {
+			delete(pendings, code)
+			AddAudit(&AuditRecord{Event: AuditExpire, Action: p.Action, Detail: p.Detail, Code: code,
+				Requester: p.Requester})
+		}
+	}
+}
+
+/*
+RequestConfirm 创建一个待确认的危险操作，返回包含一次性确认码的PendingAction。
+run在确认通过后执行。requester格式如 tg:<用户ID>, api:<用户名>, cli:<系统用户>
+*/
+func RequestConfirm(action, detail, requester string, run func() (interface{}, error)) *PendingAction {
+	ttl := 120
+	var approvers []string
+	if config.Confirm != nil {
+		if config.Confirm.TTLSecs > 0 {
+			ttl = config.Confirm.TTLSecs
+		}
+		approvers = config.Confirm.Approvers
+	}
+	curMS := btime.UTCStamp()
+	lockPending.Lock()
+	purgeExpired(curMS)
+	p := &PendingAction{
+		Code:         newConfirmCode(),
+		Action:       action,
+		Detail:       detail,
+		Requester:    requester,
+		NeedApprover: len(approvers) > 0,
+		CreateMS:     curMS,
+		ExpireMS:     curMS + int64(ttl)*1000,
+		run:          run,
+	}
+	pendings[p.Code] = p
+	lockPending.Unlock()
+	AddAudit(&AuditRecord{Event: AuditRequest, Action: action, Detail: detail, Code: p.Code, User: requester,
+		Requester: requester})
+	return p
+}
+
+/*
+ConfirmAction 使用确认码确认并执行操作。未配置审批人时只能由发起人确认；
+配置审批人时必须由列表中的其他用户确认。权限不足时确认码仍保留。
+*/
+func ConfirmAction(code, user string) (*PendingAction, interface{}, *errs.Error) {
+	lockPending.Lock()
+	purgeExpired(btime.UTCStamp())
+	p, ok := pendings[code]
+	if !ok {
+		lockPending.Unlock()
+		return nil, nil, errs.NewMsg(errs.CodeExpired, "confirm code invalid or expired: %s", code)
+	}
+	var err *errs.Error
+	if !p.NeedApprover {
+		if user != p.Requester {
+			err = errs.NewMsg(core.ErrAuthFail, "only requester %s can confirm %s", p.Requester, code)
+		}
+	} else if user == p.Requester {
+		err = errs.NewMsg(core.ErrAuthFail, "requester can't approve own action, need another approver")
+	} else if !isApprover(user) {
+		err = errs.NewMsg(core.ErrAuthFail, "%s is not an approver", user)
+	}
+	if err != nil {
+		lockPending.Unlock()
+		AddAudit(&AuditRecord{Event: AuditDeny, Action: p.Action, Detail: p.Detail, Code: code, User: user,
+			Requester: p.Requester, Result: err.Short()})
+		return p, nil, err
+	}
+	delete(pendings, code)
+	lockPending.Unlock()
+	AddAudit(&AuditRecord{Event: AuditApprove, Action: p.Action, Detail: p.Detail, Code: code, User: user,
+		Requester: p.Requester})
+	var res interface{}
+	var err_ error
+	if p.run != nil {
+		res, err_ = p.run()
+	}
+	if err_ != nil {
+		AddAudit(&AuditRecord{Event: AuditFail, Action: p.Action, Detail: p.Detail, Code: code, User: user,
+			Requester: p.Requester, Result: err_.Error()})
+		return p, res, errs.New(core.ErrRunTime, err_)
+	}
+	AddAudit(&AuditRecord{Event: AuditDone, Action: p.Action, Detail: p.Detail, Code: code, User: user,
+		Requester: p.Requester, Result: fmt.Sprintf("%v", res)})
+	return p, res, nil
+}
+
+// CancelAction 取消待确认的操作，发起人或审批人均可取消
+func CancelAction(code, user string) (*PendingAction, *errs.Error) {
+	lockPending.Lock()
+	purgeExpired(btime.UTCStamp())
+	p, ok := pendings[code]
+	if !ok {
+		lockPending.Unlock()
+		return nil, errs.NewMsg(errs.CodeExpired, "confirm code invalid or expired: %s", code)
+	}
+	if user != p.Requester && !isApprover(user) {
+		lockPending.Unlock()
+		return p, errs.NewMsg(core.ErrAuthFail, "%s can't cancel %s", user, code)
+	}
+	delete(pendings, code)
+	lockPending.Unlock()
+	AddAudit(&AuditRecord{Event: AuditCancel, Action: p.Action, Detail: p.Detail, Code: code, User: user,
+		Requester: p.Requester})
+	return p, nil
+}
@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"github.com/banbox/banbot/config"
+	"testing"
+)
+
+func TestConfirmAction(t *testing.T) {
+	config.DataDir = t.TempDir()
+	config.Confirm = &config.ConfirmConfig{Enable: true, TTLSecs: 60}
+	runNum := 0
+	run := func() (interface{}, error) {
+		runNum += 1
+		return "ok", nil
+	}
+	// without approvers, only requester can confirm, code is one-time
+	p := RequestConfirm(ActCloseAll, "all", "tg:1", run)
+	if p.NeedApprover || len(p.Code) != 6 {
+		t.Fatalf("bad pending: %+v", p)
+	}
+	if _, _, err := ConfirmAction(p.Code, "tg:2"); err == nil {
+		t.Fatal("other user should not confirm")
+	}
+	if _, res, err := ConfirmAction(p.Code, "tg:1"); err != nil || res != "ok" || runNum != 1 {
+		t.Fatalf("confirm fail: %v %v %d", err, res, runNum)
+	}
+	if _, _, err := ConfirmAction(p.Code, "tg:1"); err == nil {
+		t.Fatal("code should be consumed")
+	}
+	// with approvers, requester can't approve itself
+	config.Confirm.Approvers = []string{"tg:1", "api:ban"}
+	p = RequestConfirm(ActExitOrders, "account user1", "tg:1", run)
+	if !p.NeedApprover {
+		t.Fatal("approver should be required")
+	}
+	if _, _, err := ConfirmAction(p.Code, "tg:1"); err == nil {
+		t.Fatal("requester should not approve")
+	}
+	if _, _, err := ConfirmAction(p.Code, "api:other"); err == nil {
+		t.Fatal("non-approver should not approve")
+	}
+	if _, _, err := ConfirmAction(p.Code, "api:ban"); err != nil || runNum != 2 {
+		t.Fatalf("approve fail: %v %d", err, runNum)
+	}
+	// cancel
+	p = RequestConfirm(ActClosePos, "account user1", "api:ban", run)
+	if _, err := CancelAction(p.Code, "tg:1"); err != nil {
+		t.Fatalf("cancel fail: %v", err)
+	}
+	if _, _, err := ConfirmAction(p.Code, "tg:1"); err == nil || runNum != 2 {
+		t.Fatal("cancelled code should be invalid")
+	}
+	audits, err := ReadAudits(3)
+	if err != nil || len(audits) != 3 || audits[0].Event != AuditCancel {
+		t.Fatalf("bad audits: %v %v", err, audits)
+	}
+}
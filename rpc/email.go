@@ -66,3 +66,15 @@ func makeDoSendMsgEmail(e *Email) func([]map[string]string) []map[string]string
 		return nil
 	}
 }
+
+// SendHtml 直接发送html邮件(不经过队列合并)，用于绩效报告等带图片的消息
+func (e *Email) SendHtml(msgType, account, subject, body string, images map[string][]byte) bool {
+	if !e.accept(msgType, account) {
+		return false
+	}
+	if err := utils2.SendHtmlEmail("", e.toUser, subject, body, images); err != nil {
+		log.Error("email send fail", zap.String("to", e.toUser), zap.Error(err))
+		return false
+	}
+	return true
+}
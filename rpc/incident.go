@@ -0,0 +1,228 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banexg/errs"
+	"github.com/sasha-s/go-deadlock"
+	"go.uber.org/zap/zapcore"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	incDigestMS  = int64(600000) // Digest interval of ongoing incident, unit: ms 持续中的事件汇总发送间隔，单位：毫秒
+	incResolveMS = int64(300000) // Incident resolved after no exception for this duration 超过此时长无异常视为已恢复
+	incCheckIntv = time.Second * 10
+	incHisMax    = 100 // Max resolved incidents kept 最多保留的已恢复事件数量
+
+	incActives   = map[string]*Incident{} // sign: incident
+	incHistory   []*Incident
+	incLastID    int64
+	incLooping   bool
+	lockIncident deadlock.Mutex
+
+	reIncHex = regexp.MustCompile(`0x[0-9a-fA-F]+|\b[0-9a-fA-F]{16,}\b`)
+	reIncNum = regexp.MustCompile(`\d+(\.\d+)?`)
+)
+
+/*
+Incident
+A cluster of exceptions with the same normalized message and stack signature.
+具有相同规范化消息和堆栈签名的一组异常
+*/
+type Incident struct {
+	ID          int64  `json:"id"`
+	Sign        string `json:"sign"`
+	Caller      string `json:"caller"`
+	Message     string `json:"message"` // normalized message 规范化后的消息
+	Sample      string `json:"sample"`  // last raw message 最后一条原始消息
+	Count       int    `json:"count"`
+	DigestCount int    `json:"digest_count"` // count since last notify 上次通知后的数量
+	FirstMS     int64  `json:"first_ms"`
+	LastMS      int64  `json:"last_ms"`
+	NotifyMS    int64  `json:"notify_ms"`
+	ResolvedMS  int64  `json:"resolved_ms"`
+}
+
+/*
+normExcMsg replace numbers and hex ids in message, so that similar exceptions fall into the same cluster
+替换消息中的数字和十六进制id，使相似的异常归入同一组
+*/
+func normExcMsg(msg string) string {
+	msg = reIncHex.ReplaceAllString(msg, "*")
+	return reIncNum.ReplaceAllString(msg, "#")
+}
+
+/*
+stackSign return function names from zap stack, fallback to caller when stack is empty
+从zap堆栈中提取函数名作为签名，堆栈为空时使用调用位置
+*/
+func stackSign(ent zapcore.Entry) string {
+	if ent.Stack == "" {
+		return ent.Caller.TrimmedPath()
+	}
+	var funcs []string
+	for _, line := range strings.Split(ent.Stack, "\n") {
+		if line == "" || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		funcs = append(funcs, line)
+		if len(funcs) >= 5 {
+			break
+		}
+	}
+	return strings.Join(funcs, ";")
+}
+
+/*
+excEntryMsg message and error fields of a log entry, used for clustering
+日志的消息和错误字段，用于聚类
+*/
+func excEntryMsg(ent zapcore.Entry, fields []zapcore.Field) string {
+	var b strings.Builder
+	b.WriteString(ent.Message)
+	for _, f := range fields {
+		if f.Type != zapcore.ErrorType {
+			continue
+		}
+		err, _ := f.Interface.(error)
+		if err == nil {
+			continue
+		}
+		b.WriteString(" ")
+		var err2 *errs.Error
+		if errors.As(err, &err2) {
+			b.WriteString(err2.Short())
+		} else {
+			b.WriteString(err.Error())
+		}
+	}
+	return b.String()
+}
+
+/*
+AddIncident record an exception to its cluster. Notify immediately when the cluster first appears.
+记录异常到对应的事件中，首次出现时立刻发送通知
+*/
+func AddIncident(caller, sign, msg, sample string) {
+	normMsg := normExcMsg(msg)
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(sign + "|" + normMsg))
+	key := fmt.Sprintf("%x", h.Sum64())
+	curMS := btime.UTCStamp()
+	lockIncident.Lock()
+	inc, ok := incActives[key]
+	if ok {
+		inc.Count += 1
+		inc.DigestCount += 1
+		inc.LastMS = curMS
+		inc.Sample = sample
+		lockIncident.Unlock()
+		return
+	}
+	incLastID += 1
+	inc = &Incident{
+		ID:       incLastID,
+		Sign:     key,
+		Caller:   caller,
+		Message:  normMsg,
+		Sample:   sample,
+		Count:    1,
+		FirstMS:  curMS,
+		LastMS:   curMS,
+		NotifyMS: curMS,
+	}
+	incActives[key] = inc
+	text := fmt.Sprintf("[incident #%d] new at %s\n%s", inc.ID, caller, sample)
+	startLoop := !incLooping
+	incLooping = true
+	lockIncident.Unlock()
+	if startLoop {
+		go loopIncidents()
+	}
+	sendIncident(text)
+}
+
+func sendIncident(text string) {
+	SendMsg(map[string]interface{}{
+		"type":   MsgTypeException,
+		"status": text,
+	})
+}
+
+// loopIncidents send digests and resolved messages periodically, exit when no active incident
+// 定期发送汇总和恢复消息，无活跃事件时退出
+func loopIncidents() {
+	for {
+		time.Sleep(incCheckIntv)
+		if !checkIncidents(btime.UTCStamp()) {
+			return
+		}
+	}
+}
+
+/*
+checkIncidents send digests for ongoing incidents and resolve the quiet ones. return whether any incident is active
+为持续中的事件发送汇总，解决已静默的事件。返回是否还有活跃事件
+*/
+func checkIncidents(curMS int64) bool {
+	var texts []string
+	lockIncident.Lock()
+	for key, inc := range incActives {
+		if curMS-inc.LastMS >= incResolveMS {
+			inc.ResolvedMS = curMS
+			delete(incActives, key)
+			incHistory = append(incHistory, inc)
+			dura := time.Duration(inc.LastMS-inc.FirstMS) * time.Millisecond
+			texts = append(texts, fmt.Sprintf("[incident #%d] resolved, total %d in %v\n%s", inc.ID, inc.Count,
+				dura.Round(time.Second), inc.Message))
+		} else if inc.DigestCount > 0 && curMS-inc.NotifyMS >= incDigestMS {
+			dura := time.Duration(curMS-inc.NotifyMS) * time.Millisecond
+			texts = append(texts, fmt.Sprintf("[incident #%d] ongoing, +%d in last %v, total %d\n%s", inc.ID,
+				inc.DigestCount, dura.Round(time.Second), inc.Count, inc.Sample))
+			inc.DigestCount = 0
+			inc.NotifyMS = curMS
+		}
+	}
+	if len(incHistory) > incHisMax {
+		incHistory = incHistory[len(incHistory)-incHisMax:]
+	}
+	active := len(incActives) > 0
+	if !active {
+		incLooping = false
+	}
+	lockIncident.Unlock()
+	for _, text := range texts {
+		sendIncident(text)
+	}
+	return active
+}
+
+/*
+GetIncidents return active incidents, and resolved ones if withResolved, latest first
+返回活跃事件，withResolved为true时包含已恢复事件，按最后发生时间倒序
+*/
+func GetIncidents(withResolved bool) []*Incident {
+	lockIncident.Lock()
+	res := make([]*Incident, 0, len(incActives))
+	for _, inc := range incActives {
+		item := *inc
+		res = append(res, &item)
+	}
+	if withResolved {
+		for _, inc := range incHistory {
+			item := *inc
+			res = append(res, &item)
+		}
+	}
+	lockIncident.Unlock()
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].LastMS > res[j].LastMS
+	})
+	return res
+}
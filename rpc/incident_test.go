@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"testing"
+)
+
+func TestIncidents(t *testing.T) {
+	incDigestMS, incResolveMS = 1000, 5000
+	AddIncident("exg.go:10", "exg.go:10", "fetch ohlcv fail 1711800000 code: -1003", "a")
+	AddIncident("exg.go:10", "exg.go:10", "fetch ohlcv fail 1711800060 code: -1003", "b")
+	AddIncident("exg.go:20", "exg.go:20", "fetch ohlcv fail 1711800000 code: -1003", "c")
+	items := GetIncidents(false)
+	if len(items) != 2 {
+		t.Fatalf("expect 2 incidents, got %d", len(items))
+	}
+	var inc *Incident
+	for _, it := range items {
+		if it.Caller == "exg.go:10" {
+			inc = it
+		}
+	}
+	if inc == nil || inc.Count != 2 || inc.DigestCount != 1 || inc.Sample != "b" {
+		t.Fatalf("bad incident: %+v", inc)
+	}
+	if !checkIncidents(inc.LastMS + incDigestMS) {
+		t.Fatal("incidents should still be active before resolved")
+	}
+	items = GetIncidents(false)
+	for _, it := range items {
+		if it.DigestCount != 0 {
+			t.Fatalf("digest should reset count: %+v", it)
+		}
+	}
+	if checkIncidents(inc.LastMS + incDigestMS + incResolveMS) {
+		t.Fatal("incidents should be resolved")
+	}
+	if len(GetIncidents(false)) != 0 || len(GetIncidents(true)) != 2 {
+		t.Fatal("resolved incidents should move to history")
+	}
+}
@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"github.com/banbox/banexg/log"
+	"github.com/sasha-s/go-deadlock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type FnLogLine func(level string, line string)
+
+var (
+	logSubs    []FnLogLine
+	lockLogSub deadlock.Mutex
+)
+
+/*
+NewLogSub
+A zap core which forwards log lines of INFO and above to listeners added by AddLogSub.
+将INFO及以上级别的日志行转发给AddLogSub添加的监听者的zap core
+*/
+func NewLogSub() *LogSub {
+	level := zap.NewAtomicLevel()
+	_ = level.UnmarshalText([]byte("INFO"))
+	encoder := log.NewTextEncoder(log.NewEncoderConfig(), false, false)
+	return &LogSub{
+		LevelEnabler: level,
+		enc: &ExcEncoder{
+			TextEncoder: encoder.(*log.TextEncoder),
+		},
+	}
+}
+
+type LogSub struct {
+	zapcore.LevelEnabler
+	enc *ExcEncoder
+}
+
+// AddLogSub add a listener for log lines 添加日志行监听
+func AddLogSub(cb FnLogLine) {
+	lockLogSub.Lock()
+	logSubs = append(logSubs, cb)
+	lockLogSub.Unlock()
+}
+
+func (h *LogSub) With(fields []zapcore.Field) zapcore.Core {
+	clone := &LogSub{
+		LevelEnabler: h.LevelEnabler,
+		enc:          h.enc.Clone(),
+	}
+	clone.enc.addFields(fields)
+	return clone
+}
+
+func (h *LogSub) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if h.Enabled(ent.Level) {
+		return ce.AddCore(ent, h)
+	}
+	return ce
+}
+
+func (h *LogSub) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	lockLogSub.Lock()
+	subs := logSubs
+	lockLogSub.Unlock()
+	if len(subs) == 0 {
+		return nil
+	}
+	buf, _ := h.enc.EncodeEntry(ent, fields)
+	line := buf.String()
+	buf.Free()
+	level := ent.Level.CapitalString()
+	for _, cb := range subs {
+		cb(level, line)
+	}
+	return nil
+}
+
+func (h *LogSub) Sync() error {
+	return nil
+}
@@ -101,6 +101,20 @@ func SendMsg(msg map[string]interface{}) {
 	}
 }
 
+/*
+SendReport 发送绩效报告：邮件渠道发送html(可带内嵌图片)，其他渠道发送text。
+不使用webhook模板，需渠道的msg_types为空或包含report
+*/
+func SendReport(account, subject, text, html string, images map[string][]byte) {
+	for _, chl := range channels {
+		if email, ok := chl.(*Email); ok && html != "" {
+			email.SendHtml(MsgTypeReport, account, subject, html, images)
+			continue
+		}
+		chl.SendMsg(MsgTypeReport, account, map[string]string{"content": text})
+	}
+}
+
 func CleanUp() {
 	for _, chl := range channels {
 		chl.SetDisable(true)
@@ -4,8 +4,10 @@ import (
 	"sync"
 	"context"
 	"fmt"
+	"html"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -33,6 +35,7 @@ import (
 //
 // 通过 SendMsg -> Queue -> doSendMsgs 的链路实现异步批量发送与失败重试
 // 实际发送调用 Telegram Bot API 的 sendMessage 接口。
+// 命令回复和键盘按钮文本通过 config.GetLangMsg 按 show_lang_code 本地化，键为 tg_ 前缀。
 
 var (
 	telegramInstances = make(map[string]*Telegram)
@@ -41,6 +44,11 @@ var (
 	orderManager OrderManagerInterface
 )
 
+const (
+	tgDivider   = "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+	tgMaxGroups = 20 // /performance 最多显示的分组数
+)
+
 // OrderInfo 订单信息结构
 type OrderInfo struct {
 	ID       int64   `json:"id"`
@@ -52,12 +60,50 @@ type OrderInfo struct {
 	Account  string  `json:"account"`
 }
 
+// BalanceItem 钱包中单个币种的余额
+type BalanceItem struct {
+	Coin      string  `json:"coin"`
+	Total     float64 `json:"total"`
+	Free      float64 `json:"free"`
+	Used      float64 `json:"used"`
+	UPol      float64 `json:"upol"`
+	TotalFiat float64 `json:"total_fiat"`
+}
+
+// ProfitSta 已平仓订单和当前持仓的收益统计
+type ProfitSta struct {
+	DoneNum       int     `json:"done_num"`
+	WinNum        int     `json:"win_num"`
+	DoneProfit    float64 `json:"done_profit"`
+	DoneProfitPct float64 `json:"done_profit_pct"`
+	OpenNum       int     `json:"open_num"`
+	OpenProfit    float64 `json:"open_profit"`
+	BestPair      string  `json:"best_pair"`
+	BestRate      float64 `json:"best_rate"`
+}
+
+// PerfItem 已平仓订单的分组统计
+type PerfItem struct {
+	Key       string  `json:"key"`
+	CloseNum  int     `json:"close_num"`
+	WinNum    int     `json:"win_num"`
+	ProfitSum float64 `json:"profit_sum"`
+	ProfitPct float64 `json:"profit_pct"`
+	HoldHours float64 `json:"hold_hours"`
+}
+
 // OrderManagerInterface 订单管理接口，避免循环依赖
 type OrderManagerInterface interface {
 	GetActiveOrders(account string) ([]*OrderInfo, error)
 	CloseOrder(account string, orderID int64) error
 	CloseAllOrders(account string) (int, int, error) // success count, failed count, error
 	GetOrderStats(account string) (longCount, shortCount int, err error)
+	GetBalance(account string) ([]*BalanceItem, float64, error) // items, total fiat value, error
+	GetProfitSta(account string, days int) (*ProfitSta, error)
+	GetPerformance(account, groupBy string, days int) ([]*PerfItem, error)
+	PauseStrategy(account, stratName string, pause bool) error
+	GetPausedStrats(account string) []string
+	ForceExitPair(account, pair string) (int, int, error) // success count, failed count, error
 }
 
 // SetOrderManager 设置订单管理器（由外部调用）
@@ -65,6 +111,18 @@ func SetOrderManager(mgr OrderManagerInterface) {
 	orderManager = mgr
 }
 
+// tgText 获取本地化的Telegram文本，defVal为中文默认值
+func tgText(code, defVal string) string {
+	return config.GetLangMsg(config.ShowLangCode, "tg_"+code, defVal)
+}
+
+// tgAccounts 返回排序后的账户列表
+func tgAccounts() []string {
+	res := utils.KeysOfMap(config.Accounts)
+	slices.Sort(res)
+	return res
+}
+
 type Telegram struct {
 	*WebHook
 	token           string
@@ -76,6 +134,12 @@ type Telegram struct {
 	tradingDisabled map[string]time.Time // account -> disabled until time
 }
 
+// tgButton 回复键盘按钮，文本同时用于注册处理器
+type tgButton struct {
+	text    string
+	handler bot.HandlerFunc
+}
+
 // NewTelegram 构造函数，基于通用 WebHook 创建 Telegram 发送实例
 func NewTelegram(name string, item map[string]interface{}) *Telegram {
 	hook := NewWebHook(name, item)
@@ -184,7 +248,7 @@ func makeDoSendMsgTelegram(t *Telegram) func([]map[string]string) []map[string]s
 				continue
 			}
 			if i > 0 {
-				b.WriteString("\n\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+				b.WriteString("\n\n" + tgDivider + "\n\n")
 			}
 			b.WriteString(content)
 		}
@@ -219,6 +283,35 @@ func makeDoSendMsgTelegram(t *Telegram) func([]map[string]string) []map[string]s
 	}
 }
 
+// keyboardButtons 返回菜单键盘按钮，按行排列
+func (t *Telegram) keyboardButtons() [][]tgButton {
+	return [][]tgButton{
+		{
+			{tgText("kb_orders", "📊 查看订单"), t.handleKeyboardOrdersCommand},
+			{tgText("kb_status", "📈 开单状态"), t.handleKeyboardStatusCommand},
+		},
+		{
+			{tgText("kb_balance", "💰 账户余额"), t.handleBalanceCommand},
+			{tgText("kb_profit", "💹 收益统计"), t.handleProfitCommand},
+		},
+		{
+			{tgText("kb_performance", "🏆 交易表现"), t.handlePerformanceCommand},
+			{tgText("kb_daily", "📅 每日收益"), t.handleDailyCommand},
+		},
+		{
+			{tgText("kb_disable", "🚫 禁止开单"), t.handleKeyboardDisableCommand},
+			{tgText("kb_enable", "✅ 启用开单"), t.handleKeyboardEnableCommand},
+		},
+		{
+			{tgText("kb_close_all", "❌ 平仓所有"), t.handleKeyboardCloseAllCommand},
+			{tgText("kb_refresh", "🔄 刷新菜单"), t.handleMenuCommand},
+		},
+		{
+			{tgText("kb_hide", "❌ 隐藏菜单"), t.handleHideMenuCommand},
+		},
+	}
+}
+
 // setupCommandHandlers 设置Telegram Bot命令处理器
 func (t *Telegram) setupCommandHandlers() {
 	// 注册命令处理器
@@ -227,17 +320,24 @@ func (t *Telegram) setupCommandHandlers() {
 	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/status", bot.MatchTypeExact, t.handleStatusCommand)
 	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/disable", bot.MatchTypePrefix, t.handleDisableCommand)
 	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/enable", bot.MatchTypeExact, t.handleEnableCommand)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/balance", bot.MatchTypeExact, t.handleBalanceCommand)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/profit", bot.MatchTypePrefix, t.handleProfitCommand)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/performance", bot.MatchTypePrefix, t.handlePerformanceCommand)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/daily", bot.MatchTypePrefix, t.handleDailyCommand)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/pause", bot.MatchTypePrefix, t.handlePauseCommand)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/resume", bot.MatchTypePrefix, t.handleResumeCommand)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/forceexit", bot.MatchTypePrefix, t.handleForceExitCommand)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/confirm", bot.MatchTypePrefix, t.handleConfirmCommand)
+	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/cancel", bot.MatchTypePrefix, t.handleCancelCommand)
 	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/help", bot.MatchTypeExact, t.handleHelpCommand)
 	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "/menu", bot.MatchTypeExact, t.handleMenuCommand)
 	
 	// 注册键盘按钮处理器
-	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "📊 查看订单", bot.MatchTypeExact, t.handleKeyboardOrdersCommand)
-	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "📈 开单状态", bot.MatchTypeExact, t.handleKeyboardStatusCommand)
-	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "🚫 禁止开单", bot.MatchTypeExact, t.handleKeyboardDisableCommand)
-	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "✅ 启用开单", bot.MatchTypeExact, t.handleKeyboardEnableCommand)
-	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "❌ 平仓所有", bot.MatchTypeExact, t.handleKeyboardCloseAllCommand)
-	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "🔄 刷新菜单", bot.MatchTypeExact, t.handleMenuCommand)
-	t.bot.RegisterHandler(bot.HandlerTypeMessageText, "❌ 隐藏菜单", bot.MatchTypeExact, t.handleHideMenuCommand)
+	for _, row := range t.keyboardButtons() {
+		for _, btn := range row {
+			t.bot.RegisterHandler(bot.HandlerTypeMessageText, btn.text, bot.MatchTypeExact, btn.handler)
+		}
+	}
 	
 	// 注册内联键盘回调处理器
 	t.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "", bot.MatchTypePrefix, t.handleCallbackQuery)
@@ -273,17 +373,22 @@ func (t *Telegram) handleCloseCommand(ctx context.Context, b *bot.Bot, update *m
 	
 	parts := strings.Fields(update.Message.Text)
 	if len(parts) < 2 {
-		response := "❌ <b>用法错误</b>\n\n" +
-			"请使用: <code>/close [订单ID|all]</code>\n\n" +
-			"示例:\n" +
-			"• <code>/close 123</code> - 平仓指定订单\n" +
-			"• <code>/close all</code> - 平仓所有订单"
+		response := tgText("close_usage", "❌ <b>用法错误</b>\n\n"+
+			"请使用: <code>/close [订单ID|all]</code>\n\n"+
+			"示例:\n"+
+			"• <code>/close 123</code> - 平仓指定订单\n"+
+			"• <code>/close all</code> - 平仓所有订单")
 		t.sendResponse(b, update, response)
 		return
 	}
 	
 	orderID := parts[1]
-	response := t.closeOrders(orderID)
+	var response string
+	if orderID == "all" {
+		response = t.guardAction(ActCloseAll, "all orders", tgUser(update.Message.From), t.closeAllOrders)
+	} else {
+		response = t.closeOrders(orderID)
+	}
 	t.sendResponse(b, update, response)
 }
 
@@ -326,29 +431,177 @@ func (t *Telegram) handleEnableCommand(ctx context.Context, b *bot.Bot, update *
 	t.sendResponse(b, update, response)
 }
 
+// handleBalanceCommand 处理 /balance 命令 - 查看账户余额
+func (t *Telegram) handleBalanceCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if !t.isAuthorized(update) {
+		return
+	}
+
+	t.sendResponse(b, update, t.getBalance())
+}
+
+// handleProfitCommand 处理 /profit [天数] 命令 - 查看收益统计，默认全部
+func (t *Telegram) handleProfitCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if !t.isAuthorized(update) {
+		return
+	}
+
+	days := parseDaysArg(update.Message.Text, 1, 0)
+	t.sendResponse(b, update, t.getProfit(days))
+}
+
+// handlePerformanceCommand 处理 /performance [分组] [天数] 命令 - 按分组查看已平仓订单表现
+func (t *Telegram) handlePerformanceCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if !t.isAuthorized(update) {
+		return
+	}
+
+	groupBy, days := "symbol", 0
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) >= 2 && strings.HasPrefix(parts[0], "/") {
+		if _, err := strconv.Atoi(parts[1]); err == nil {
+			// 省略分组，直接指定天数
+			days = parseDaysArg(update.Message.Text, 1, 0)
+		} else {
+			groupBy = parts[1]
+			days = parseDaysArg(update.Message.Text, 2, 0)
+		}
+	}
+	t.sendResponse(b, update, t.getPerformance(groupBy, days))
+}
+
+// handleDailyCommand 处理 /daily [天数] 命令 - 查看每日已实现收益，默认7天
+func (t *Telegram) handleDailyCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if !t.isAuthorized(update) {
+		return
+	}
+
+	days := parseDaysArg(update.Message.Text, 1, 7)
+	t.sendResponse(b, update, t.getDaily(days))
+}
+
+// handlePauseCommand 处理 /pause <策略> 命令 - 暂停策略新开单
+func (t *Telegram) handlePauseCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if !t.isAuthorized(update) {
+		return
+	}
+
+	t.sendResponse(b, update, t.pauseStrategy(update.Message.Text, true))
+}
+
+// handleResumeCommand 处理 /resume <策略> 命令 - 恢复策略新开单
+func (t *Telegram) handleResumeCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if !t.isAuthorized(update) {
+		return
+	}
+
+	t.sendResponse(b, update, t.pauseStrategy(update.Message.Text, false))
+}
+
+// handleForceExitCommand 处理 /forceexit <品种> 命令 - 强制平仓品种的所有订单
+func (t *Telegram) handleForceExitCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if !t.isAuthorized(update) {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 2 {
+		response := tgText("forceexit_usage", "❌ <b>用法错误</b>\n\n"+
+			"请使用: <code>/forceexit [品种]</code>\n\n"+
+			"示例: <code>/forceexit BTC/USDT:USDT</code> 或 <code>/forceexit BTC</code>")
+		t.sendResponse(b, update, response)
+		return
+	}
+
+	pair := parts[1]
+	response := t.guardAction(ActForceExit, pair, tgUser(update.Message.From), func() string {
+		return t.forceExitPair(pair)
+	})
+	t.sendResponse(b, update, response)
+}
+
+// handleConfirmCommand 处理 /confirm <确认码> 命令 - 确认并执行待确认的危险操作
+func (t *Telegram) handleConfirmCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if !t.isAuthorized(update) {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 2 {
+		t.sendResponse(b, update, tgText("confirm_usage", "❌ <b>用法错误</b>\n\n请使用: <code>/confirm [确认码]</code>"))
+		return
+	}
+
+	p, res, err := ConfirmAction(parts[1], tgUser(update.Message.From))
+	if err != nil {
+		t.sendResponse(b, update, fmt.Sprintf(tgText("confirm_fail", "❌ <b>确认失败</b>: %s"), html.EscapeString(err.Short())))
+		return
+	}
+	response := fmt.Sprintf(tgText("confirm_ok", "✅ <b>已确认</b> <code>%s</code>"), p.Action) + "\n\n"
+	if text, ok := res.(string); ok {
+		response += text
+	}
+	t.sendResponse(b, update, response)
+}
+
+// handleCancelCommand 处理 /cancel <确认码> 命令 - 取消待确认的危险操作
+func (t *Telegram) handleCancelCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if !t.isAuthorized(update) {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 2 {
+		t.sendResponse(b, update, tgText("cancel_usage", "❌ <b>用法错误</b>\n\n请使用: <code>/cancel [确认码]</code>"))
+		return
+	}
+
+	p, err := CancelAction(parts[1], tgUser(update.Message.From))
+	if err != nil {
+		t.sendResponse(b, update, fmt.Sprintf(tgText("cancel_fail", "❌ <b>取消失败</b>: %s"), html.EscapeString(err.Short())))
+		return
+	}
+	t.sendResponse(b, update, fmt.Sprintf(tgText("cancel_ok", "🚫 <b>已取消</b> <code>%s</code>"), p.Action))
+}
+
 // handleHelpCommand 处理 /help 命令 - 显示帮助信息
 func (t *Telegram) handleHelpCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
 	if !t.isAuthorized(update) {
 		return
 	}
 	
-	response := "🤖 <b>BanBot Telegram 命令帮助</b>\n\n" +
-		"<b>订单管理:</b>\n" +
-		"• <code>/menu</code> - 显示操作菜单（推荐）\n" +
-		"• <code>/orders</code> - 查看当前订单列表\n" +
-		"• <code>/close [订单ID|all]</code> - 平仓指定订单或所有订单\n\n" +
-		"<b>交易控制:</b>\n" +
-		"• <code>/status</code> - 查看当前交易状态\n" +
-		"• <code>/disable [小时]</code> - 禁止开单(默认1小时)\n" +
-		"• <code>/enable</code> - 重新启用开单\n\n" +
-		"<b>其他:</b>\n" +
-		"• <code>/help</code> - 显示此帮助信息\n\n" +
-		"💡 <i>提示：使用 /menu 命令可获得更便捷的按钮操作界面</i>\n" +
-		"━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+	response := tgText("help", "🤖 <b>BanBot Telegram 命令帮助</b>\n\n"+
+		"<b>订单管理:</b>\n"+
+		"• <code>/menu</code> - 显示操作菜单（推荐）\n"+
+		"• <code>/orders</code> - 查看当前订单列表\n"+
+		"• <code>/close [订单ID|all]</code> - 平仓指定订单或所有订单\n"+
+		"• <code>/forceexit [品种]</code> - 强制平仓指定品种的所有订单\n"+
+		"• <code>/confirm [确认码]</code> - 确认危险操作\n"+
+		"• <code>/cancel [确认码]</code> - 取消危险操作\n\n"+
+		"<b>账户统计:</b>\n"+
+		"• <code>/balance</code> - 查看账户余额\n"+
+		"• <code>/profit [天数]</code> - 查看收益统计(默认全部)\n"+
+		"• <code>/performance [symbol|strategy|enterTag|exitTag] [天数]</code> - 分组查看交易表现\n"+
+		"• <code>/daily [天数]</code> - 查看每日收益(默认7天)\n\n"+
+		"<b>交易控制:</b>\n"+
+		"• <code>/status</code> - 查看当前交易状态\n"+
+		"• <code>/disable [小时]</code> - 禁止开单(默认1小时)\n"+
+		"• <code>/enable</code> - 重新启用开单\n"+
+		"• <code>/pause [策略]</code> - 暂停策略开单\n"+
+		"• <code>/resume [策略]</code> - 恢复策略开单\n\n"+
+		"<b>其他:</b>\n"+
+		"• <code>/help</code> - 显示此帮助信息\n\n"+
+		"💡 <i>提示：使用 /menu 命令可获得更便捷的按钮操作界面</i>\n") + tgDivider
 	
 	t.sendResponse(b, update, response)
 }
 
+// menuText 返回菜单标题文本
+func menuText() string {
+	return tgText("menu_title", "🎛️ <b>BanBot 操作菜单</b>") + "\n" + tgDivider + "\n\n" +
+		tgText("menu_choose", "请选择您要执行的操作：") + "\n\n" + tgDivider
+}
+
 // handleMenuCommand 处理 /menu 命令 - 显示主菜单
 func (t *Telegram) handleMenuCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
 	if !t.isAuthorized(update) {
@@ -356,38 +609,23 @@ func (t *Telegram) handleMenuCommand(ctx context.Context, b *bot.Bot, update *mo
 	}
 
 	// 创建 Reply Keyboard（显示在键盘上）
+	var rows [][]models.KeyboardButton
+	for _, row := range t.keyboardButtons() {
+		btnRow := make([]models.KeyboardButton, 0, len(row))
+		for _, btn := range row {
+			btnRow = append(btnRow, models.KeyboardButton{Text: btn.text})
+		}
+		rows = append(rows, btnRow)
+	}
 	kb := &models.ReplyKeyboardMarkup{
-		Keyboard: [][]models.KeyboardButton{
-			{
-				{Text: "📊 查看订单"},
-				{Text: "📈 开单状态"},
-			},
-			{
-				{Text: "🚫 禁止开单"},
-				{Text: "✅ 启用开单"},
-			},
-			{
-				{Text: "❌ 平仓所有"},
-				{Text: "🔄 刷新菜单"},
-			},
-			{
-				{Text: "❌ 隐藏菜单"},
-			},
-		},
+		Keyboard:        rows,
 		ResizeKeyboard:  true,
 		OneTimeKeyboard: false,
 	}
 
-	menuText := `🎛️ <b>BanBot 操作菜单</b>
-━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
-
-请选择您要执行的操作：
-
-━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━`
-
 	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID:      update.Message.Chat.ID,
-		Text:        menuText,
+		Text:        menuText(),
 		ParseMode:   models.ParseModeHTML,
 		ReplyMarkup: kb,
 	})
@@ -411,7 +649,7 @@ func (t *Telegram) handleCallbackQuery(ctx context.Context, b *bot.Bot, update *
 	// 先回应回调查询
 	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
 		CallbackQueryID: update.CallbackQuery.ID,
-		Text:           "处理中...",
+		Text:            tgText("processing", "处理中..."),
 	})
 	if err != nil {
 		log.Error("Failed to answer callback query", zap.Error(err))
@@ -483,22 +721,87 @@ func (t *Telegram) sendResponse(b *bot.Bot, update *models.Update, response stri
 	}
 }
 
+// tgUser 返回Telegram用户在确认和审计日志中的标识
+func tgUser(from *models.User) string {
+	if from == nil {
+		return "tg:"
+	}
+	return fmt.Sprintf("tg:%d", from.ID)
+}
+
+// guardAction 启用确认时创建待确认操作并返回确认码提示，否则直接执行run
+func (t *Telegram) guardAction(action, detail, user string, run func() string) string {
+	if !ConfirmEnabled() {
+		return run()
+	}
+	p := RequestConfirm(action, detail, user, func() (interface{}, error) {
+		return run(), nil
+	})
+	var response strings.Builder
+	response.WriteString(tgText("confirm_title", "🔐 <b>需要确认</b>") + "\n")
+	response.WriteString(tgDivider + "\n\n")
+	response.WriteString(fmt.Sprintf(tgText("confirm_action", "⚠️ 操作: <code>%s</code>\n📝 详情: <code>%s</code>")+"\n",
+		p.Action, html.EscapeString(p.Detail)))
+	response.WriteString(fmt.Sprintf(tgText("confirm_code", "🔑 确认码: <code>%s</code> (%d秒内有效)")+"\n\n",
+		p.Code, (p.ExpireMS-p.CreateMS)/1000))
+	if p.NeedApprover {
+		response.WriteString(fmt.Sprintf(tgText("confirm_tip_approver", "👥 需由其他审批人发送 <code>/confirm %s</code> 确认"), p.Code))
+	} else {
+		response.WriteString(fmt.Sprintf(tgText("confirm_tip", "💡 发送 <code>/confirm %s</code> 确认执行"), p.Code))
+	}
+	response.WriteString("\n" + fmt.Sprintf(tgText("confirm_cancel_tip", "🚫 发送 <code>/cancel %s</code> 取消"), p.Code))
+	response.WriteString("\n" + tgDivider)
+	return response.String()
+}
+
+// accountTitle 返回账户标题行
+func accountTitle(account string) string {
+	return fmt.Sprintf(tgText("account", "🏷️ <b>账户:</b> <code>%s</code>")+"\n", account)
+}
+
+// orderDirection 返回订单方向文本
+func orderDirection(short bool) string {
+	if short {
+		return tgText("dirt_short", "📉 空单")
+	}
+	return tgText("dirt_long", "📈 多单")
+}
+
+// parseDaysArg 解析命令中第index个参数为天数，无效时返回defVal
+func parseDaysArg(text string, index, defVal int) int {
+	parts := strings.Fields(text)
+	if len(parts) > index && strings.HasPrefix(parts[0], "/") {
+		if d, err := strconv.Atoi(parts[index]); err == nil && d >= 0 && d <= 3650 {
+			return d
+		}
+	}
+	return defVal
+}
+
+// daysTitle 返回统计时间范围文本，0表示全部
+func daysTitle(days int) string {
+	if days <= 0 {
+		return tgText("range_all", "全部")
+	}
+	return fmt.Sprintf(tgText("range_days", "最近%d天"), days)
+}
+
 // getOrdersList 获取订单列表
 func (t *Telegram) getOrdersList() string {
 	var response strings.Builder
-	response.WriteString("📊 <b>当前订单列表</b>\n")
-	response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	response.WriteString(tgText("orders_title", "📊 <b>当前订单列表</b>") + "\n")
+	response.WriteString(tgDivider + "\n\n")
 	
 	if orderManager == nil {
-		response.WriteString("❌ 订单管理器未初始化\n")
-		response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+		response.WriteString(tgText("no_mgr", "❌ 订单管理器未初始化") + "\n")
+		response.WriteString(tgDivider)
 		return response.String()
 	}
 	
 	totalOrders := 0
 	
 	// 遍历所有账户
-	for account := range config.Accounts {
+	for _, account := range tgAccounts() {
 		orders, err := orderManager.GetActiveOrders(account)
 		if err != nil {
 			log.Error("Failed to get orders", zap.String("account", account), zap.Error(err))
@@ -509,39 +812,27 @@ func (t *Telegram) getOrdersList() string {
 			continue
 		}
 		
-		response.WriteString(fmt.Sprintf("🏷️ <b>账户:</b> <code>%s</code>\n", account))
+		response.WriteString(accountTitle(account))
 		
 		for _, order := range orders {
 			totalOrders++
 			
-			// 订单方向
-			direction := "📈 多"
-			if order.Short {
-				direction = "📉 空"
-			}
-			
 			// 格式化订单信息
-			response.WriteString(fmt.Sprintf(
-				"• <code>%d</code> %s <code>%s</code>\n"+
-				"  💰 价格: <code>%.5f</code> | 数量: <code>%.4f</code>\n"+
-				"  📊 盈亏: <code>计算中...</code> | 标签: <code>%s</code>\n\n",
-				order.ID,
-				direction,
-				order.Symbol,
-				order.Price,
-				order.Amount,
-				order.EnterTag,
-			))
+			response.WriteString(fmt.Sprintf("• <code>%d</code> %s <code>%s</code>\n", order.ID,
+				orderDirection(order.Short), order.Symbol))
+			response.WriteString(fmt.Sprintf(tgText("order_price", "  💰 价格: <code>%.5f</code> | 数量: <code>%.4f</code>")+"\n",
+				order.Price, order.Amount))
+			response.WriteString(fmt.Sprintf(tgText("order_tag", "  🏷️ 标签: <code>%s</code>")+"\n\n", order.EnterTag))
 		}
 	}
 	
 	if totalOrders == 0 {
-		response.WriteString("暂无活跃订单\n")
+		response.WriteString(tgText("no_orders", "📭 <b>暂无活跃订单</b>") + "\n")
 	} else {
-		response.WriteString(fmt.Sprintf("总计: <b>%d</b> 个活跃订单", totalOrders))
+		response.WriteString(fmt.Sprintf(tgText("orders_total", "📊 <b>总计:</b> %d 个活跃订单"), totalOrders))
 	}
 	
-	response.WriteString("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	response.WriteString("\n" + tgDivider)
 	
 	return response.String()
 }
@@ -553,163 +844,396 @@ func (t *Telegram) closeOrders(orderID string) string {
 	}
 	
 	if orderManager == nil {
-		return "❌ <b>错误</b>: 订单管理器未初始化"
+		return tgText("no_mgr", "❌ 订单管理器未初始化")
 	}
 	
 	// 解析订单ID
 	id, err := strconv.ParseInt(orderID, 10, 64)
 	if err != nil {
-		return "❌ <b>错误</b>: 无效的订单ID"
+		return tgText("bad_order_id", "❌ <b>错误</b>: 无效的订单ID")
 	}
+
+	closeOk := tgText("close_ok", "✅ <b>平仓成功</b>\n\n📊 订单ID: <code>%d</code>\n🎯 账户: <code>%s</code>\n"+
+		"⏰ 时间: %s\n\n已提交平仓请求，请等待执行完成。")
 	
 	// 先尝试默认账户
 	defaultAccount := "default"
 	err = orderManager.CloseOrder(defaultAccount, id)
 	if err == nil {
-		return fmt.Sprintf("✅ <b>平仓成功</b>\n\n📊 订单ID: <code>%d</code>\n🎯 账户: <code>%s</code>\n⏰ 时间: %s\n\n已提交平仓请求，请等待执行完成。", 
-			id, defaultAccount, time.Now().Format("15:04:05"))
+		return fmt.Sprintf(closeOk, id, defaultAccount, time.Now().Format("15:04:05"))
 	}
 	
 	// 如果默认账户中没有找到，再查找其他账户
-	for account := range config.Accounts {
+	for _, account := range tgAccounts() {
 		if account == defaultAccount {
 			continue // 跳过已经尝试过的默认账户
 		}
 		err := orderManager.CloseOrder(account, id)
 		if err == nil {
-			return fmt.Sprintf("✅ <b>平仓成功</b>\n\n📊 订单ID: <code>%d</code>\n🎯 账户: <code>%s</code>\n⏰ 时间: %s\n\n已提交平仓请求，请等待执行完成。", 
-				id, account, time.Now().Format("15:04:05"))
+			return fmt.Sprintf(closeOk, id, account, time.Now().Format("15:04:05"))
 		}
 	}
 	
-	return fmt.Sprintf("❌ <b>订单未找到</b>\n\n📊 订单ID: <code>%d</code>\n⏰ 时间: %s\n\n请检查订单ID是否正确，或使用 <code>/orders</code> 查看当前活跃订单。", 
-		id, time.Now().Format("15:04:05"))
+	return fmt.Sprintf(tgText("close_not_found", "❌ <b>订单未找到</b>\n\n📊 订单ID: <code>%d</code>\n⏰ 时间: %s\n\n"+
+		"请检查订单ID是否正确，或使用 <code>/orders</code> 查看当前活跃订单。"), id, time.Now().Format("15:04:05"))
 }
 
 // closeAllOrders 平仓所有订单
 func (t *Telegram) closeAllOrders() string {
 	var response strings.Builder
-	response.WriteString("🔄 <b>批量平仓结果</b>\n")
-	response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	response.WriteString(tgText("close_all_title", "🔄 <b>批量平仓结果</b>") + "\n")
+	response.WriteString(tgDivider + "\n\n")
 	
 	if orderManager == nil {
-		response.WriteString("❌ 订单管理器未初始化\n")
-		response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+		response.WriteString(tgText("no_mgr", "❌ 订单管理器未初始化") + "\n")
+		response.WriteString(tgDivider)
 		return response.String()
 	}
 	
 	totalClosed := 0
 	totalFailed := 0
 	
-	for account := range config.Accounts {
-		response.WriteString(fmt.Sprintf("🏷️ <b>账户:</b> <code>%s</code>\n", account))
+	for _, account := range tgAccounts() {
+		response.WriteString(accountTitle(account))
 		
 		successCount, failedCount, err := orderManager.CloseAllOrders(account)
 		if err != nil {
-			response.WriteString(fmt.Sprintf("  ❌ 获取订单失败: %s\n", err.Error()))
+			response.WriteString(fmt.Sprintf(tgText("get_orders_fail", "  ❌ 获取订单失败: %s")+"\n", err.Error()))
 			continue
 		}
 		
 		totalClosed += successCount
 		totalFailed += failedCount
 		
-		response.WriteString(fmt.Sprintf("  ✅ 成功: %d | ❌ 失败: %d\n", successCount, failedCount))
-		response.WriteString("\n")
+		response.WriteString(fmt.Sprintf(tgText("close_res", "  ✅ 成功: %d | ❌ 失败: %d")+"\n\n", successCount, failedCount))
 	}
 	
-	response.WriteString(fmt.Sprintf("📊 <b>统计:</b> 成功 %d | 失败 %d", totalClosed, totalFailed))
-	response.WriteString("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	response.WriteString(fmt.Sprintf(tgText("close_all_sum", "📊 <b>统计:</b> 成功 %d | 失败 %d"), totalClosed, totalFailed))
+	response.WriteString("\n" + tgDivider)
 	
 	return response.String()
 }
 
 // getOrdersListWithKeyboard 获取订单列表并返回是否有订单的标志
 func (t *Telegram) getOrdersListWithKeyboard(account string) (string, bool) {
+	text, num := t.getAccOrdersText(account, false)
+	return text, num > 0
+}
+
+// getAccOrdersText 获取指定账户的活跃订单文本，withClose为true时附带平仓命令提示
+func (t *Telegram) getAccOrdersText(account string, withClose bool) (string, int) {
 	var response strings.Builder
-	response.WriteString("📊 <b>活跃订单列表</b>\n")
-	response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	response.WriteString(tgText("active_orders_title", "📊 <b>活跃订单列表</b>") + "\n")
+	response.WriteString(tgDivider + "\n\n")
 	
 	if orderManager == nil {
-		response.WriteString("❌ 订单管理器未初始化\n")
-		response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		return response.String(), false
+		response.WriteString(tgText("no_mgr", "❌ 订单管理器未初始化") + "\n")
+		response.WriteString(tgDivider)
+		return response.String(), 0
 	}
 	
-	hasOrders := false
 	totalOrders := 0
 	
 	// 获取指定账户的订单
 	orders, err := orderManager.GetActiveOrders(account)
 	if err != nil {
 		log.Error("Failed to get orders", zap.String("account", account), zap.Error(err))
-		response.WriteString(fmt.Sprintf("❌ 获取账户 %s 订单失败: %v\n", account, err))
+		response.WriteString(fmt.Sprintf(tgText("get_acc_orders_fail", "❌ 获取账户 %s 订单失败: %v")+"\n", account, err))
 	} else if len(orders) > 0 {
-		hasOrders = true
-		response.WriteString(fmt.Sprintf("🏷️ <b>账户:</b> %s\n\n", account))
+		response.WriteString(accountTitle(account) + "\n")
 		
 		for _, order := range orders {
 			totalOrders++
-			direction := "📈 多单"
-			if order.Short {
-				direction = "📉 空单"
-			}
-			
-			response.WriteString(fmt.Sprintf("• <code>%d</code> %s <code>%s</code>\n", order.ID, direction, order.Symbol))
-			response.WriteString(fmt.Sprintf("  💰 价格: <code>%.5f</code> | 数量: <code>%.4f</code>\n", order.Price, order.Amount))
+			response.WriteString(fmt.Sprintf("• <code>%d</code> %s <code>%s</code>\n", order.ID,
+				orderDirection(order.Short), order.Symbol))
+			response.WriteString(fmt.Sprintf(tgText("order_price", "  💰 价格: <code>%.5f</code> | 数量: <code>%.4f</code>")+"\n",
+				order.Price, order.Amount))
 			if order.EnterTag != "" {
-				response.WriteString(fmt.Sprintf("  🏷️ 标签: <code>%s</code>\n", order.EnterTag))
+				response.WriteString(fmt.Sprintf(tgText("order_tag", "  🏷️ 标签: <code>%s</code>")+"\n", order.EnterTag))
+			}
+			if withClose {
+				response.WriteString(fmt.Sprintf(tgText("order_close_cmd", "  💡 平仓命令: <code>/close %d</code>")+"\n", order.ID))
 			}
 			response.WriteString("\n")
 		}
 	}
 	
 	if totalOrders == 0 {
-		response.WriteString("📭 <b>暂无活跃订单</b>\n")
-		response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+		response.WriteString(tgText("no_orders", "📭 <b>暂无活跃订单</b>") + "\n")
+		response.WriteString(tgDivider)
 	} else {
-		response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-		response.WriteString(fmt.Sprintf("📊 <b>总计:</b> %d 个活跃订单", totalOrders))
+		response.WriteString(tgDivider + "\n")
+		response.WriteString(fmt.Sprintf(tgText("orders_total", "📊 <b>总计:</b> %d 个活跃订单"), totalOrders))
+		if withClose {
+			response.WriteString("\n" + tgText("close_tip", "💡 <b>提示:</b> 点击上方平仓命令或直接输入 "+
+				"<code>/close [订单ID]</code> 来平仓指定订单"))
+		}
 	}
 	
-	return response.String(), hasOrders
+	return response.String(), totalOrders
 }
 
 // getTradingStatus 获取交易状态
 func (t *Telegram) getTradingStatus() string {
 	var response strings.Builder
-	response.WriteString("📊 <b>交易状态</b>\n")
-	response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	response.WriteString(tgText("status_title", "📊 <b>交易状态</b>") + "\n")
+	response.WriteString(tgDivider + "\n\n")
 	
 	now := time.Now()
 	
-	for account := range config.Accounts {
-		response.WriteString(fmt.Sprintf("🏷️ <b>账户:</b> <code>%s</code>\n", account))
+	for _, account := range tgAccounts() {
+		response.WriteString(accountTitle(account))
 		
 		// 检查是否被禁用
 		if disabledUntil, exists := t.tradingDisabled[account]; exists && now.Before(disabledUntil) {
 			remaining := disabledUntil.Sub(now)
-			response.WriteString(fmt.Sprintf("  🚫 <b>状态:</b> 开单已禁用\n"))
-			response.WriteString(fmt.Sprintf("  ⏰ <b>剩余:</b> %s\n", formatDuration(remaining)))
+			response.WriteString(tgText("status_disabled", "  🚫 <b>状态:</b> 开单已禁用") + "\n")
+			response.WriteString(fmt.Sprintf(tgText("status_remain", "  ⏰ <b>剩余:</b> %s")+"\n", formatDuration(remaining)))
 		} else {
-			response.WriteString("  ✅ <b>状态:</b> 开单正常\n")
+			response.WriteString(tgText("status_normal", "  ✅ <b>状态:</b> 开单正常") + "\n")
 		}
 		
-		// 获取当前订单数量
+		// 获取当前订单数量和已暂停的策略
 		if orderManager != nil {
 			longCount, shortCount, err := orderManager.GetOrderStats(account)
 			if err == nil {
-				response.WriteString(fmt.Sprintf("  📈 <b>多单:</b> %d | 📉 <b>空单:</b> %d\n", longCount, shortCount))
+				response.WriteString(fmt.Sprintf(tgText("status_od_num", "  📈 <b>多单:</b> %d | 📉 <b>空单:</b> %d")+"\n",
+					longCount, shortCount))
+			}
+			if paused := orderManager.GetPausedStrats(account); len(paused) > 0 {
+				response.WriteString(fmt.Sprintf(tgText("status_paused", "  ⏸️ <b>暂停策略:</b> <code>%s</code>")+"\n",
+					html.EscapeString(strings.Join(paused, ", "))))
 			}
 		}
 		
 		response.WriteString("\n")
 	}
 	
-	response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	response.WriteString(tgDivider)
 	
 	return response.String()
 }
 
+// getBalance 获取所有账户的余额
+func (t *Telegram) getBalance() string {
+	var response strings.Builder
+	response.WriteString(tgText("balance_title", "💰 <b>账户余额</b>") + "\n")
+	response.WriteString(tgDivider + "\n\n")
+
+	if orderManager == nil {
+		response.WriteString(tgText("no_mgr", "❌ 订单管理器未初始化") + "\n")
+		response.WriteString(tgDivider)
+		return response.String()
+	}
+
+	for _, account := range tgAccounts() {
+		response.WriteString(accountTitle(account))
+		items, total, err := orderManager.GetBalance(account)
+		if err != nil {
+			response.WriteString(fmt.Sprintf(tgText("query_fail", "  ❌ 查询失败: %s")+"\n\n", err.Error()))
+			continue
+		}
+		for _, it := range items {
+			response.WriteString(fmt.Sprintf("• <code>%s</code> %.4f ≈ <code>%.2f</code>\n", it.Coin, it.Total, it.TotalFiat))
+			response.WriteString(fmt.Sprintf(tgText("balance_item", "  可用: %.4f | 占用: %.4f | 未实现盈亏: %.4f")+"\n",
+				it.Free, it.Used, it.UPol))
+		}
+		response.WriteString(fmt.Sprintf(tgText("balance_total", "💵 <b>总价值:</b> <code>%.2f</code>")+"\n\n", total))
+	}
+
+	response.WriteString(tgDivider)
+	return response.String()
+}
+
+// getProfit 获取所有账户的收益统计，days为0表示全部
+func (t *Telegram) getProfit(days int) string {
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf(tgText("profit_title", "💹 <b>收益统计</b> (%s)")+"\n", daysTitle(days)))
+	response.WriteString(tgDivider + "\n\n")
+
+	if orderManager == nil {
+		response.WriteString(tgText("no_mgr", "❌ 订单管理器未初始化") + "\n")
+		response.WriteString(tgDivider)
+		return response.String()
+	}
+
+	for _, account := range tgAccounts() {
+		response.WriteString(accountTitle(account))
+		sta, err := orderManager.GetProfitSta(account, days)
+		if err != nil {
+			response.WriteString(fmt.Sprintf(tgText("query_fail", "  ❌ 查询失败: %s")+"\n\n", err.Error()))
+			continue
+		}
+		winRate := float64(sta.WinNum) / float64(max(1, sta.DoneNum)) * 100
+		response.WriteString(fmt.Sprintf(tgText("profit_done", "  ✅ <b>已平仓:</b> %d | 胜率: %.1f%%")+"\n",
+			sta.DoneNum, winRate))
+		response.WriteString(fmt.Sprintf(tgText("profit_done_pft", "  💰 <b>已实现盈亏:</b> <code>%.4f</code> (%.2f%%)")+"\n",
+			sta.DoneProfit, sta.DoneProfitPct))
+		response.WriteString(fmt.Sprintf(tgText("profit_open", "  📂 <b>持仓:</b> %d | 浮动盈亏: <code>%.4f</code>")+"\n",
+			sta.OpenNum, sta.OpenProfit))
+		if sta.BestPair != "" {
+			response.WriteString(fmt.Sprintf(tgText("profit_best", "  🏆 <b>最佳:</b> <code>%s</code> (%.2f%%)")+"\n",
+				sta.BestPair, sta.BestRate*100))
+		}
+		response.WriteString("\n")
+	}
+
+	response.WriteString(tgDivider)
+	return response.String()
+}
+
+// getPerformance 获取按groupBy分组的已平仓订单表现
+func (t *Telegram) getPerformance(groupBy string, days int) string {
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf(tgText("perf_title", "🏆 <b>交易表现</b> (%s, %s)")+"\n", html.EscapeString(groupBy),
+		daysTitle(days)))
+	response.WriteString(tgDivider + "\n\n")
+
+	if orderManager == nil {
+		response.WriteString(tgText("no_mgr", "❌ 订单管理器未初始化") + "\n")
+		response.WriteString(tgDivider)
+		return response.String()
+	}
+
+	for _, account := range tgAccounts() {
+		response.WriteString(accountTitle(account))
+		items, err := orderManager.GetPerformance(account, groupBy, days)
+		if err != nil {
+			response.WriteString(fmt.Sprintf(tgText("query_fail", "  ❌ 查询失败: %s")+"\n\n", html.EscapeString(err.Error())))
+			continue
+		}
+		if len(items) == 0 {
+			response.WriteString(tgText("no_done_orders", "  📭 暂无已平仓订单") + "\n\n")
+			continue
+		}
+		for i, it := range items {
+			if i >= tgMaxGroups {
+				response.WriteString(fmt.Sprintf(tgText("perf_more", "  ... 另有 %d 项未显示")+"\n", len(items)-i))
+				break
+			}
+			response.WriteString(fmt.Sprintf(tgText("perf_item", "%d. <code>%s</code> %d单 胜率%.0f%% | "+
+				"盈亏 <code>%.4f</code> (%.2f%%) | 持仓%.1f小时")+"\n", i+1, html.EscapeString(it.Key), it.CloseNum,
+				float64(it.WinNum)/float64(max(1, it.CloseNum))*100, it.ProfitSum, it.ProfitPct, it.HoldHours))
+		}
+		response.WriteString("\n")
+	}
+
+	response.WriteString(tgDivider)
+	return response.String()
+}
+
+// getDaily 获取最近days天每日已实现收益
+func (t *Telegram) getDaily(days int) string {
+	if days <= 0 {
+		days = 7
+	}
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf(tgText("daily_title", "📅 <b>每日收益</b> (%s)")+"\n", daysTitle(days)))
+	response.WriteString(tgDivider + "\n\n")
+
+	if orderManager == nil {
+		response.WriteString(tgText("no_mgr", "❌ 订单管理器未初始化") + "\n")
+		response.WriteString(tgDivider)
+		return response.String()
+	}
+
+	for _, account := range tgAccounts() {
+		response.WriteString(accountTitle(account))
+		items, err := orderManager.GetPerformance(account, "day", days)
+		if err != nil {
+			response.WriteString(fmt.Sprintf(tgText("query_fail", "  ❌ 查询失败: %s")+"\n\n", err.Error()))
+			continue
+		}
+		if len(items) == 0 {
+			response.WriteString(tgText("no_done_orders", "  📭 暂无已平仓订单") + "\n\n")
+			continue
+		}
+		var total float64
+		for _, it := range items {
+			total += it.ProfitSum
+			response.WriteString(fmt.Sprintf(tgText("daily_item", "• <code>%s</code> %d单 | 盈亏 <code>%.4f</code> (%.2f%%)")+"\n",
+				it.Key, it.CloseNum, it.ProfitSum, it.ProfitPct))
+		}
+		response.WriteString(fmt.Sprintf(tgText("daily_total", "📊 <b>合计:</b> <code>%.4f</code>")+"\n\n", total))
+	}
+
+	response.WriteString(tgDivider)
+	return response.String()
+}
+
+// pauseStrategy 解析 /pause 或 /resume 命令，暂停或恢复所有账户中策略的新开单
+func (t *Telegram) pauseStrategy(text string, pause bool) string {
+	if orderManager == nil {
+		return tgText("no_mgr", "❌ 订单管理器未初始化")
+	}
+
+	parts := strings.Fields(text)
+	if len(parts) < 2 {
+		var response strings.Builder
+		response.WriteString(tgText("pause_usage", "❌ <b>用法错误</b>\n\n"+
+			"请使用: <code>/pause [策略]</code> 或 <code>/resume [策略]</code>") + "\n\n")
+		for _, account := range tgAccounts() {
+			paused := orderManager.GetPausedStrats(account)
+			if len(paused) == 0 {
+				continue
+			}
+			response.WriteString(accountTitle(account))
+			response.WriteString(fmt.Sprintf(tgText("status_paused", "  ⏸️ <b>暂停策略:</b> <code>%s</code>")+"\n",
+				html.EscapeString(strings.Join(paused, ", "))))
+		}
+		return response.String()
+	}
+
+	stratName := parts[1]
+	for _, account := range tgAccounts() {
+		if err := orderManager.PauseStrategy(account, stratName, pause); err != nil {
+			return fmt.Sprintf(tgText("pause_fail", "❌ <b>操作失败</b>: %s"), html.EscapeString(err.Error()))
+		}
+	}
+	stratName = html.EscapeString(stratName)
+
+	if pause {
+		return fmt.Sprintf(tgText("pause_ok", "⏸️ <b>策略已暂停开单</b>\n\n🎯 策略: <code>%s</code>\n\n"+
+			"已有订单仍会正常管理和平仓，使用 <code>/resume %s</code> 恢复"), stratName, stratName)
+	}
+	return fmt.Sprintf(tgText("resume_ok", "▶️ <b>策略已恢复开单</b>\n\n🎯 策略: <code>%s</code>"), stratName)
+}
+
+// forceExitPair 强制平仓所有账户中指定品种的订单
+func (t *Telegram) forceExitPair(pair string) string {
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf(tgText("forceexit_title", "🔄 <b>强制平仓</b> <code>%s</code>")+"\n",
+		html.EscapeString(pair)))
+	response.WriteString(tgDivider + "\n\n")
+
+	if orderManager == nil {
+		response.WriteString(tgText("no_mgr", "❌ 订单管理器未初始化") + "\n")
+		response.WriteString(tgDivider)
+		return response.String()
+	}
+
+	totalClosed, totalFailed := 0, 0
+	for _, account := range tgAccounts() {
+		response.WriteString(accountTitle(account))
+		successCount, failedCount, err := orderManager.ForceExitPair(account, pair)
+		totalClosed += successCount
+		totalFailed += failedCount
+		response.WriteString(fmt.Sprintf(tgText("close_res", "  ✅ 成功: %d | ❌ 失败: %d")+"\n", successCount, failedCount))
+		if err != nil {
+			response.WriteString(fmt.Sprintf(tgText("query_fail", "  ❌ 查询失败: %s")+"\n", html.EscapeString(err.Error())))
+		}
+		response.WriteString("\n")
+	}
+
+	if totalClosed+totalFailed == 0 {
+		response.WriteString(tgText("no_orders", "📭 <b>暂无活跃订单</b>"))
+	} else {
+		response.WriteString(fmt.Sprintf(tgText("close_all_sum", "📊 <b>统计:</b> 成功 %d | 失败 %d"), totalClosed, totalFailed))
+	}
+	response.WriteString("\n" + tgDivider)
+	return response.String()
+}
+
 // disableTrading 禁用交易
 func (t *Telegram) disableTrading(hours int) string {
 	disabledUntil := time.Now().Add(time.Duration(hours) * time.Hour)
@@ -719,11 +1243,10 @@ func (t *Telegram) disableTrading(hours int) string {
 		t.tradingDisabled[account] = disabledUntil
 	}
 	
-	return fmt.Sprintf(
-		"🚫 <b>开单已禁用</b>\n\n"+
+	return fmt.Sprintf(tgText("disable_ok", "🚫 <b>开单已禁用</b>\n\n"+
 		"⏰ <b>禁用时长:</b> %d 小时\n"+
 		"📅 <b>恢复时间:</b> %s\n\n"+
-		"使用 <code>/enable</code> 可提前恢复开单",
+		"使用 <code>/enable</code> 可提前恢复开单"),
 		hours,
 		disabledUntil.Format("2006-01-02 15:04:05"),
 	)
@@ -734,7 +1257,7 @@ func (t *Telegram) enableTrading() string {
 	// 清除所有账户的禁用状态
 	t.tradingDisabled = make(map[string]time.Time)
 	
-	return "✅ <b>开单已恢复</b>\n\n所有账户的交易功能已重新启用"
+	return tgText("enable_ok", "✅ <b>开单已恢复</b>\n\n所有账户的交易功能已重新启用")
 }
 
 // IsTradingDisabled 检查指定账户是否被禁用交易（供外部调用）
@@ -751,9 +1274,9 @@ func formatDuration(d time.Duration) string {
 	minutes := int(d.Minutes()) % 60
 	
 	if hours > 0 {
-		return fmt.Sprintf("%d小时%d分钟", hours, minutes)
+		return fmt.Sprintf(tgText("dur_hour_min", "%d小时%d分钟"), hours, minutes)
 	}
-	return fmt.Sprintf("%d分钟", minutes)
+	return fmt.Sprintf(tgText("dur_min", "%d分钟"), minutes)
 }
 
 // IsTradingDisabledByTelegram 检查指定账户是否被Telegram Bot禁用交易（全局函数）
@@ -776,9 +1299,17 @@ func initTradingDisableHook() {
 	// 这个函数将在适当的时候被调用
 }
 
+// inlineBtn 创建内联键盘按钮
+func inlineBtn(code, defVal, data string) models.InlineKeyboardButton {
+	return models.InlineKeyboardButton{Text: tgText(code, defVal), CallbackData: data}
+}
+
 // handleOrdersCallback 处理查看订单回调
 func (t *Telegram) handleOrdersCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
 	ordersList, hasOrders := t.getOrdersListWithKeyboard("default")
+
+	refreshBtn := inlineBtn("btn_refresh_orders", "🔄 刷新订单", "action:orders")
+	backBtn := inlineBtn("btn_back", "🔙 返回菜单", "action:refresh")
 	
 	// 创建键盘
 	var kb *models.InlineKeyboardMarkup
@@ -787,23 +1318,15 @@ func (t *Telegram) handleOrdersCallback(ctx context.Context, b *bot.Bot, update
 		// 如果有订单，添加平仓所有按钮
 		kb = &models.InlineKeyboardMarkup{
 			InlineKeyboard: [][]models.InlineKeyboardButton{
-				{
-					{Text: "❌ 平仓所有订单", CallbackData: "action:close_all"},
-					{Text: "🔄 刷新订单", CallbackData: "action:orders"},
-				},
-				{
-					{Text: "🔙 返回菜单", CallbackData: "action:refresh"},
-				},
+				{inlineBtn("btn_close_all", "❌ 平仓所有订单", "action:close_all"), refreshBtn},
+				{backBtn},
 			},
 		}
 	} else {
 		// 没有订单时只显示刷新按钮
 		kb = &models.InlineKeyboardMarkup{
 			InlineKeyboard: [][]models.InlineKeyboardButton{
-				{
-					{Text: "🔄 刷新订单", CallbackData: "action:orders"},
-					{Text: "🔙 返回菜单", CallbackData: "action:refresh"},
-				},
+				{refreshBtn, backBtn},
 			},
 		}
 	}
@@ -827,8 +1350,8 @@ func (t *Telegram) handleStatusCallback(ctx context.Context, b *bot.Bot, update
 	kb := &models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
-				{Text: "🔄 刷新状态", CallbackData: "action:status"},
-				{Text: "🔙 返回菜单", CallbackData: "action:refresh"},
+				inlineBtn("btn_refresh_status", "🔄 刷新状态", "action:status"),
+				inlineBtn("btn_back", "🔙 返回菜单", "action:refresh"),
 			},
 		},
 	}
@@ -848,20 +1371,13 @@ func (t *Telegram) handleStatusCallback(ctx context.Context, b *bot.Bot, update
 // handleDisableCallback 处理禁止开单回调
 func (t *Telegram) handleDisableCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
 	// 默认禁用1小时
-	duration := time.Hour
-	t.disableTrading(1) // 1 hour
-	
-	response := fmt.Sprintf("🚫 <b>交易已禁用</b>\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n"+
-		"⏰ <b>禁用时长:</b> %s\n"+
-		"🕒 <b>恢复时间:</b> %s\n\n"+
-		"━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━",
-		"1小时", time.Now().Add(duration).Format("2006-01-02 15:04:05"))
+	response := t.disableTrading(1)
 
 	kb := &models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
-				{Text: "✅ 立即启用", CallbackData: "action:enable"},
-				{Text: "🔙 返回菜单", CallbackData: "action:refresh"},
+				inlineBtn("btn_enable_now", "✅ 立即启用", "action:enable"),
+				inlineBtn("btn_back", "🔙 返回菜单", "action:refresh"),
 			},
 		},
 	}
@@ -880,18 +1396,13 @@ func (t *Telegram) handleDisableCallback(ctx context.Context, b *bot.Bot, update
 
 // handleEnableCallback 处理启用开单回调
 func (t *Telegram) handleEnableCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
-	t.enableTrading()
-	
-	response := "✅ <b>交易已启用</b>\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n" +
-		"🎯 <b>状态:</b> 交易功能已恢复正常\n" +
-		"⏰ <b>时间:</b> " + time.Now().Format("2006-01-02 15:04:05") + "\n\n" +
-		"━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+	response := t.enableTrading()
 
 	kb := &models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
-				{Text: "🚫 禁用交易", CallbackData: "action:disable"},
-				{Text: "🔙 返回菜单", CallbackData: "action:refresh"},
+				inlineBtn("btn_disable", "🚫 禁用交易", "action:disable"),
+				inlineBtn("btn_back", "🔙 返回菜单", "action:refresh"),
 			},
 		},
 	}
@@ -910,18 +1421,16 @@ func (t *Telegram) handleEnableCallback(ctx context.Context, b *bot.Bot, update
 
 // handleCloseAllCallback 处理平仓所有订单回调
 func (t *Telegram) handleCloseAllCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
-	result := t.closeAllOrders()
+	result := t.guardAction(ActCloseAll, "all orders", tgUser(&update.CallbackQuery.From), t.closeAllOrders)
 	
 	kb := &models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
-				{Text: "📊 查看订单", CallbackData: "action:orders"},
-				{Text: "🔙 返回菜单", CallbackData: "action:refresh"},
+				inlineBtn("btn_orders", "📊 查看订单", "action:orders"),
+				inlineBtn("btn_back", "🔙 返回菜单", "action:refresh"),
 			},
 		},
 	}
-	
-	// FIXME: Replace this placeholder with proper keyboard
 
 	_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
 		ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
@@ -940,24 +1449,20 @@ func (t *Telegram) handleMenuCallback(ctx context.Context, b *bot.Bot, update *m
 	kb := &models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
-				{Text: "📊 查看订单", CallbackData: "action:orders"},
-				{Text: "🔙 返回菜单", CallbackData: "action:refresh"},
+				inlineBtn("btn_orders", "📊 查看订单", "action:orders"),
+				inlineBtn("btn_status", "📈 开单状态", "action:status"),
+			},
+			{
+				inlineBtn("btn_disable", "🚫 禁用交易", "action:disable"),
+				inlineBtn("btn_enable_now", "✅ 立即启用", "action:enable"),
 			},
 		},
 	}
 	
-
-	menuText := `🎛️ <b>BanBot 操作菜单</b>
-━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
-
-请选择您要执行的操作：
-
-━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━`
-
 	_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
 		ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
 		MessageID:   update.CallbackQuery.Message.Message.ID,
-		Text:        menuText,
+		Text:        menuText(),
 		ParseMode:   models.ParseModeHTML,
 		ReplyMarkup: kb,
 	})
@@ -981,13 +1486,11 @@ func (t *Telegram) handleCloseOrderCallback(ctx context.Context, b *bot.Bot, upd
 	kb := &models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
-				{Text: "📊 查看订单", CallbackData: "action:orders"},
-				{Text: "🔙 返回菜单", CallbackData: "action:refresh"},
+				inlineBtn("btn_orders", "📊 查看订单", "action:orders"),
+				inlineBtn("btn_back", "🔙 返回菜单", "action:refresh"),
 			},
 		},
 	}
-	
-	// FIXME: Replace this placeholder with proper keyboard
 
 	_, editErr := b.EditMessageText(ctx, &bot.EditMessageTextParams{
 		ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
@@ -1009,16 +1512,9 @@ func (t *Telegram) handleKeyboardOrdersCommand(ctx context.Context, b *bot.Bot,
 		return
 	}
 
-	ordersList, hasOrders := t.getOrdersListWithKeyboard("default")
-	
-	// 如果有订单，添加单独平仓按钮
-	if hasOrders {
-		// 解析订单列表，为每个订单添加平仓按钮
-		ordersWithButtons := t.addCloseButtonsToOrdersList("default")
-		t.sendResponse(b, update, ordersWithButtons)
-	} else {
-		t.sendResponse(b, update, ordersList)
-	}
+	// 有订单时附带单独平仓命令
+	text, _ := t.getAccOrdersText("default", true)
+	t.sendResponse(b, update, text)
 }
 
 // handleKeyboardStatusCommand 处理键盘"开单状态"按钮
@@ -1057,7 +1553,7 @@ func (t *Telegram) handleKeyboardCloseAllCommand(ctx context.Context, b *bot.Bot
 		return
 	}
 	
-	response := t.closeAllOrders()
+	response := t.guardAction(ActCloseAll, "all orders", tgUser(update.Message.From), t.closeAllOrders)
 	t.sendResponse(b, update, response)
 }
 
@@ -1069,8 +1565,8 @@ func (t *Telegram) handleHideMenuCommand(ctx context.Context, b *bot.Bot, update
 	
 	// 发送隐藏键盘的消息
 	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
-		Text:   "🔄 <b>菜单已隐藏</b>\n\n使用 <code>/menu</code> 命令可以重新显示菜单。",
+		ChatID:    update.Message.Chat.ID,
+		Text:      tgText("menu_hidden", "🔄 <b>菜单已隐藏</b>\n\n使用 <code>/menu</code> 命令可以重新显示菜单。"),
 		ParseMode: models.ParseModeHTML,
 		ReplyMarkup: &models.ReplyKeyboardRemove{
 			RemoveKeyboard: true,
@@ -1080,53 +1576,3 @@ func (t *Telegram) handleHideMenuCommand(ctx context.Context, b *bot.Bot, update
 		log.Error("Failed to hide menu", zap.Error(err))
 	}
 }
-
-// addCloseButtonsToOrdersList 为订单列表添加单独平仓按钮
-func (t *Telegram) addCloseButtonsToOrdersList(account string) string {
-	var response strings.Builder
-	response.WriteString("📊 <b>活跃订单列表</b>\n")
-	response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
-	
-	if orderManager == nil {
-		response.WriteString("❌ 订单管理器未初始化\n")
-		response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		return response.String()
-	}
-	
-	totalOrders := 0
-	
-	// 获取指定账户的订单
-	orders, err := orderManager.GetActiveOrders(account)
-	if err != nil {
-		log.Error("Failed to get orders", zap.String("account", account), zap.Error(err))
-		response.WriteString(fmt.Sprintf("❌ 获取账户 %s 订单失败: %v\n", account, err))
-	} else if len(orders) > 0 {
-		response.WriteString(fmt.Sprintf("🏷️ <b>账户:</b> %s\n\n", account))
-		
-		for _, order := range orders {
-			totalOrders++
-			direction := "📈 多单"
-			if order.Short {
-				direction = "📉 空单"
-			}
-			
-			response.WriteString(fmt.Sprintf("• <code>%d</code> %s <code>%s</code>\n", order.ID, direction, order.Symbol))
-			response.WriteString(fmt.Sprintf("  💰 价格: <code>%.5f</code> | 数量: <code>%.4f</code>\n", order.Price, order.Amount))
-			if order.EnterTag != "" {
-				response.WriteString(fmt.Sprintf("  🏷️ 标签: <code>%s</code>\n", order.EnterTag))
-			}
-			response.WriteString(fmt.Sprintf("  💡 平仓命令: <code>/close %d</code>\n\n", order.ID))
-		}
-	}
-	
-	if totalOrders == 0 {
-		response.WriteString("📭 <b>暂无活跃订单</b>\n")
-		response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	} else {
-		response.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-		response.WriteString(fmt.Sprintf("📊 <b>总计:</b> %d 个活跃订单\n", totalOrders))
-		response.WriteString("💡 <b>提示:</b> 点击上方平仓命令或直接输入 <code>/close [订单ID]</code> 来平仓指定订单")
-	}
-	
-	return response.String()
-}
@@ -54,6 +54,7 @@ const (
 	MsgTypeEntry  = "entry"
 	MsgTypeExit   = "exit"
 	MsgTypeMarket = "market"
+	MsgTypeReport = "report"
 )
 
 var (
@@ -113,19 +114,9 @@ func (h *WebHook) SetDisable(val bool) {
 }
 
 func (h *WebHook) SendMsg(msgType string, account string, payload map[string]string) bool {
-	if h.Disable {
+	if !h.accept(msgType, account) {
 		return false
 	}
-	if len(h.MsgTypes) > 0 {
-		if _, ok := h.MsgTypes[msgType]; !ok {
-			return false
-		}
-	}
-	if account != "" && len(h.Accounts) > 0 {
-		if _, ok := h.Accounts[account]; !ok {
-			return false
-		}
-	}
 	if content, ok := payload["content"]; ok && len(h.Keywords) > 0 {
 		match := false
 		for _, word := range h.Keywords {
@@ -143,6 +134,24 @@ func (h *WebHook) SendMsg(msgType string, account string, payload map[string]str
 	return true
 }
 
+// accept 检查渠道是否启用，并订阅了此消息类型和账户
+func (h *WebHook) accept(msgType string, account string) bool {
+	if h.Disable {
+		return false
+	}
+	if len(h.MsgTypes) > 0 {
+		if _, ok := h.MsgTypes[msgType]; !ok {
+			return false
+		}
+	}
+	if account != "" && len(h.Accounts) > 0 {
+		if _, ok := h.Accounts[account]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
 func (h *WebHook) CleanUp() {
 	h.Disable = true
 	h.wg.Wait()
@@ -3,6 +3,7 @@ package strat
 import (
 	"fmt"
 	testcom "github.com/banbox/banbot/_testcom"
+	"github.com/banbox/banbot/orm"
 	"github.com/banbox/banbot/orm/ormo"
 	"github.com/banbox/banbot/utils"
 	ta "github.com/banbox/banta"
@@ -78,3 +79,53 @@ func TestStratJob_DrawDownExit(t *testing.T) {
 //	}
 //	t.Logf("%s %d %d", stgy.Name, stgy.Version, stgy.WarmupNum)
 //}
+
+func TestStratJob_State(t *testing.T) {
+	job := &StratJob{
+		Strat:     &TradeStrat{Name: "test", Version: 1},
+		Symbol:    &orm.ExSymbol{Symbol: "BTC/USDT:USDT"},
+		TimeFrame: "1h",
+	}
+	type regime struct {
+		Trend int
+		Score float64
+	}
+	err := job.SaveState("regime", &regime{Trend: 1, Score: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res regime
+	ok, err := job.LoadState("regime", &res)
+	if err != nil || !ok || res.Trend != 1 || res.Score != 0.5 {
+		t.Fatalf("load state fail: %v %v %+v", ok, err, res)
+	}
+	job.Strat.Version = 2
+	ok, err = job.LoadState("regime", &res)
+	if err != nil || ok {
+		t.Fatalf("state of old version should be discarded: %v %v", ok, err)
+	}
+	if len(MemStates) != 0 {
+		t.Fatalf("old state not removed: %d", len(MemStates))
+	}
+}
+
+func TestPauseStrat(t *testing.T) {
+	acc := "test_pause"
+	PauseStrat(acc, "ma:demo", false)
+	if IsStratPaused(acc, "ma:demo") {
+		t.Fatal("strategy should not be paused")
+	}
+	PauseStrat(acc, "ma:demo", true)
+	PauseStrat(acc, "ma:alpha", true)
+	if !IsStratPaused(acc, "ma:demo") || IsStratPaused("other", "ma:demo") {
+		t.Fatal("pause should only apply to the account")
+	}
+	paused := GetPausedStrats(acc)
+	if len(paused) != 2 || paused[0] != "ma:alpha" {
+		t.Fatalf("unexpected paused strategies: %v", paused)
+	}
+	PauseStrat(acc, "ma:demo", false)
+	if IsStratPaused(acc, "ma:demo") || len(GetPausedStrats(acc)) != 1 {
+		t.Fatal("resume failed")
+	}
+}
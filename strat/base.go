@@ -11,6 +11,7 @@ import (
 	"github.com/banbox/banbot/btime"
 	"github.com/banbox/banbot/config"
 	"github.com/banbox/banbot/core"
+	"github.com/banbox/banbot/orm"
 	"github.com/banbox/banbot/orm/ormo"
 	"github.com/banbox/banbot/utils"
 	"github.com/banbox/banexg"
@@ -163,6 +164,10 @@ func (s *StratJob) openOrder(req *EnterReq) *errs.Error {
 		AddAccFailOpen(s.Account, FailOpenBadDirtOrLimit)
 		return errs.NewMsg(errs.CodeParamInvalid, "trading disabled by external service")
 	}
+	if IsStratPaused(s.Account, s.Strat.Name) {
+		AddAccFailOpen(s.Account, FailOpenStratPaused)
+		return errs.NewMsg(errs.CodeParamInvalid, "strategy paused: %s", s.Strat.Name)
+	}
 	if math.IsNaN(req.Limit+req.Amount+req.Leverage+req.CostRate+req.LegalCost) ||
 		math.IsNaN(req.StopLoss+req.StopLossVal+req.StopLossLimit+req.StopLossRate) ||
 		math.IsNaN(req.TakeProfit+req.TakeProfitVal+req.TakeProfitLimit+req.TakeProfitRate) {
@@ -659,6 +664,23 @@ func (s *StratJob) GetAvgCostPrice(odList ...*ormo.InOutOrder) float64 {
 	return totalCost / totalAmt
 }
 
+/*
+MinsToSessionClose
+Minutes left until the current trading session closes. Return -1 if the market trades 24/7 or calendars are
+unavailable, 0 if the market is not in a trading session now (e.g. lunch break or night).
+距当前交易时段收盘的分钟数。全天候交易的市场或无日历时返回-1；当前处于休市（如午休、夜间）时返回0
+*/
+func (s *StratJob) MinsToSessionClose() float64 {
+	sess, always, err := orm.GetSessionAt(s.Symbol, btime.TimeMS())
+	if err != nil || always {
+		return -1
+	}
+	if sess == nil {
+		return 0
+	}
+	return float64(sess.Stop-btime.TimeMS()) / 60000
+}
+
 func (s *StratJob) GetTmpEnv(stamp int64, o, h, l, c, v, i float64) *ta.BarEnv {
 	envKey := strings.Join([]string{s.Symbol.Symbol, s.TimeFrame}, "_")
 	lockTmpEnv.Lock()
@@ -2,6 +2,7 @@ package strat
 
 import (
 	"fmt"
+	"maps"
 	"math"
 	"slices"
 	"sort"
@@ -591,6 +592,63 @@ func AddOdSub(acc string, cb FnOdChange) {
 	lockOdSub.Unlock()
 }
 
+/*
+AddJobSub
+Add a listener for jobs added or removed by LoadStratJobs, items are `pair_tf/stratID`
+添加LoadStratJobs中任务新增或删除的监听，项为`pair_tf/stratID`
+*/
+func AddJobSub(cb FnJobChange) {
+	lockJobSub.Lock()
+	jobSubs = append(jobSubs, cb)
+	lockJobSub.Unlock()
+}
+
+func accJobKeys() map[string]map[string]bool {
+	res := make(map[string]map[string]bool)
+	for acc, jobs := range AccJobs {
+		keys := make(map[string]bool)
+		for pairTF, items := range jobs {
+			for stratID := range items {
+				keys[pairTF+"/"+stratID] = true
+			}
+		}
+		res[acc] = keys
+	}
+	return res
+}
+
+func fireJobChange(olds map[string]map[string]bool) {
+	lockJobSub.Lock()
+	subs := jobSubs
+	lockJobSub.Unlock()
+	if len(subs) == 0 {
+		return
+	}
+	news := accJobKeys()
+	for acc := range config.Accounts {
+		oldKeys, newKeys := olds[acc], news[acc]
+		var adds, removes []string
+		for k := range newKeys {
+			if !oldKeys[k] {
+				adds = append(adds, k)
+			}
+		}
+		for k := range oldKeys {
+			if !newKeys[k] {
+				removes = append(removes, k)
+			}
+		}
+		if len(adds)+len(removes) == 0 {
+			continue
+		}
+		sort.Strings(adds)
+		sort.Strings(removes)
+		for _, cb := range subs {
+			cb(acc, adds, removes)
+		}
+	}
+}
+
 func FireOdChange(acc string, od *ormo.InOutOrder, evt int) {
 	lockOdSub.Lock()
 	subs, _ := accOdSubs[acc]
@@ -709,6 +767,69 @@ func DumpAccFailOpens() string {
 	return b.String()
 }
 
+/*
+GetAccFailOpens
+Return a copy of failed entry counts: account: reason: count
+返回开单失败统计的副本：账户：原因：数量
+*/
+func GetAccFailOpens() map[string]map[string]int {
+	lockAccFailOpen.Lock()
+	defer lockAccFailOpen.Unlock()
+	res := make(map[string]map[string]int, len(accFailOpens))
+	for acc, items := range accFailOpens {
+		res[acc] = maps.Clone(items)
+	}
+	return res
+}
+
+/*
+PauseStrat
+Pause or resume new entries of a strategy for the account, existing orders are still managed and can exit.
+The state is kept in memory only and lost after restart.
+暂停或恢复账户中某策略的新开单，已有订单仍正常管理和平仓。状态仅保存在内存中，重启后丢失
+*/
+func PauseStrat(acc, stratName string, paused bool) {
+	lockPaused.Lock()
+	defer lockPaused.Unlock()
+	items, ok := pausedStrats[acc]
+	if !ok {
+		if !paused {
+			return
+		}
+		items = make(map[string]bool)
+		pausedStrats[acc] = items
+	}
+	if paused {
+		items[stratName] = true
+	} else {
+		delete(items, stratName)
+	}
+}
+
+/*
+IsStratPaused
+Whether new entries of the strategy are paused by user for the account
+账户中该策略的新开单是否被用户暂停
+*/
+func IsStratPaused(acc, stratName string) bool {
+	lockPaused.Lock()
+	defer lockPaused.Unlock()
+	return pausedStrats[acc][stratName]
+}
+
+/*
+GetPausedStrats
+Return sorted names of paused strategies for the account
+返回账户中已暂停策略的名称，已排序
+*/
+func GetPausedStrats(acc string) []string {
+	lockPaused.Lock()
+	defer lockPaused.Unlock()
+	res := utils.KeysOfMap(pausedStrats[acc])
+	slices.Sort(res)
+	return res
+}
+
 func newAccStratLimits() (accStratLimits, int) {
 	res := make(accStratLimits)
 	maxJobNum := 1
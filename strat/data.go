@@ -1,6 +1,7 @@
 package strat
 
 import (
+	"github.com/banbox/banbot/orm/ormo"
 	ta "github.com/banbox/banta"
 	"github.com/sasha-s/go-deadlock"
 )
@@ -28,10 +29,22 @@ var (
 	accOdSubs = map[string][]FnOdChange{} // acc: listeners List of subscription order status change events 订阅订单状态变化事件列表
 	lockOdSub deadlock.Mutex
 
+	jobSubs    []FnJobChange // Listeners of job changes in LoadStratJobs LoadStratJobs中任务变化的监听
+	lockJobSub deadlock.Mutex
+
 	accFailOpens    = make(map[string]map[string]int) // Statistics of reasons for failed entry for accounts 各个账号开单失败原因统计
 	lockAccFailOpen deadlock.Mutex
 
+	pausedStrats = make(map[string]map[string]bool) // account: [stratName] entries paused by user 用户暂停开单的策略
+	lockPaused   deadlock.Mutex
+
 	WsSubUnWatch func(map[string][]string)
+
+	MemStates    = make(map[string]*ormo.StratState) // task_strat_pair_tf_key: state, used for non-live mode 非实盘模式的策略状态存储
+	lockMemState deadlock.Mutex
+
+	PlotSeries = make(map[string]map[string]*PlotLine) // pair_tf: name: line, series recorded by StratJob.Plot in backtest 回测中StratJob.Plot记录的序列
+	lockPlot   deadlock.Mutex
 )
 
 var (
@@ -44,4 +57,7 @@ var (
 	FailOpenNoEntry        = "NoEntry"
 	FailOpenNumLimit       = "NumLimit"
 	FailOpenNumLimitPol    = "NumLimitPol"
+	FailOpenNumLimitTag    = "NumLimitTag"
+	FailOpenStratPaused    = "StratPaused"
+	FailOpenExgRejPrefix   = "Rej" // prefix of reasons rejected by market limits, e.g. RejCostMin 被市场限制拒绝的原因前缀
 )
@@ -45,6 +45,7 @@ func LoadStratJobs(pairs []string, tfScores map[string]map[string]float64) (map[
 	// 将涉及的全局变量置为空，下面会更新
 	core.TFSecs = make(map[string]int)
 	core.StgPairTfs = make(map[string]map[string]string)
+	oldJobs := accJobKeys()
 	resetJobs()
 	pairTfWarms := make(Warms)
 	// 记录每个账户下，每个策略的任务数量，防止超过账户要求数量
@@ -296,9 +297,65 @@ func LoadStratJobs(pairs []string, tfScores map[string]map[string]float64) (map[
 			}
 		}
 	}
+	fireJobChange(oldJobs)
 	return pairTfs, accExitOds, nil
 }
 
+/*
+ReloadPolicies
+Prepare jobs for run_policy changes before calling LoadStratJobs.
+Strategies of changed policies are discarded and re-created, their jobs will be rebound to new strategies.
+Jobs of removed policies are no longer allowed to open orders. Return open orders of removed jobs whose
+OrderOnRotation is "close", which should be exited by caller.
+在调用LoadStratJobs前，为run_policy变化准备任务。
+修改的策略会被丢弃并重新创建，其任务会绑定到新策略；删除的策略其任务不再允许开单。
+返回OrderOnRotation为"close"的已删除任务的未平仓订单，应由调用方平仓
+*/
+func ReloadPolicies(removes, changes []string) map[string][]*ormo.InOutOrder {
+	for _, stgMap := range PairStrats {
+		for _, polID := range changes {
+			delete(stgMap, polID)
+		}
+	}
+	accOds := make(map[string][]*ormo.InOutOrder)
+	if len(removes) == 0 {
+		return accOds
+	}
+	removeMap := make(map[string]bool)
+	for _, polID := range removes {
+		removeMap[polID] = true
+	}
+	// copies of removed strategies which only allow holding, shared strategies are not modified
+	// 已删除策略的仅持有副本，不修改共享的策略
+	holdStgys := make(map[*TradeStrat]*TradeStrat)
+	for acc, jobs := range AccJobs {
+		for _, envJobs := range jobs {
+			for polID, job := range envJobs {
+				if _, ok := removeMap[polID]; !ok {
+					continue
+				}
+				job.MaxOpenLong = -1
+				job.MaxOpenShort = -1
+				if job.Strat.OrderOnRotation == "close" {
+					accOds[acc] = append(accOds[acc], job.LongOrders...)
+					accOds[acc] = append(accOds[acc], job.ShortOrders...)
+				} else if job.Strat.OrderOnRotation == "open" {
+					// The policy is removed, only allow holding 策略已删除，仅允许持有
+					holdStgy, ok := holdStgys[job.Strat]
+					if !ok {
+						stgyCopy := *job.Strat
+						stgyCopy.OrderOnRotation = "hold"
+						holdStgy = &stgyCopy
+						holdStgys[job.Strat] = holdStgy
+					}
+					job.Strat = holdStgy
+				}
+			}
+		}
+	}
+	return accOds
+}
+
 func ExitStratJobs() {
 	for _, jobs := range AccJobs {
 		for _, items := range jobs {
@@ -472,6 +529,16 @@ func ensureStratJob(stgy *TradeStrat, tf string, exs *orm.ExSymbol, env *ta.BarE
 				stgy.OnStartUp(job)
 			}
 			envJobs[stgy.Name] = job
+		} else if job.Strat != stgy {
+			// The policy is re-parameterized by hot-reloading, bind the new strategy and keep orders,
+			// call OnStartUp again to apply new parameters
+			// 策略通过热加载更新了参数，绑定新策略，保留订单，再次调用OnStartUp以应用新参数
+			log.Info("rebind job strategy", zap.String("acc", account), zap.String("job", envKey),
+				zap.String("strat", stgy.Name))
+			job.Strat = stgy
+			if stgy.OnStartUp != nil {
+				stgy.OnStartUp(job)
+			}
 		}
 		if allowOpen {
 			job.MaxOpenShort = stgy.EachMaxShort
@@ -0,0 +1,82 @@
+package strat
+
+import (
+	"sort"
+
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/core"
+)
+
+/*
+PlotLine
+An indicator series recorded by StratJob.Plot, rendered in the kline html of backtest.
+StratJob.Plot记录的指标序列，在回测的K线html中绘制
+*/
+type PlotLine struct {
+	Name   string       `json:"name"`
+	Sub    bool         `json:"sub"`    // draw in the sub pane instead of overlaying on price 绘制在副图而不是叠加在价格上
+	Points [][2]float64 `json:"points"` // [13-digit timestamp, value]
+}
+
+/*
+Plot
+Record an indicator value of current bar, which is overlaid on the price in kline html of backtest
+(enabled by bt_kline_html). It does nothing in live mode.
+记录当前bar的指标值，在回测的K线html中叠加在价格上（通过bt_kline_html启用）。实盘时无效
+*/
+func (s *StratJob) Plot(name string, value float64) {
+	s.plot(name, value, false)
+}
+
+/*
+PlotSub
+Same as Plot, but draw in the sub pane, for oscillators like RSI/MACD.
+同Plot，但绘制在副图中，用于RSI/MACD等振荡指标
+*/
+func (s *StratJob) PlotSub(name string, value float64) {
+	s.plot(name, value, true)
+}
+
+func (s *StratJob) plot(name string, value float64, sub bool) {
+	if core.LiveMode || config.BtKlineHtml == 0 || s.IsWarmUp || s.Env == nil {
+		return
+	}
+	pairTF := plotKey(s.Symbol.Symbol, s.TimeFrame)
+	key := s.Strat.Name + ":" + name
+	lockPlot.Lock()
+	lines, ok := PlotSeries[pairTF]
+	if !ok {
+		lines = make(map[string]*PlotLine)
+		PlotSeries[pairTF] = lines
+	}
+	line, ok := lines[key]
+	if !ok {
+		line = &PlotLine{Name: key, Sub: sub}
+		lines[key] = line
+	}
+	line.Points = append(line.Points, [2]float64{float64(s.Env.TimeStart), value})
+	lockPlot.Unlock()
+}
+
+func plotKey(pair, tf string) string {
+	return pair + "_" + tf
+}
+
+/*
+GetPlotLines
+Get the indicator series recorded for the pair and timeframe by Plot/PlotSub
+获取Plot/PlotSub为品种和周期记录的指标序列
+*/
+func GetPlotLines(pair, tf string) []*PlotLine {
+	lockPlot.Lock()
+	defer lockPlot.Unlock()
+	lines := PlotSeries[plotKey(pair, tf)]
+	res := make([]*PlotLine, 0, len(lines))
+	for _, l := range lines {
+		res = append(res, l)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
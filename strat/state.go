@@ -0,0 +1,131 @@
+package strat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banbot/orm"
+	"github.com/banbox/banbot/orm/ormo"
+	"github.com/banbox/banexg/errs"
+	"github.com/banbox/banexg/log"
+	utils2 "github.com/banbox/banexg/utils"
+	"go.uber.org/zap"
+)
+
+func (s *StratJob) stateKey(key string) *ormo.StratState {
+	return &ormo.StratState{
+		TaskID:    ormo.GetTaskID(s.Account),
+		Strategy:  s.Strat.Name,
+		Pair:      s.Symbol.Symbol,
+		Timeframe: s.TimeFrame,
+		Key:       key,
+		Version:   int64(s.Strat.Version),
+	}
+}
+
+func memStateKey(st *ormo.StratState) string {
+	return fmt.Sprintf("%d_%s_%s_%s_%s", st.TaskID, st.Strategy, st.Pair, st.Timeframe, st.Key)
+}
+
+/*
+SaveState
+Persist a custom state of the job, value is encoded as json. States are scoped by task, strategy, pair and
+timeframe, and survive restarts and pair rotations in live mode. In backtest they are kept in memory.
+持久化任务的自定义状态，value使用json编码。状态按任务、策略、品种、周期隔离，实盘中可跨重启和品种轮换保留；回测时保存在内存中
+*/
+func (s *StratJob) SaveState(key string, value interface{}) *errs.Error {
+	data, err_ := utils2.MarshalString(value)
+	if err_ != nil {
+		return errs.New(core.ErrMarshalFail, err_)
+	}
+	st := s.stateKey(key)
+	st.Data = data
+	if !core.LiveMode {
+		lockMemState.Lock()
+		MemStates[memStateKey(st)] = st
+		lockMemState.Unlock()
+		return nil
+	}
+	sess, conn, err := ormo.Conn(orm.DbTrades, true)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return sess.SetStratState(st)
+}
+
+/*
+LoadState
+Load the state saved by SaveState into out (should be a pointer), return whether it's found.
+States saved by a different TradeStrat.Version are considered incompatible, they are discarded and false is returned.
+加载SaveState保存的状态到out（应为指针），返回是否找到。
+不同TradeStrat.Version保存的状态视为不兼容，会被丢弃并返回false
+*/
+func (s *StratJob) LoadState(key string, out interface{}) (bool, *errs.Error) {
+	st := s.stateKey(key)
+	var old *ormo.StratState
+	if !core.LiveMode {
+		memKey := memStateKey(st)
+		lockMemState.Lock()
+		old = MemStates[memKey]
+		if old != nil && old.Version != st.Version {
+			delete(MemStates, memKey)
+			old = nil
+		}
+		lockMemState.Unlock()
+	} else {
+		sess, conn, err := ormo.Conn(orm.DbTrades, true)
+		if err != nil {
+			return false, err
+		}
+		defer conn.Close()
+		old, err = sess.GetStratState(st.TaskID, st.Strategy, st.Pair, st.Timeframe, key)
+		if err != nil {
+			return false, err
+		}
+		if old != nil && old.Version != st.Version {
+			log.Info("discard strategy state for version changed", zap.String("strat", st.Strategy),
+				zap.String("pair", st.Pair), zap.String("key", key), zap.Int64("old", old.Version))
+			err = sess.DelStratState(st.TaskID, st.Strategy, st.Pair, st.Timeframe, key)
+			if err != nil {
+				return false, err
+			}
+			old = nil
+		}
+	}
+	if old == nil {
+		return false, nil
+	}
+	err_ := utils2.UnmarshalString(old.Data, out, utils2.JsonNumDefault)
+	if err_ != nil {
+		return false, errs.New(core.ErrMarshalFail, err_)
+	}
+	return true, nil
+}
+
+/*
+DelState
+Delete the state saved by SaveState. Delete all states of the job if key is empty.
+删除SaveState保存的状态，key为空时删除当前任务的所有状态
+*/
+func (s *StratJob) DelState(key string) *errs.Error {
+	st := s.stateKey(key)
+	if !core.LiveMode {
+		prefix := memStateKey(st)
+		lockMemState.Lock()
+		for k := range MemStates {
+			if k == prefix || (key == "" && strings.HasPrefix(k, prefix)) {
+				delete(MemStates, k)
+			}
+		}
+		lockMemState.Unlock()
+		return nil
+	}
+	sess, conn, err := ormo.Conn(orm.DbTrades, true)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return sess.DelStratState(st.TaskID, st.Strategy, st.Pair, st.Timeframe, key)
+}
@@ -12,6 +12,7 @@ import (
 type CalcDDExitRate func(s *StratJob, od *ormo.InOutOrder, maxChg float64) float64
 type PickTimeFrameFunc func(symbol string, tfScores []*core.TfScore) string
 type FnOdChange func(acc string, od *ormo.InOutOrder, evt int)
+type FnJobChange func(acc string, adds, removes []string)
 type FnOnPostApi func(client *core.ApiClient, msg map[string]interface{}, jobs map[string]map[string]*StratJob) error
 
 type Warms map[string]map[string]int
@@ -1,11 +1,15 @@
 package utils
 
 import (
+	"bytes"
 	"crypto/tls"
+	"encoding/base64"
 	"fmt"
 	"github.com/banbox/banexg/log"
 	"go.uber.org/zap"
+	"mime"
 	"net/smtp"
+	"sort"
 	"sync"
 )
 
@@ -15,6 +19,8 @@ type EmailTask struct {
 	To      string
 	Subject string
 	Body    string
+	HTML    bool              // Body是html
+	Images  map[string][]byte // 内嵌png图片，key为Content-ID，html中通过 cid:key 引用
 }
 
 var (
@@ -36,7 +42,7 @@ func StartEmailWorker(queueSize int) {
 		go func() {
 			log.Info("Email worker started")
 			for task := range emailQueue {
-				err := mailSender.SendMail(task.From, task.To, task.Subject, task.Body)
+				err := mailSender.SendTask(&task)
 				if err != nil {
 					log.Error("Failed to send email from queue",
 						zap.Error(err),
@@ -57,6 +63,29 @@ func SetMailSender(host string, port int, username, password string) {
 
 // SendEmailFrom sends an email using the provided configuration
 func SendEmailFrom(from, to, subject, body string) error {
+	return sendEmailTask(EmailTask{
+		From:    from,
+		To:      to,
+		Subject: subject,
+		Body:    body,
+	})
+}
+
+/*
+SendHtmlEmail 发送html邮件，images中的png图片以内嵌附件发送，html中通过 <img src="cid:key"> 引用
+*/
+func SendHtmlEmail(from, to, subject, body string, images map[string][]byte) error {
+	return sendEmailTask(EmailTask{
+		From:    from,
+		To:      to,
+		Subject: subject,
+		Body:    body,
+		HTML:    true,
+		Images:  images,
+	})
+}
+
+func sendEmailTask(task EmailTask) error {
 	// 确保邮件工作线程已经启动
 	if emailQueue == nil {
 		if mailSender == nil {
@@ -66,18 +95,11 @@ func SendEmailFrom(from, to, subject, body string) error {
 	}
 
 	// 向队列中添加邮件任务
-	task := EmailTask{
-		From:    from,
-		To:      to,
-		Subject: subject,
-		Body:    body,
-	}
-
 	select {
 	case emailQueue <- task:
 		return nil
 	default:
-		log.Warn("Email queue is full, task dropped", zap.String("to", to), zap.String("subject", subject))
+		log.Warn("Email queue is full, task dropped", zap.String("to", task.To), zap.String("subject", task.Subject))
 		return fmt.Errorf("email queue is full")
 	}
 }
@@ -141,20 +163,25 @@ func (ms *MailSender) getClient() (*smtp.Client, error) {
 }
 
 func (ms *MailSender) SendMail(from string, to string, subject, body string) error {
+	return ms.SendTask(&EmailTask{
+		From:    from,
+		To:      to,
+		Subject: subject,
+		Body:    body,
+	})
+}
+
+func (ms *MailSender) SendTask(task *EmailTask) error {
 	client, err := ms.getClient()
 	if err != nil {
 		return err
 	}
+	from, to := task.From, task.To
 	if from == "" {
 		from = ms.username
 	}
 
-	message := []byte(
-		"From: " + from + "\r\n" +
-			"To: " + to + "\r\n" +
-			"Subject: " + subject + "\r\n" +
-			"\r\n" +
-			body + "\r\n")
+	message := buildMailMessage(from, task)
 
 	// 使用同一个客户端发送多封邮件
 	ms.mu.Lock()
@@ -179,3 +206,50 @@ func (ms *MailSender) SendMail(from string, to string, subject, body string) err
 
 	return nil
 }
+
+// buildMailMessage 构建邮件内容，html邮件使用MIME格式，有内嵌图片时使用multipart/related
+func buildMailMessage(from string, task *EmailTask) []byte {
+	var b bytes.Buffer
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + task.To + "\r\n")
+	if !task.HTML {
+		b.WriteString("Subject: " + task.Subject + "\r\n\r\n")
+		b.WriteString(task.Body + "\r\n")
+		return b.Bytes()
+	}
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", task.Subject) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	htmlHeader := "Content-Type: text/html; charset=UTF-8\r\nContent-Transfer-Encoding: base64\r\n\r\n"
+	if len(task.Images) == 0 {
+		b.WriteString(htmlHeader)
+		writeBase64Lines(&b, []byte(task.Body))
+		return b.Bytes()
+	}
+	boundary := "banbot_" + RandomStr(16)
+	b.WriteString("Content-Type: multipart/related; boundary=\"" + boundary + "\"\r\n\r\n")
+	b.WriteString("--" + boundary + "\r\n" + htmlHeader)
+	writeBase64Lines(&b, []byte(task.Body))
+	cids := make([]string, 0, len(task.Images))
+	for cid := range task.Images {
+		cids = append(cids, cid)
+	}
+	sort.Strings(cids)
+	for _, cid := range cids {
+		b.WriteString("--" + boundary + "\r\n")
+		b.WriteString("Content-Type: image/png\r\nContent-Transfer-Encoding: base64\r\n")
+		b.WriteString("Content-ID: <" + cid + ">\r\n")
+		b.WriteString("Content-Disposition: inline; filename=\"" + cid + ".png\"\r\n\r\n")
+		writeBase64Lines(&b, task.Images[cid])
+	}
+	b.WriteString("--" + boundary + "--\r\n")
+	return b.Bytes()
+}
+
+func writeBase64Lines(b *bytes.Buffer, data []byte) {
+	text := base64.StdEncoding.EncodeToString(data)
+	for len(text) > 76 {
+		b.WriteString(text[:76] + "\r\n")
+		text = text[76:]
+	}
+	b.WriteString(text + "\r\n")
+}
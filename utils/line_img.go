@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"bytes"
+	"github.com/banbox/banexg/log"
+	"github.com/fogleman/gg"
+	"go.uber.org/zap"
+	"image/png"
+	"math"
+	"strconv"
+	"time"
+)
+
+/*
+GenLineImg
+Draw a line chart png, times are 13-digit timestamps of each value, used for equity curve in reports.
+绘制折线图png，times为每个值对应的13位时间戳，用于报告中的权益曲线
+*/
+func GenLineImg(title string, times []int64, values []float64, fontName string) ([]byte, error) {
+	const imgWidth, imgHeight = 800, 360
+	const padLeft, padRight, padTop, padBottom = 80.0, 30.0, 50.0, 40.0
+	dc := gg.NewContext(imgWidth, imgHeight)
+	dc.SetRGB(1, 1, 1)
+	dc.Clear()
+
+	fontFace, err := GetOpenFont(fontName)
+	if err != nil {
+		log.Warn("load font fail when create LineImage", zap.Error(err))
+	}
+	dc.SetRGB(0, 0, 0)
+	setFontFace(dc, fontFace, 18, 72)
+	dc.DrawStringAnchored(title, imgWidth/2, padTop/2, 0.5, 0.5)
+	setFontFace(dc, fontFace, 12, 72)
+
+	plotW := imgWidth - padLeft - padRight
+	plotH := imgHeight - padTop - padBottom
+	// draw axis
+	dc.SetRGB(0.6, 0.6, 0.6)
+	dc.SetLineWidth(1)
+	dc.DrawLine(padLeft, padTop, padLeft, padTop+plotH)
+	dc.DrawLine(padLeft, padTop+plotH, padLeft+plotW, padTop+plotH)
+	dc.Stroke()
+	if len(values) < 2 || len(times) != len(values) {
+		dc.SetRGB(0, 0, 0)
+		dc.DrawStringAnchored("no data", imgWidth/2, imgHeight/2, 0.5, 0.5)
+		return encodePng(dc)
+	}
+
+	minVal, maxVal := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		minVal = math.Min(minVal, v)
+		maxVal = math.Max(maxVal, v)
+	}
+	if maxVal-minVal < 1e-9 {
+		maxVal, minVal = maxVal+1, minVal-1
+	}
+	minTime, maxTime := times[0], times[len(times)-1]
+	if maxTime <= minTime {
+		maxTime = minTime + 1
+	}
+	toX := func(t int64) float64 {
+		return padLeft + float64(t-minTime)/float64(maxTime-minTime)*plotW
+	}
+	toY := func(v float64) float64 {
+		return padTop + (maxVal-v)/(maxVal-minVal)*plotH
+	}
+	// y grid and labels
+	const gridNum = 4
+	for i := 0; i <= gridNum; i++ {
+		val := minVal + (maxVal-minVal)*float64(i)/gridNum
+		y := toY(val)
+		dc.SetRGB(0.9, 0.9, 0.9)
+		dc.DrawLine(padLeft, y, padLeft+plotW, y)
+		dc.Stroke()
+		dc.SetRGB(0, 0, 0)
+		dc.DrawStringAnchored(strconv.FormatFloat(val, 'f', 2, 64), padLeft-6, y, 1, 0.5)
+	}
+	// x labels
+	dateFmt := "01-02 15:04"
+	if maxTime-minTime > int64(time.Hour/time.Millisecond)*24*3 {
+		dateFmt = "2006-01-02"
+	}
+	for _, t := range []int64{minTime, maxTime} {
+		label := time.UnixMilli(t).UTC().Format(dateFmt)
+		ax := 0.0
+		if t == maxTime {
+			ax = 1
+		}
+		dc.DrawStringAnchored(label, toX(t), padTop+plotH+16, ax, 0.5)
+	}
+	// curve
+	dc.SetRGB(0.15, 0.4, 0.85)
+	dc.SetLineWidth(2)
+	dc.MoveTo(toX(times[0]), toY(values[0]))
+	for i := 1; i < len(values); i++ {
+		dc.LineTo(toX(times[i]), toY(values[i]))
+	}
+	dc.Stroke()
+	return encodePng(dc)
+}
+
+func encodePng(dc *gg.Context) ([]byte, error) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, dc.Image())
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -61,6 +61,25 @@ func SplitSolid(text string, sep string, unique bool) []string {
 	return result
 }
 
+/*
+NormTags
+Trim spaces, remove empty and duplicate tags, keep the order.
+去除空白、空标签和重复标签，保持顺序
+*/
+func NormTags(tags []string) []string {
+	res := make([]string, 0, len(tags))
+	visited := make(map[string]bool)
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || visited[t] {
+			continue
+		}
+		visited[t] = true
+		res = append(res, t)
+	}
+	return res
+}
+
 func SplitToMap(text string, sep string) map[string]bool {
 	arr := strings.Split(text, sep)
 	var result = make(map[string]bool)
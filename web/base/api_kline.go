@@ -2,11 +2,15 @@ package base
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/banbox/banbot/orm"
+	"github.com/banbox/banexg/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
+const maxCalcBars = 10000 // max bars loaded from database for calc_ind 计算指标时从数据库加载的最大K线数
+
 func RegApiKline(api fiber.Router) {
 	api.Get("/symbols", getSymbols)
 	api.Get("/hist", getHist)
@@ -67,29 +71,80 @@ getTaInds 获取云端指标列表
 */
 func getTaInds(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
-		"data": IndsCache,
+		"data": GetIndsCache(),
 	})
 }
 
 /*
-postCalcInd 计算云端指标
+postCalcInd
+Calculate indicator on klines given by client, or historical klines loaded from database when `kline` is empty
+and `symbol`, `timeframe`, `from`, `to` are given.
+计算云端指标，基于客户端传入的K线；`kline`为空且给定`symbol`,`timeframe`,`from`,`to`时，从数据库加载历史K线计算
 */
 func postCalcInd(c *fiber.Ctx) error {
 	type CalcArgs struct {
-		Name   string      `json:"name" validate:"required"`
-		Kline  [][]float64 `json:"kline" validate:"required"`
-		Params []float64   `json:"params" validate:"required"`
+		Name      string      `json:"name" validate:"required"`
+		Kline     [][]float64 `json:"kline"`
+		Params    []float64   `json:"params"`
+		Exchange  string      `json:"exchange"`
+		Symbol    string      `json:"symbol"`
+		TimeFrame string      `json:"timeframe"`
+		FromMS    int64       `json:"from"`
+		ToMS      int64       `json:"to"`
 	}
 	var data = new(CalcArgs)
 	if err := VerifyArg(c, data, ArgBody); err != nil {
 		return err
 	}
+	loaded := false
+	if len(data.Kline) == 0 {
+		if data.Symbol == "" || data.TimeFrame == "" || data.ToMS <= data.FromMS {
+			return fiber.NewError(fiber.StatusBadRequest, "`kline` or `symbol`, `timeframe`, `from`, `to` is required")
+		}
+		tfSecs := safeTFSecs(data.TimeFrame)
+		if tfSecs <= 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid timeframe: "+data.TimeFrame)
+		}
+		if (data.ToMS-data.FromMS)/int64(tfSecs*1000) > maxCalcBars {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("too many bars, max %d", maxCalcBars))
+		}
+		exs, err := orm.ParseShort(data.Exchange, data.Symbol)
+		if err != nil {
+			return err
+		}
+		_, klines, err := orm.GetOHLCV(exs, data.TimeFrame, data.FromMS, data.ToMS, maxCalcBars, false)
+		if err != nil {
+			return err
+		}
+		data.Kline = ArrKLines(klines)
+		loaded = true
+	}
+	for _, k := range data.Kline {
+		if len(k) < 6 {
+			return fiber.NewError(fiber.StatusBadRequest, "each kline requires time,open,high,low,close,volume")
+		}
+	}
 	res, err := CalcInd(data.Name, data.Kline, data.Params)
 	if err != nil {
 		return err
 	}
-	return c.JSON(fiber.Map{
+	out := fiber.Map{
 		"code": 200,
 		"data": res,
-	})
+	}
+	if loaded {
+		out["kline"] = data.Kline
+	}
+	return c.JSON(out)
+}
+
+// safeTFSecs return 0 for invalid timeframe given by client, instead of panic in TFToSecs
+// 客户端传入无效周期时返回0，避免TFToSecs中panic
+func safeTFSecs(tf string) (secs int) {
+	defer func() {
+		if recover() != nil {
+			secs = 0
+		}
+	}()
+	return utils.TFToSecs(tf)
 }
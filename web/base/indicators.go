@@ -1,9 +1,11 @@
 package base
 
 import (
+	"fmt"
 	utils2 "github.com/banbox/banexg/utils"
 	ta "github.com/banbox/banta"
 	"github.com/gofiber/fiber/v2"
+	"github.com/sasha-s/go-deadlock"
 	"math"
 	"sort"
 	"strconv"
@@ -25,7 +27,8 @@ type DrawInd struct {
 	Figures    []*Figure
 	FigureTpl  string // 客户端会使用此模板动态生成Figures
 	FigureType string // 默认空，客户端默认line
-	doCalc     func(e *ta.BarEnv, params []float64) []float64
+	// DoCalc return values of figures for the latest bar of env 返回env最新bar各figure的值
+	DoCalc func(e *ta.BarEnv, params []float64) []float64
 }
 
 type AdvInd struct {
@@ -40,7 +43,7 @@ var (
 			IsMain:     true,
 			CalcParams: []float64{5, 10, 30},
 			FigureTpl:  "{i}",
-			doCalc: func(e *ta.BarEnv, params []float64) []float64 {
+			DoCalc: func(e *ta.BarEnv, params []float64) []float64 {
 				res := make([]float64, len(params))
 				for i, p := range params {
 					res[i] = ta.RMA(e.Close, int(p)).Get(0)
@@ -53,7 +56,7 @@ var (
 			IsMain:     true,
 			CalcParams: []float64{10, 30},
 			FigureTpl:  "{i}",
-			doCalc: func(e *ta.BarEnv, params []float64) []float64 {
+			DoCalc: func(e *ta.BarEnv, params []float64) []float64 {
 				res := make([]float64, len(params))
 				for i, p := range params {
 					res[i] = ta.WMA(e.Close, int(p)).Get(0)
@@ -66,7 +69,7 @@ var (
 			IsMain:     true,
 			CalcParams: []float64{10, 30},
 			FigureTpl:  "{i}",
-			doCalc: func(e *ta.BarEnv, params []float64) []float64 {
+			DoCalc: func(e *ta.BarEnv, params []float64) []float64 {
 				res := make([]float64, len(params))
 				for i, p := range params {
 					res[i] = ta.VWMA(e.Close, e.Volume, int(p)).Get(0)
@@ -79,7 +82,7 @@ var (
 			IsMain:     true,
 			CalcParams: []float64{10, 30},
 			FigureTpl:  "{i}",
-			doCalc: func(e *ta.BarEnv, params []float64) []float64 {
+			DoCalc: func(e *ta.BarEnv, params []float64) []float64 {
 				res := make([]float64, len(params))
 				for i, p := range params {
 					res[i] = ta.HMA(e.Close, int(p)).Get(0)
@@ -92,7 +95,7 @@ var (
 			IsMain:     true,
 			CalcParams: []float64{10, 30},
 			FigureTpl:  "{i}",
-			doCalc: func(e *ta.BarEnv, params []float64) []float64 {
+			DoCalc: func(e *ta.BarEnv, params []float64) []float64 {
 				res := make([]float64, len(params))
 				for i, p := range params {
 					res[i] = ta.KAMA(e.Close, int(p)).Get(0)
@@ -107,7 +110,7 @@ var (
 			Figures: []*Figure{
 				{"alma", "ALMA: ", "line", 0},
 			},
-			doCalc: func(e *ta.BarEnv, params []float64) []float64 {
+			DoCalc: func(e *ta.BarEnv, params []float64) []float64 {
 				res := []float64{ta.ALMA(e.Close, int(params[0]), params[1], params[2]).Get(0)}
 				return res
 			},
@@ -117,7 +120,7 @@ var (
 			Figures: []*Figure{
 				{"tr", "TR: ", "line", 0},
 			},
-			doCalc: func(e *ta.BarEnv, params []float64) []float64 {
+			DoCalc: func(e *ta.BarEnv, params []float64) []float64 {
 				val := ta.TR(e.High, e.Low, e.Close).Get(0)
 				return []float64{val}
 			},
@@ -126,7 +129,7 @@ var (
 			Title:      "ATR 平均真实振幅",
 			CalcParams: []float64{14, 30},
 			FigureTpl:  "{i}",
-			doCalc: func(e *ta.BarEnv, params []float64) []float64 {
+			DoCalc: func(e *ta.BarEnv, params []float64) []float64 {
 				res := make([]float64, len(params))
 				for i, p := range params {
 					res[i] = ta.ATR(e.High, e.Low, e.Close, int(p)).Get(0)
@@ -138,7 +141,7 @@ var (
 			Title:      "StdDev 标准差",
 			CalcParams: []float64{7},
 			FigureTpl:  "{i}",
-			doCalc: func(e *ta.BarEnv, params []float64) []float64 {
+			DoCalc: func(e *ta.BarEnv, params []float64) []float64 {
 				res := make([]float64, len(params))
 				for i, p := range params {
 					dev := ta.StdDev(e.Close, int(p))
@@ -152,7 +155,7 @@ var (
 			Figures: []*Figure{
 				{"td", "TD: ", "line", 0},
 			},
-			doCalc: func(e *ta.BarEnv, params []float64) []float64 {
+			DoCalc: func(e *ta.BarEnv, params []float64) []float64 {
 				val := ta.TD(e.Close).Get(0)
 				return []float64{val}
 			},
@@ -161,7 +164,7 @@ var (
 			Title:      "ADX",
 			CalcParams: []float64{14, 30},
 			FigureTpl:  "{i}",
-			doCalc: func(e *ta.BarEnv, params []float64) []float64 {
+			DoCalc: func(e *ta.BarEnv, params []float64) []float64 {
 				res := make([]float64, len(params))
 				for i, p := range params {
 					res[i] = ta.ADX(e.High, e.Low, e.Close, int(p)).Get(0)
@@ -169,6 +172,39 @@ var (
 				return res
 			},
 		},
+		"ER":      periodInd("ER 效率比", false, []float64{10}, ta.ER, nil),
+		"CMF":     periodInd("CMF 蔡金资金流", false, []float64{20}, nil, ta.CMF),
+		"MFI":     periodInd("MFI 资金流量指标", false, []float64{14}, nil, ta.MFI),
+		"CHOP":    periodInd("CHOP 波动指数", false, []float64{14}, nil, ta.CHOP),
+		"CTI":     periodInd("CTI 相关趋势指标", false, []float64{20}, ta.CTI, nil),
+		"CMO":     periodInd("CMO 钱德动量摆动", false, []float64{9}, ta.CMO, nil),
+		"LinReg":  periodInd("LinReg 线性回归", true, []float64{20}, ta.LinReg, nil),
+		"PctRank": periodInd("PercentRank 百分比排名", false, []float64{20}, ta.PercentRank, nil),
+		"StochRSI": {
+			Title:      "StochRSI 随机RSI",
+			CalcParams: []float64{14, 14, 3, 3},
+			Figures: []*Figure{
+				{"k", "K: ", "line", 0},
+				{"d", "D: ", "line", 0},
+			},
+			DoCalc: func(e *ta.BarEnv, params []float64) []float64 {
+				k, d := ta.StochRSI(e.Close, int(params[0]), int(params[1]), int(params[2]), int(params[3]))
+				return []float64{k.Get(0), d.Get(0)}
+			},
+		},
+		"Aroon": {
+			Title:      "Aroon 阿隆指标",
+			CalcParams: []float64{14},
+			Figures: []*Figure{
+				{"up", "Up: ", "line", 0},
+				{"osc", "Osc: ", "line", 0},
+				{"dn", "Down: ", "line", 0},
+			},
+			DoCalc: func(e *ta.BarEnv, params []float64) []float64 {
+				up, osc, dn := ta.Aroon(e.High, e.Low, int(params[0]))
+				return []float64{up.Get(0), osc.Get(0), dn.Get(0)}
+			},
+		},
 	}
 	advInds = map[string]*AdvInd{
 		"ChanLun": {
@@ -203,40 +239,151 @@ var (
 		},
 	}
 	IndsCache = make([]map[string]interface{}, 0)
+	lockInds  deadlock.Mutex
 )
 
 func init() {
 	for name, ind := range baseInds {
 		ind.Name = name
-		IndsCache = append(IndsCache, ind.ToMap())
 	}
 	for name, ind := range advInds {
 		ind.Name = name
-		IndsCache = append(IndsCache, ind.ToMap())
 	}
+	refreshIndsCache()
+}
+
+/*
+periodInd
+Make an indicator with one figure for each period param, from banta functions on close or on BarEnv
+用banta中基于收盘价或BarEnv的函数创建指标，每个周期参数对应一个figure
+*/
+func periodInd(title string, isMain bool, params []float64, byClose func(obj *ta.Series, period int) *ta.Series,
+	byEnv func(e *ta.BarEnv, period int) *ta.Series) *DrawInd {
+	return &DrawInd{
+		Title:      title,
+		IsMain:     isMain,
+		CalcParams: params,
+		FigureTpl:  "{i}",
+		DoCalc: func(e *ta.BarEnv, params []float64) []float64 {
+			res := make([]float64, len(params))
+			for i, p := range params {
+				if byClose != nil {
+					res[i] = byClose(e.Close, int(p)).Get(0)
+				} else {
+					res[i] = byEnv(e, int(p)).Get(0)
+				}
+			}
+			return res
+		},
+	}
+}
+
+/*
+RegInd
+Register an indicator calculated bar by bar on BarEnv, which can be listed by `/all_inds` and charted by `/calc_ind`.
+Strategies and plugins can call this in init to expose custom indicators. Existing one with same name is replaced.
+注册一个在BarEnv上逐bar计算的指标，可通过`/all_inds`列出，通过`/calc_ind`绘制。
+策略和插件可在init中调用以暴露自定义指标。同名指标会被替换
+*/
+func RegInd(ind *DrawInd) {
+	if ind == nil || ind.Name == "" || ind.DoCalc == nil {
+		panic("RegInd: Name and DoCalc are required")
+	}
+	lockInds.Lock()
+	baseInds[ind.Name] = ind
+	delete(advInds, ind.Name)
+	lockInds.Unlock()
+	refreshIndsCache()
+}
 
-	sort.Slice(IndsCache, func(i, j int) bool {
-		a := IndsCache[i]["name"].(string)
-		b := IndsCache[j]["name"].(string)
+/*
+RegAdvInd
+Register an indicator calculated from the whole kline array at once, e.g. drawings like ChanLun.
+注册一个一次性基于整个K线数组计算的指标，如缠论等绘图
+*/
+func RegAdvInd(ind *AdvInd) {
+	if ind == nil || ind.DrawInd == nil || ind.Name == "" || ind.Calc == nil {
+		panic("RegAdvInd: Name and Calc are required")
+	}
+	lockInds.Lock()
+	advInds[ind.Name] = ind
+	delete(baseInds, ind.Name)
+	lockInds.Unlock()
+	refreshIndsCache()
+}
+
+func refreshIndsCache() {
+	lockInds.Lock()
+	defer lockInds.Unlock()
+	cache := make([]map[string]interface{}, 0, len(baseInds)+len(advInds))
+	for _, ind := range baseInds {
+		cache = append(cache, ind.ToMap())
+	}
+	for _, ind := range advInds {
+		cache = append(cache, ind.ToMap())
+	}
+	sort.Slice(cache, func(i, j int) bool {
+		a := cache[i]["name"].(string)
+		b := cache[j]["name"].(string)
 		return a < b
 	})
+	IndsCache = cache
+}
+
+// GetIndsCache return all registered indicators for clients 返回所有已注册指标供客户端使用
+func GetIndsCache() []map[string]interface{} {
+	lockInds.Lock()
+	defer lockInds.Unlock()
+	return IndsCache
 }
 
 func CalcInd(name string, kline [][]float64, params []float64) (interface{}, error) {
+	lockInds.Lock()
 	indi, _ := advInds[name]
+	ind, ok := baseInds[name]
+	lockInds.Unlock()
 	if indi != nil {
+		params, err := indi.checkParams(params)
+		if err != nil {
+			return nil, err
+		}
 		return indi.Calc(kline, params)
 	}
-	ind, ok := baseInds[name]
 	if !ok {
 		return nil, &fiber.Error{
 			Code:    fiber.StatusBadRequest,
 			Message: "unsupported indicator: " + name,
 		}
 	}
+	params, err := ind.checkParams(params)
+	if err != nil {
+		return nil, err
+	}
 	return ind.Calc(kline, params)
 }
 
+/*
+checkParams use default CalcParams when params is empty, return 400 if params are less than required
+params为空时使用默认CalcParams，参数少于要求时返回400
+*/
+func (d *DrawInd) checkParams(params []float64) ([]float64, error) {
+	if len(params) == 0 {
+		return d.CalcParams, nil
+	}
+	minNum := len(d.CalcParams)
+	if strings.Contains(d.FigureTpl, "{i}") {
+		// one figure for each param 每个参数一条线
+		minNum = min(minNum, 1)
+	}
+	if len(params) < minNum {
+		return nil, &fiber.Error{
+			Code:    fiber.StatusBadRequest,
+			Message: fmt.Sprintf("%s requires %d params, got %d", d.Name, minNum, len(params)),
+		}
+	}
+	return params, nil
+}
+
 func (d *DrawInd) Calc(kline [][]float64, params []float64) ([]map[string]float64, error) {
 	if len(kline) < 2 {
 		return nil, nil
@@ -271,7 +418,7 @@ func (d *DrawInd) Calc(kline [][]float64, params []float64) ([]map[string]float6
 		if err != nil {
 			return nil, err
 		}
-		arr := d.doCalc(env, params)
+		arr := d.DoCalc(env, params)
 		data := make(map[string]float64)
 		for i, v := range arr {
 			if i >= len(figures) {
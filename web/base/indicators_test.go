@@ -0,0 +1,197 @@
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	ta "github.com/banbox/banta"
+	"github.com/gofiber/fiber/v2"
+)
+
+func makeKline(num int) [][]float64 {
+	res := make([][]float64, 0, num)
+	for i := 0; i < num; i++ {
+		price := 100 + float64(i%7)
+		res = append(res, []float64{float64(i * 60000), price, price + 1, price - 1, price + 0.5, 10})
+	}
+	return res
+}
+
+func hasInd(name string) bool {
+	for _, it := range GetIndsCache() {
+		if it["name"] == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRegInd(t *testing.T) {
+	doCalc := func(e *ta.BarEnv, params []float64) []float64 {
+		return []float64{e.Close.Get(0)}
+	}
+	advCalc := func(kline [][]float64, params []float64) (interface{}, error) {
+		return len(kline), nil
+	}
+	cases := []struct {
+		name    string
+		reg     func()
+		ind     string
+		isAdv   bool
+		wantErr bool
+	}{
+		{"base", func() {
+			RegInd(&DrawInd{Name: "TestClose", Figures: []*Figure{{Key: "c"}}, DoCalc: doCalc})
+		}, "TestClose", false, false},
+		{"adv", func() {
+			RegAdvInd(&AdvInd{DrawInd: &DrawInd{Name: "TestAdv"}, Calc: advCalc})
+		}, "TestAdv", true, false},
+		{"adv replace base", func() {
+			RegInd(&DrawInd{Name: "TestSwap", DoCalc: doCalc})
+			RegAdvInd(&AdvInd{DrawInd: &DrawInd{Name: "TestSwap"}, Calc: advCalc})
+		}, "TestSwap", true, false},
+		{"no DoCalc", func() {
+			RegInd(&DrawInd{Name: "TestBad"})
+		}, "TestBad", false, true},
+		{"no Calc", func() {
+			RegAdvInd(&AdvInd{DrawInd: &DrawInd{Name: "TestBad"}})
+		}, "TestBad", true, true},
+	}
+	defer func() {
+		lockInds.Lock()
+		for _, c := range cases {
+			delete(baseInds, c.ind)
+			delete(advInds, c.ind)
+		}
+		lockInds.Unlock()
+		refreshIndsCache()
+	}()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			panicked := func() (res bool) {
+				defer func() {
+					res = recover() != nil
+				}()
+				c.reg()
+				return false
+			}()
+			if panicked != c.wantErr {
+				t.Fatalf("panic: %v, expect %v", panicked, c.wantErr)
+			}
+			if c.wantErr {
+				if hasInd(c.ind) {
+					t.Fatalf("%s should not be registered", c.ind)
+				}
+				return
+			}
+			if !hasInd(c.ind) {
+				t.Fatalf("%s not found in inds cache", c.ind)
+			}
+			lockInds.Lock()
+			_, inBase := baseInds[c.ind]
+			_, inAdv := advInds[c.ind]
+			lockInds.Unlock()
+			if inAdv != c.isAdv || inBase == c.isAdv {
+				t.Fatalf("%s inBase: %v, inAdv: %v", c.ind, inBase, inAdv)
+			}
+			res, err := CalcInd(c.ind, makeKline(5), nil)
+			if err != nil {
+				t.Fatalf("calc %s fail: %v", c.ind, err)
+			}
+			if c.isAdv {
+				if res != 5 {
+					t.Fatalf("calc %s expect 5, got %v", c.ind, res)
+				}
+			} else if rows, _ := res.([]map[string]float64); len(rows) != 5 {
+				t.Fatalf("calc %s expect 5 rows, got %v", c.ind, res)
+			}
+		})
+	}
+}
+
+func TestCheckParams(t *testing.T) {
+	tplInd := &DrawInd{Name: "tpl", CalcParams: []float64{5, 10}, FigureTpl: "{i}"}
+	fixInd := &DrawInd{Name: "fix", CalcParams: []float64{10, 6, 0.85}}
+	cases := []struct {
+		name    string
+		ind     *DrawInd
+		params  []float64
+		want    []float64
+		wantErr bool
+	}{
+		{"tpl default", tplInd, nil, []float64{5, 10}, false},
+		{"tpl one", tplInd, []float64{7}, []float64{7}, false},
+		{"tpl more", tplInd, []float64{7, 8, 9}, []float64{7, 8, 9}, false},
+		{"fix default", fixInd, nil, []float64{10, 6, 0.85}, false},
+		{"fix full", fixInd, []float64{9, 5, 0.8}, []float64{9, 5, 0.8}, false},
+		{"fix less", fixInd, []float64{9, 5}, nil, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := c.ind.checkParams(c.params)
+			if c.wantErr {
+				var fe *fiber.Error
+				if !errors.As(err, &fe) || fe.Code != fiber.StatusBadRequest {
+					t.Fatalf("expect 400 error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fmt.Sprint(res) != fmt.Sprint(c.want) {
+				t.Fatalf("expect %v, got %v", c.want, res)
+			}
+		})
+	}
+}
+
+func TestPostCalcInd(t *testing.T) {
+	app := fiber.New()
+	app.Post("/calc_ind", postCalcInd)
+	tooFar := int64(maxCalcBars+1) * 60000
+	cases := []struct {
+		name string
+		body map[string]interface{}
+		code int
+	}{
+		{"kline", map[string]interface{}{"name": "RMA", "kline": makeKline(20), "params": []float64{5}}, 200},
+		{"no kline", map[string]interface{}{"name": "RMA"}, 400},
+		{"bad kline", map[string]interface{}{"name": "RMA", "kline": [][]float64{{1, 2, 3}}}, 400},
+		{"bad ind", map[string]interface{}{"name": "NotExist", "kline": makeKline(20)}, 400},
+		{"bad tf", map[string]interface{}{"name": "RMA", "symbol": "BTC/USDT", "timeframe": "xx",
+			"from": 0, "to": 60000}, 400},
+		{"over max bars", map[string]interface{}{"name": "RMA", "symbol": "BTC/USDT", "timeframe": "1m",
+			"from": 0, "to": tooFar}, 400},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			body, _ := json.Marshal(c.body)
+			req := httptest.NewRequest("POST", "/calc_ind", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rsp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rsp.StatusCode != c.code {
+				t.Fatalf("expect status %d, got %d", c.code, rsp.StatusCode)
+			}
+			if c.code != 200 {
+				return
+			}
+			var out struct {
+				Data []map[string]float64 `json:"data"`
+			}
+			if err = json.NewDecoder(rsp.Body).Decode(&out); err != nil {
+				t.Fatal(err)
+			}
+			if len(out.Data) != 20 {
+				t.Fatalf("expect 20 rows, got %d", len(out.Data))
+			}
+		})
+	}
+}
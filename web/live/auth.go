@@ -17,6 +17,7 @@ import (
 	"github.com/banbox/banbot/config"
 	"github.com/banbox/banbot/orm/ormo"
 	"github.com/banbox/banbot/web/base"
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -184,6 +185,11 @@ func CreateAuthToken(user string, secret string, expHours float64) (string, erro
 func AuthMiddleware(secret string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		tokenStr := c.Get("X-Authorization")
+		if tokenStr == "" && websocket.IsWebSocketUpgrade(c) && c.Query("token") != "" {
+			// browsers can't set headers for websocket, read token from query
+			// 浏览器无法为websocket设置请求头，从query读取token
+			tokenStr = "Bearer " + c.Query("token")
+		}
 		if tokenStr == "" {
 			return fiber.NewError(fiber.StatusUnauthorized, "missing token")
 		}
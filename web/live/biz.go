@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/banbox/banbot/orm/ormo"
+	"github.com/banbox/banexg/errs"
 	"github.com/banbox/banexg/log"
 	"go.uber.org/zap"
 
@@ -24,11 +25,13 @@ import (
 	"github.com/banbox/banbot/core"
 	"github.com/banbox/banbot/exg"
 	"github.com/banbox/banbot/orm"
+	"github.com/banbox/banbot/rpc"
 	"github.com/banbox/banbot/strat"
 	"github.com/banbox/banbot/utils"
 	"github.com/banbox/banbot/web/base"
 	"github.com/banbox/banexg"
 	utils2 "github.com/banbox/banexg/utils"
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"github.com/shirou/gopsutil/v4/cpu"
 	"github.com/shirou/gopsutil/v4/mem"
@@ -44,11 +47,18 @@ func regApiBiz(api fiber.Router) {
 	api.Get("/task_pairs", getTaskPairs)
 	api.Get("/exs_map", getExsMap)
 	api.Get("/orders", getOrders)
+	api.Post("/order_note", postOrderNote)
+	api.Get("/order_tags", getOrderTags)
 	api.Post("/calc_profits", postCalcProfits)
 	api.Post("/exit_order", postExitOrder)
 	api.Post("/close_exg_pos", postCloseExgPos)
+	api.Post("/confirm", postConfirm)
+	api.Post("/cancel_confirm", postCancelConfirm)
+	api.Get("/audit", getAudit)
+	api.Get("/incidents", getIncidents)
 	api.Post("/delay_entry", postDelayEntry)
 	api.Get("/config", getConfig)
+	api.Post("/reload_config", postReloadConfig)
 	api.Get("/stg_jobs", getStratJobs)
 	api.Get("/performance", getPerformance)
 	api.Post("/start_down_trade", postStartDownTrade)
@@ -56,6 +66,7 @@ func regApiBiz(api fiber.Router) {
 	api.Get("/group_sta", getGroupSta)
 	api.Get("/log", getLog)
 	api.Get("/bot_info", getBotInfo)
+	api.Get("/events", wsUpgrade, websocket.New(wsEvents))
 }
 
 type FnAccCB = func(acc string) error
@@ -323,6 +334,7 @@ func getOrders(c *fiber.Ctx) error {
 		Source    string `query:"source" validate:"required"`
 		EnterTag  string `query:"enterTag"`
 		ExitTag   string `query:"exitTag"`
+		Tags      string `query:"tags"` // user tags separated by comma 逗号分隔的用户标签
 	}
 	var data = new(OrderArgs)
 	if err := base.VerifyArg(c, data, base.ArgQuery); err != nil {
@@ -330,7 +342,9 @@ func getOrders(c *fiber.Ctx) error {
 	}
 	type OdWrap struct {
 		*ormo.InOutOrder
-		CurPrice float64 `json:"curPrice"`
+		CurPrice float64  `json:"curPrice"`
+		Note     string   `json:"note,omitempty"`
+		Tags     []string `json:"tags,omitempty"`
 	}
 	getBotOrders := func(acc string) error {
 		sess, conn, err := ormo.Conn(orm.DbTrades, false)
@@ -368,10 +382,15 @@ func getOrders(c *fiber.Ctx) error {
 			AfterID:     data.AfterID,
 			EnterTag:    data.EnterTag,
 			ExitTag:     data.ExitTag,
+			UserTags:    splitTags(data.Tags),
 		})
 		if err != nil {
 			return err
 		}
+		notes, err := sess.GetOrderNotes(getOrderIDs(orders))
+		if err != nil {
+			return err
+		}
 		odList := make([]*OdWrap, 0, len(orders))
 		for _, od := range orders {
 			price := float64(0)
@@ -384,10 +403,14 @@ func getOrders(c *fiber.Ctx) error {
 				}
 			}
 			od.NanInfTo(0)
-			odList = append(odList, &OdWrap{
+			item := &OdWrap{
 				InOutOrder: od,
 				CurPrice:   price,
-			})
+			}
+			if note, ok := notes[od.ID]; ok {
+				item.Note, item.Tags = note.Note, note.Tags
+			}
+			odList = append(odList, item)
 		}
 		sort.Slice(odList, func(i, j int) bool {
 			return odList[i].RealEnterMS() > odList[j].RealEnterMS()
@@ -494,111 +517,212 @@ func postExitOrder(c *fiber.Ctx) error {
 	}
 
 	return wrapAccount(c, func(acc string) error {
-		openOds, lock := ormo.GetOpenODs(acc)
-		lock.Lock()
-
-		var targetOrders []*ormo.InOutOrder
 		if data.OrderID == "all" {
-			targetOrders = utils2.ValsOfMap(openOds)
-		} else {
-			orderID, err := strconv.ParseInt(data.OrderID, 10, 64)
-			if err != nil {
+			run := func() (interface{}, error) {
+				openOds, lock := ormo.GetOpenODs(acc)
+				lock.Lock()
+				targetOrders := utils2.ValsOfMap(openOds)
 				lock.Unlock()
-				return fiber.NewError(fiber.StatusBadRequest, "invalid order id")
+				return exitAccOrders(acc, targetOrders), nil
 			}
-			for _, od := range openOds {
-				if od.ID == orderID {
-					targetOrders = append(targetOrders, od)
-					break
-				}
+			if rpc.ConfirmEnabled() {
+				p := rpc.RequestConfirm(rpc.ActExitOrders, "account "+acc, apiUser(c), run)
+				return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"confirm": p})
 			}
-			if len(targetOrders) == 0 {
-				lock.Unlock()
-				return fiber.NewError(fiber.StatusNotFound, "order not found")
+			res, _ := run()
+			return c.JSON(res)
+		}
+		orderID, err := strconv.ParseInt(data.OrderID, 10, 64)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid order id")
+		}
+		openOds, lock := ormo.GetOpenODs(acc)
+		lock.Lock()
+		var targetOrders []*ormo.InOutOrder
+		for _, od := range openOds {
+			if od.ID == orderID {
+				targetOrders = append(targetOrders, od)
+				break
 			}
 		}
 		lock.Unlock()
-
-		closeNum, failNum, err := biz.CloseAccOrders(acc, targetOrders, &strat.ExitReq{
-			Tag:   core.ExitTagUserExit,
-			Force: true,
-		})
-		var errMsg string
-		if err != nil {
-			errMsg = err.Short()
+		if len(targetOrders) == 0 {
+			return fiber.NewError(fiber.StatusNotFound, "order not found")
 		}
+		return c.JSON(exitAccOrders(acc, targetOrders))
+	})
+}
 
-		return c.JSON(fiber.Map{
-			"closeNum": closeNum,
-			"failNum":  failNum,
-			"errMsg":   errMsg,
-		})
+func exitAccOrders(acc string, targetOrders []*ormo.InOutOrder) fiber.Map {
+	closeNum, failNum, err := biz.CloseAccOrders(acc, targetOrders, &strat.ExitReq{
+		Tag:   core.ExitTagUserExit,
+		Force: true,
 	})
+	var errMsg string
+	if err != nil {
+		errMsg = err.Short()
+	}
+	return fiber.Map{
+		"closeNum": closeNum,
+		"failNum":  failNum,
+		"errMsg":   errMsg,
+	}
+}
+
+type ClosePosArgs struct {
+	Symbol    string  `json:"symbol" validate:"required"`
+	Side      string  `json:"side"`
+	Amount    float64 `json:"amount"`
+	OrderType string  `json:"orderType"`
+	Price     float64 `json:"price"`
 }
 
 func postCloseExgPos(c *fiber.Ctx) error {
-	type CloseArgs struct {
-		Symbol    string  `json:"symbol" validate:"required"`
-		Side      string  `json:"side"`
-		Amount    float64 `json:"amount"`
-		OrderType string  `json:"orderType"`
-		Price     float64 `json:"price"`
-	}
-	var data = new(CloseArgs)
+	var data = new(ClosePosArgs)
 	if err := base.VerifyArg(c, data, base.ArgBody); err != nil {
 		return err
 	}
 	return wrapAccount(c, func(acc string) error {
-		var reqs []*CloseArgs
-		if data.Symbol == "all" {
-			posList, err := exg.Default.FetchPositions(nil, map[string]interface{}{
-				banexg.ParamAccount: acc,
+		if data.Symbol == "all" && rpc.ConfirmEnabled() {
+			p := rpc.RequestConfirm(rpc.ActClosePos, "account "+acc, apiUser(c), func() (interface{}, error) {
+				return closeExgPos(acc, data)
 			})
-			if err != nil {
-				return err
-			}
-			for _, p := range posList {
-				reqs = append(reqs, &CloseArgs{
-					Symbol:    p.Symbol,
-					Side:      p.Side,
-					Amount:    p.Contracts,
-					OrderType: banexg.OdTypeMarket,
-				})
-			}
-		} else {
-			reqs = append(reqs, data)
+			return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"confirm": p})
 		}
-		closeNum, doneNum := 0, 0
-		for _, q := range reqs {
-			side := "sell"
-			if q.Side == "short" {
-				side = "buy"
-			}
-			params := map[string]interface{}{
-				banexg.ParamAccount:       acc,
-				banexg.ParamClientOrderId: fmt.Sprintf("bandash_%v", rand.Intn(1000)),
-			}
-			if banexg.IsContract(core.Market) {
-				params[banexg.ParamPositionSide] = strings.ToUpper(q.Side)
-			}
-			res, err := exg.Default.CreateOrder(q.Symbol, q.OrderType, side, q.Amount, q.Price, params)
-			if err != nil {
-				return err
-			}
-			if res.ID != "" {
-				closeNum += 1
-				if res.Filled == res.Amount {
-					doneNum += 1
-				}
-			}
+		res, err := closeExgPos(acc, data)
+		if err != nil {
+			return err
 		}
-		return c.JSON(fiber.Map{
-			"closeNum": closeNum,
-			"doneNum":  doneNum,
+		return c.JSON(res)
+	})
+}
+
+func closeExgPos(acc string, data *ClosePosArgs) (fiber.Map, error) {
+	var reqs []*ClosePosArgs
+	if data.Symbol == "all" {
+		posList, err := exg.Default.FetchPositions(nil, map[string]interface{}{
+			banexg.ParamAccount: acc,
 		})
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range posList {
+			reqs = append(reqs, &ClosePosArgs{
+				Symbol:    p.Symbol,
+				Side:      p.Side,
+				Amount:    p.Contracts,
+				OrderType: banexg.OdTypeMarket,
+			})
+		}
+	} else {
+		reqs = append(reqs, data)
+	}
+	closeNum, doneNum := 0, 0
+	for _, q := range reqs {
+		side := "sell"
+		if q.Side == "short" {
+			side = "buy"
+		}
+		params := map[string]interface{}{
+			banexg.ParamAccount:       acc,
+			banexg.ParamClientOrderId: fmt.Sprintf("bandash_%v", rand.Intn(1000)),
+		}
+		if banexg.IsContract(core.Market) {
+			params[banexg.ParamPositionSide] = strings.ToUpper(q.Side)
+		}
+		res, err := exg.Default.CreateOrder(q.Symbol, q.OrderType, side, q.Amount, q.Price, params)
+		if err != nil {
+			return nil, err
+		}
+		if res.ID != "" {
+			closeNum += 1
+			if res.Filled == res.Amount {
+				doneNum += 1
+			}
+		}
+	}
+	return fiber.Map{
+		"closeNum": closeNum,
+		"doneNum":  doneNum,
+	}, nil
+}
+
+// apiUser 返回api用户在确认和审计日志中的标识
+func apiUser(c *fiber.Ctx) string {
+	return fmt.Sprintf("api:%v", c.Locals("user"))
+}
+
+func postConfirm(c *fiber.Ctx) error {
+	type ConfirmArgs struct {
+		Code string `json:"code" validate:"required"`
+	}
+	var data = new(ConfirmArgs)
+	if err := base.VerifyArg(c, data, base.ArgBody); err != nil {
+		return err
+	}
+	p, res, err := rpc.ConfirmAction(data.Code, apiUser(c))
+	if err != nil {
+		if err.Code == core.ErrAuthFail {
+			return fiber.NewError(fiber.StatusForbidden, err.Short())
+		}
+		if p == nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Short())
+		}
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"action": p.Action,
+		"result": res,
 	})
 }
 
+func postCancelConfirm(c *fiber.Ctx) error {
+	type CancelArgs struct {
+		Code string `json:"code" validate:"required"`
+	}
+	var data = new(CancelArgs)
+	if err := base.VerifyArg(c, data, base.ArgBody); err != nil {
+		return err
+	}
+	p, err := rpc.CancelAction(data.Code, apiUser(c))
+	if err != nil {
+		if p == nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Short())
+		}
+		return fiber.NewError(fiber.StatusForbidden, err.Short())
+	}
+	return c.JSON(fiber.Map{"action": p.Action})
+}
+
+func getAudit(c *fiber.Ctx) error {
+	type AuditArgs struct {
+		Limit int `query:"limit"`
+	}
+	var data = new(AuditArgs)
+	if err := base.VerifyArg(c, data, base.ArgQuery); err != nil {
+		return err
+	}
+	if data.Limit <= 0 {
+		data.Limit = 100
+	}
+	items, err := rpc.ReadAudits(data.Limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"data": items})
+}
+
+func getIncidents(c *fiber.Ctx) error {
+	type IncidentArgs struct {
+		WithResolved bool `query:"withResolved"`
+	}
+	var data = new(IncidentArgs)
+	if err := base.VerifyArg(c, data, base.ArgQuery); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"data": rpc.GetIncidents(data.WithResolved)})
+}
+
 func getIncomes(c *fiber.Ctx) error {
 	type CloseArgs struct {
 		InType    string `query:"intype" validate:"required"`
@@ -639,7 +763,7 @@ func postDelayEntry(c *fiber.Ctx) error {
 }
 
 func getConfig(c *fiber.Ctx) error {
-	// 因在线更新配置有很多限制，大多数配置无法即刻生效，故暂不提供在线修改
+	// 因在线更新配置有很多限制，大多数配置无法即刻生效，故暂不提供在线修改；修改配置文件后可调用reload_config热加载run_policy和pairlists
 	data, err := config.DumpYaml(true)
 	if err != nil {
 		return err
@@ -647,6 +771,22 @@ func getConfig(c *fiber.Ctx) error {
 	return c.SendString(string(data))
 }
 
+/*
+postReloadConfig
+Re-parse config files and hot-reload run_policy and pairlists
+重新解析配置文件，热加载run_policy和pairlists
+*/
+func postReloadConfig(c *fiber.Ctx) error {
+	if core.ReloadConfig == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "reload config is not supported now")
+	}
+	res, err := core.ReloadConfig()
+	if err != nil {
+		return err
+	}
+	return c.JSON(res)
+}
+
 func getStratJobs(c *fiber.Ctx) error {
 	type JobItem struct {
 		Pair      string  `json:"pair"`
@@ -729,17 +869,6 @@ func getExsMap(c *fiber.Ctx) error {
 	})
 }
 
-type GroupItem struct {
-	Key       string             `json:"key"`
-	HoldHours float64            `json:"holdHours"`
-	TotalCost float64            `json:"totalCost"`
-	ProfitSum float64            `json:"profitSum"`
-	ProfitPct float64            `json:"profitPct"`
-	CloseNum  int                `json:"closeNum"`
-	WinNum    int                `json:"winNum"`
-	Orders    []*ormo.InOutOrder `json:"-"`
-}
-
 func getPerformance(c *fiber.Ctx) error {
 	type PerfArgs struct {
 		GroupBy   string   `query:"groupBy"`
@@ -795,58 +924,133 @@ func getPerformance(c *fiber.Ctx) error {
 		} else {
 			return c.JSON(fiber.Map{"code": 400, "msg": "unsupport group type: " + data.GroupBy})
 		}
-		res := groupOrders(orders, odKey)
-		enterTags := groupOrders(orders, func(od *ormo.InOutOrder) string {
+		res := biz.GroupOrders(orders, odKey)
+		enterTags := biz.GroupOrders(orders, func(od *ormo.InOutOrder) string {
 			return od.EnterTag
 		})
-		exitTags := groupOrders(orders, func(od *ormo.InOutOrder) string {
+		exitTags := biz.GroupOrders(orders, func(od *ormo.InOutOrder) string {
 			return od.ExitTag
 		})
 		return c.JSON(fiber.Map{"items": res, "enters": enterTags, "exits": exitTags})
 	})
 }
 
-func groupOrders(orders []*ormo.InOutOrder, odKey func(od *ormo.InOutOrder) string) []*GroupItem {
-	var itemMap = map[string]*GroupItem{}
-	hourMSecs := float64(utils2.TFToSecs("1h") * 1000)
+/*
+groupOrdersByTags
+Group orders by user tags, an order with multiple tags is counted in each tag, orders without tags are
+grouped into "-".
+按用户标签分组订单，有多个标签的订单计入每个标签，无标签的订单归入"-"
+*/
+func groupOrdersByTags(sess *ormo.Queries, orders []*ormo.InOutOrder) ([]*biz.GroupItem, *errs.Error) {
+	notes, err := sess.GetOrderNotes(getOrderIDs(orders))
+	if err != nil {
+		return nil, err
+	}
+	tagOrders := make(map[string][]*ormo.InOutOrder)
 	for _, od := range orders {
-		key := odKey(od)
-		gp, ok := itemMap[key]
-		if !ok {
-			gp = &GroupItem{Key: key}
-			itemMap[key] = gp
+		var tags []string
+		if note, ok := notes[od.ID]; ok {
+			tags = note.Tags
 		}
-		holdHours := float64(od.RealExitMS()-od.RealEnterMS()) / hourMSecs
-		gp.CloseNum += 1
-		gp.ProfitSum += od.Profit
-		gp.TotalCost += od.EnterCost()
-		gp.HoldHours += holdHours
-		gp.Orders = append(gp.Orders, od)
-		if od.Profit > 0 {
-			gp.WinNum += 1
+		if len(tags) == 0 {
+			tags = []string{"-"}
 		}
-	}
-	for _, gp := range itemMap {
-		if gp.TotalCost > 0 {
-			gp.ProfitPct = gp.ProfitSum / gp.TotalCost
+		for _, tag := range tags {
+			tagOrders[tag] = append(tagOrders[tag], od)
 		}
-		gp.HoldHours /= float64(gp.CloseNum)
 	}
-	var res = make([]*GroupItem, 0, len(itemMap))
-	for _, v := range itemMap {
-		res = append(res, v)
+	res := make([]*biz.GroupItem, 0, len(tagOrders))
+	for tag, items := range tagOrders {
+		res = append(res, biz.GroupOrders(items, func(od *ormo.InOutOrder) string {
+			return tag
+		})...)
 	}
-	slices.SortFunc(res, func(a, b *GroupItem) int {
-		if a.Key <= b.Key {
-			return -1
-		}
-		return 1
+	slices.SortFunc(res, func(a, b *biz.GroupItem) int {
+		return strings.Compare(a.Key, b.Key)
 	})
+	return res, nil
+}
+
+func getOrderIDs(orders []*ormo.InOutOrder) []int64 {
+	res := make([]int64, 0, len(orders))
+	for _, od := range orders {
+		res = append(res, od.ID)
+	}
 	return res
 }
 
+func splitTags(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return utils.NormTags(strings.Split(text, ","))
+}
+
+/*
+postOrderNote
+Set the free-form note and user tags of a bot order, replacing the old ones
+设置机器人订单的自由备注和用户标签，替换旧的值
+*/
+func postOrderNote(c *fiber.Ctx) error {
+	type NoteArgs struct {
+		OrderID int64    `json:"orderId" validate:"required"`
+		Note    string   `json:"note"`
+		Tags    []string `json:"tags"`
+	}
+	var data = new(NoteArgs)
+	if err := base.VerifyArg(c, data, base.ArgBody); err != nil {
+		return err
+	}
+	return wrapAccount(c, func(acc string) error {
+		sess, conn, err := ormo.Conn(orm.DbTrades, true)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		iod, err_ := sess.GetIOrder(context.Background(), data.OrderID)
+		if err_ != nil || iod.TaskID != ormo.GetTaskID(acc) {
+			return fiber.NewError(fiber.StatusNotFound, "order not found")
+		}
+		tx, err_ := conn.Begin()
+		if err_ != nil {
+			return err_
+		}
+		tags := utils.NormTags(data.Tags)
+		err = sess.WithTx(tx).SetOrderNote(data.OrderID, strings.TrimSpace(data.Note), tags)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err_ = tx.Commit(); err_ != nil {
+			return err_
+		}
+		return c.JSON(fiber.Map{
+			"note": strings.TrimSpace(data.Note),
+			"tags": tags,
+		})
+	})
+}
+
+// getOrderTags list all user tags of orders in current task 列出当前任务订单的所有用户标签
+func getOrderTags(c *fiber.Ctx) error {
+	return wrapAccount(c, func(acc string) error {
+		sess, conn, err := ormo.Conn(orm.DbTrades, false)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		tags, err := sess.GetTaskOrderTags(ormo.GetTaskID(acc))
+		if err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{
+			"data": tags,
+		})
+	})
+}
+
 type GroupSta struct {
-	*GroupItem
+	*biz.GroupItem
 	Nums    []int `json:"nums"`
 	MinTime int64 `json:"minTime"`
 	MaxTime int64 `json:"maxTime"`
@@ -859,6 +1063,7 @@ func getGroupSta(c *fiber.Ctx) error {
 		EnterTag  string `query:"enterTag"`
 		ExitTag   string `query:"exitTag"`
 		GroupBy   string `query:"groupBy"`
+		Tags      string `query:"tags"`
 		StartTime string `query:"startTime"`
 		EndTime   string `query:"endTime"`
 	}
@@ -899,20 +1104,29 @@ func getGroupSta(c *fiber.Ctx) error {
 			CloseBefore: endMS,
 			EnterTag:    data.EnterTag,
 			ExitTag:     data.ExitTag,
+			UserTags:    splitTags(data.Tags),
 		})
 		if err != nil {
 			return err
 		}
-		groups := groupOrders(orders, func(od *ormo.InOutOrder) string {
-			if data.GroupBy == "strategy" {
-				return od.Strategy
-			} else if data.GroupBy == "enterTag" {
-				return fmt.Sprintf("%v:%v", od.Strategy, od.EnterTag)
-			} else if data.GroupBy == "exitTag" {
-				return fmt.Sprintf("%v:%v", od.Strategy, od.ExitTag)
+		var groups []*biz.GroupItem
+		if data.GroupBy == "tag" {
+			groups, err = groupOrdersByTags(sess, orders)
+			if err != nil {
+				return err
 			}
-			return od.Symbol
-		})
+		} else {
+			groups = biz.GroupOrders(orders, func(od *ormo.InOutOrder) string {
+				if data.GroupBy == "strategy" {
+					return od.Strategy
+				} else if data.GroupBy == "enterTag" {
+					return fmt.Sprintf("%v:%v", od.Strategy, od.EnterTag)
+				} else if data.GroupBy == "exitTag" {
+					return fmt.Sprintf("%v:%v", od.Strategy, od.ExitTag)
+				}
+				return od.Symbol
+			})
+		}
 		staList := make([]*GroupSta, 0, len(groups))
 		for _, g := range groups {
 			odNums, minTime, maxTime := opt.SampleOdNums(g.Orders, 300)
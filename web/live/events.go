@@ -0,0 +1,218 @@
+package live
+
+import (
+	"sync"
+	"time"
+
+	"github.com/banbox/banbot/biz"
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/orm/ormo"
+	"github.com/banbox/banbot/rpc"
+	"github.com/banbox/banbot/strat"
+	"github.com/banbox/banexg"
+	"github.com/banbox/banexg/log"
+	"github.com/banbox/banexg/utils"
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sasha-s/go-deadlock"
+	"go.uber.org/zap"
+)
+
+const (
+	EvtOrder   = "order"
+	EvtBalance = "balance"
+	EvtLog     = "log"
+	EvtJob     = "job"
+
+	evtQueueSize = 256
+	evtPingIntv  = 30 * time.Second
+)
+
+var (
+	evtClients   = make(map[*evtClient]bool)
+	lockEvt      deadlock.Mutex
+	evtSubsOnce  sync.Once
+	odChgActions = map[int]string{
+		strat.OdChgNew:       "new",
+		strat.OdChgEnter:     "enter",
+		strat.OdChgEnterFill: "enter_fill",
+		strat.OdChgExit:      "exit",
+		strat.OdChgExitFill:  "exit_fill",
+	}
+)
+
+/*
+LiveEvent
+A message pushed to clients of `/api/bot/events` websocket.
+推送给`/api/bot/events`websocket客户端的消息
+*/
+type LiveEvent struct {
+	Type    string      `json:"type"`
+	Account string      `json:"account,omitempty"` // empty for events of all accounts 为空表示所有账户的事件
+	Action  string      `json:"action,omitempty"`
+	Time    int64       `json:"time"`
+	Data    interface{} `json:"data"`
+}
+
+type evtClient struct {
+	conn   *websocket.Conn
+	accs   map[string]string // accounts allowed to access 允许访问的账户
+	types  map[string]bool   // subscribed event types, empty for all 订阅的事件类型，为空表示全部
+	out    chan []byte
+	drops  int
+	remote string
+}
+
+/*
+initEventSubs
+Register listeners of orders, balances, logs and jobs, which push events to websocket clients.
+注册订单、余额、日志、任务的监听，推送事件到websocket客户端
+*/
+func initEventSubs() {
+	evtSubsOnce.Do(func() {
+		strat.AddOdSub("*", func(acc string, od *ormo.InOutOrder, evt int) {
+			if !hasEvtClients() {
+				return
+			}
+			pushEvent(&LiveEvent{Type: EvtOrder, Account: acc, Action: odChgActions[evt], Data: od})
+		})
+		biz.AddBalanceSub(func(acc string, assets []*banexg.Asset) {
+			pushEvent(&LiveEvent{Type: EvtBalance, Account: acc, Data: assets})
+		})
+		rpc.AddLogSub(func(level string, line string) {
+			pushEvent(&LiveEvent{Type: EvtLog, Action: level, Data: line})
+		})
+		strat.AddJobSub(func(acc string, adds, removes []string) {
+			pushEvent(&LiveEvent{Type: EvtJob, Account: acc, Data: map[string][]string{
+				"adds":    adds,
+				"removes": removes,
+			}})
+		})
+	})
+}
+
+func hasEvtClients() bool {
+	lockEvt.Lock()
+	defer lockEvt.Unlock()
+	return len(evtClients) > 0
+}
+
+func pushEvent(evt *LiveEvent) {
+	if !hasEvtClients() {
+		return
+	}
+	evt.Time = btime.UTCStamp()
+	data, err := utils.Marshal(evt)
+	if err != nil {
+		// log with debug level to avoid recursion from log events 使用debug级别防止日志事件递归
+		log.Debug("marshal live event fail", zap.String("type", evt.Type), zap.Error(err))
+		return
+	}
+	lockEvt.Lock()
+	defer lockEvt.Unlock()
+	for c := range evtClients {
+		if !c.accepts(evt) {
+			continue
+		}
+		select {
+		case c.out <- data:
+		default:
+			// drop events for slow clients 慢速客户端丢弃事件
+			c.drops += 1
+		}
+	}
+}
+
+func (c *evtClient) accepts(evt *LiveEvent) bool {
+	if len(c.types) > 0 && !c.types[evt.Type] {
+		return false
+	}
+	if evt.Account == "" {
+		return true
+	}
+	_, ok := c.accs[evt.Account]
+	return ok
+}
+
+func wsUpgrade(c *fiber.Ctx) error {
+	if websocket.IsWebSocketUpgrade(c) {
+		return c.Next()
+	}
+	return fiber.ErrUpgradeRequired
+}
+
+/*
+wsEvents
+Push events of orders, balances, logs and job changes in real time. Events of accounts which the user has no
+role are skipped. Send `{"action": "subscribe", "types": ["order", "balance"]}` to filter event types.
+实时推送订单、余额、日志、任务变化事件。跳过用户无权限账户的事件。发送上面的消息可过滤事件类型
+*/
+func wsEvents(c *websocket.Conn) {
+	accs, _ := c.Locals("accounts").(map[string]string)
+	client := &evtClient{
+		conn:   c,
+		accs:   accs,
+		types:  make(map[string]bool),
+		out:    make(chan []byte, evtQueueSize),
+		remote: c.RemoteAddr().String(),
+	}
+	lockEvt.Lock()
+	evtClients[client] = true
+	lockEvt.Unlock()
+	log.Debug("event client joined", zap.String("ip", client.remote))
+	go client.writeLoop()
+	client.readLoop()
+	lockEvt.Lock()
+	delete(evtClients, client)
+	close(client.out)
+	lockEvt.Unlock()
+	log.Debug("event client removed", zap.String("ip", client.remote), zap.Int("drops", client.drops))
+}
+
+func (c *evtClient) readLoop() {
+	for {
+		mt, data, err := c.conn.ReadMessage()
+		if err != nil || mt == websocket.CloseMessage {
+			return
+		}
+		if mt != websocket.TextMessage {
+			continue
+		}
+		var msg = struct {
+			Action string   `json:"action"`
+			Types  []string `json:"types"`
+		}{}
+		if err = utils.Unmarshal(data, &msg, utils.JsonNumDefault); err != nil || msg.Action != "subscribe" {
+			continue
+		}
+		types := make(map[string]bool)
+		for _, t := range msg.Types {
+			types[t] = true
+		}
+		lockEvt.Lock()
+		c.types = types
+		lockEvt.Unlock()
+	}
+}
+
+func (c *evtClient) writeLoop() {
+	ticker := time.NewTicker(evtPingIntv)
+	defer ticker.Stop()
+	for {
+		select {
+		case data, ok := <-c.out:
+			if !ok {
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				_ = c.conn.Close()
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				_ = c.conn.Close()
+				return
+			}
+		}
+	}
+}
@@ -39,6 +39,8 @@ func StartApi() *errs.Error {
 	base.RegApiWebsocket(app.Group("/api/ws"))
 	regApiBiz(app.Group("/api/bot", AuthMiddleware(cfg.JWTSecretKey)))
 	regApiPub(app.Group("/api"))
+	app.Get("/metrics", getMetrics)
+	initEventSubs()
 
 	// 添加静态文件服务
 	err_ := ui.ServeStatic(app)
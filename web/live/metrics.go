@@ -0,0 +1,63 @@
+package live
+
+import (
+	"crypto/subtle"
+
+	"github.com/banbox/banbot/biz"
+	"github.com/banbox/banbot/btime"
+	"github.com/banbox/banbot/config"
+	"github.com/banbox/banbot/core"
+	"github.com/banbox/banbot/orm/ormo"
+	"github.com/banbox/banbot/strat"
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+getMetrics
+Export health metrics in prometheus text format. Require `Authorization: Bearer <metrics_token>`,
+disabled when api_server.metrics_token is empty.
+以prometheus文本格式导出健康指标。需要提供Bearer token，api_server.metrics_token为空时禁用
+*/
+func getMetrics(c *fiber.Ctx) error {
+	token := config.APIServer.MetricsToken
+	if token == "" {
+		return fiber.NewError(fiber.StatusNotFound, "metrics disabled, set api_server.metrics_token to enable")
+	}
+	auth := c.Get(fiber.HeaderAuthorization)
+	if subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+token)) != 1 {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid metrics token")
+	}
+	refreshMetrics()
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	return c.SendString(core.DumpMetrics())
+}
+
+// refreshMetrics update gauges from current state 从当前状态更新仪表指标
+func refreshMetrics() {
+	for _, name := range []string{core.MetricEquity, core.MetricUnrealizedPOL, core.MetricOpenOrders,
+		core.MetricKlineDelay, core.MetricFailOpens} {
+		core.ResetMetric(name)
+	}
+	for acc := range config.Accounts {
+		wallets := biz.GetWallets(acc)
+		core.SetMetric(core.MetricEquity, wallets.TotalLegal(nil, true), "account", acc)
+		core.SetMetric(core.MetricUnrealizedPOL, wallets.UnrealizedPOLLegal(nil), "account", acc)
+		openOds, lock := ormo.GetOpenODs(acc)
+		lock.Lock()
+		odNum := len(openOds)
+		lock.Unlock()
+		core.SetMetric(core.MetricOpenOrders, float64(odNum), "account", acc)
+	}
+	curMS := btime.TimeMS()
+	for pair, it := range core.GetPairCopiedMs() {
+		core.SetMetric(core.MetricKlineDelay, float64(curMS-it[0])/1000, "pair", pair)
+	}
+	if core.LastCopiedMs > 0 {
+		core.SetMetric(core.MetricLastKlineDelay, float64(curMS-core.LastCopiedMs)/1000)
+	}
+	for acc, items := range strat.GetAccFailOpens() {
+		for reason, num := range items {
+			core.SetMetric(core.MetricFailOpens, float64(num), "account", acc, "reason", reason)
+		}
+	}
+}